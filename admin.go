@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/magicznykacpur/chirpy/internal/apperr"
+	"github.com/magicznykacpur/chirpy/internal/auth"
+	"github.com/magicznykacpur/chirpy/internal/database"
+	"github.com/magicznykacpur/chirpy/internal/store"
+)
+
+const (
+	userUsage   = "usage: chirpy user create|promote|moderator|disable|reset-password [flags] EMAIL"
+	tokensUsage = "usage: chirpy tokens revoke-all [-user EMAIL]"
+)
+
+// runUser handles `chirpy user <command>`, passwords are read as one line
+// from in so they stay out of the shell history.
+func runUser(ctx context.Context, db store.Store, args []string, in io.Reader, out io.Writer) error {
+	if len(args) == 0 {
+		return usageError(userUsage)
+	}
+
+	command := args[0]
+	flags := flag.NewFlagSet("user "+command, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	red := flags.Bool("red", false, "give the new user Chirpy Red")
+	remove := flags.Bool("remove", false, "take moderator rights away")
+
+	err := flags.Parse(args[1:])
+	if err != nil || flags.NArg() != 1 || (*red && command != "create") || (*remove && command != "moderator") {
+		return usageError(userUsage)
+	}
+	email := flags.Arg(0)
+
+	switch command {
+	case "create":
+		hashedPassword, err := readPassword(in, email)
+		if err != nil {
+			return err
+		}
+
+		user, err := db.Createuser(ctx, database.CreateuserParams{Email: email, HashedPassword: hashedPassword})
+		if err != nil {
+			return fmt.Errorf("couldn't create user: %w", err)
+		}
+
+		if *red {
+			err = db.UpdateIsChirpyRed(ctx, user.ID)
+			if err != nil {
+				return fmt.Errorf("couldn't give %s Chirpy Red: %w", email, err)
+			}
+		}
+
+		fmt.Fprintf(out, "created user %s %s\n", user.ID, user.Email)
+		return nil
+	case "promote":
+		user, err := db.GetUserByEmail(ctx, email)
+		if err != nil {
+			return fmt.Errorf("couldn't find user %s: %w", email, err)
+		}
+
+		err = db.UpdateIsChirpyRed(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("couldn't give %s Chirpy Red: %w", email, err)
+		}
+
+		fmt.Fprintf(out, "%s now has Chirpy Red\n", email)
+		return nil
+	case "moderator":
+		user, err := db.GetUserByEmail(ctx, email)
+		if err != nil {
+			return fmt.Errorf("couldn't find user %s: %w", email, err)
+		}
+
+		if *remove {
+			_, err = db.DeleteModerator(ctx, user.ID)
+			if err != nil {
+				return fmt.Errorf("couldn't remove moderator %s: %w", email, err)
+			}
+
+			fmt.Fprintf(out, "%s is no longer a moderator\n", email)
+			return nil
+		}
+
+		err = db.CreateModerator(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("couldn't make %s a moderator: %w", email, err)
+		}
+
+		fmt.Fprintf(out, "%s is now a moderator\n", email)
+		return nil
+	case "disable":
+		user, err := db.GetUserByEmail(ctx, email)
+		if err != nil {
+			return fmt.Errorf("couldn't find user %s: %w", email, err)
+		}
+
+		err = db.DisableUser(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("couldn't disable %s: %w", email, err)
+		}
+
+		revoked, err := db.RevokeUserRefreshTokens(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("couldn't revoke refresh tokens of %s: %w", email, err)
+		}
+
+		// access tokens are not stored, they can't be revoked
+		fmt.Fprintf(out, "disabled %s and revoked %d refresh tokens, issued access tokens stay valid until they expire\n", email, revoked)
+		return nil
+	case "reset-password":
+		user, err := db.GetUserByEmail(ctx, email)
+		if err != nil {
+			return fmt.Errorf("couldn't find user %s: %w", email, err)
+		}
+
+		hashedPassword, err := readPassword(in, email)
+		if err != nil {
+			return err
+		}
+
+		err = db.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{HashedPassword: hashedPassword, ID: user.ID})
+		if err != nil {
+			return fmt.Errorf("couldn't reset password of %s: %w", email, err)
+		}
+
+		revoked, err := db.RevokeUserRefreshTokens(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("couldn't revoke refresh tokens of %s: %w", email, err)
+		}
+
+		fmt.Fprintf(out, "reset password of %s and revoked %d refresh tokens\n", email, revoked)
+		return nil
+	default:
+		return usageError(userUsage)
+	}
+}
+
+// readPassword reads a password line from in, checks it against the same
+// policy as the API and hashes it.
+func readPassword(in io.Reader, email string) (string, error) {
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("couldn't read password: %w", err)
+	}
+
+	rq := userRQ{Email: email, Password: strings.TrimRight(line, "\r\n")}
+	err = rq.validate()
+	if err != nil {
+		return "", describeValidation(err)
+	}
+
+	hashedPassword, err := auth.HashPassword(rq.Password)
+	if err != nil {
+		return "", fmt.Errorf("couldn't hash password: %w", err)
+	}
+
+	return hashedPassword, nil
+}
+
+// describeValidation spells out every field a validation error names, the
+// terminal has no problem details to show them in.
+func describeValidation(err error) error {
+	var validationErr *apperr.ValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Fields) == 0 {
+		return err
+	}
+
+	fields := make([]string, 0, len(validationErr.Fields))
+	for _, field := range validationErr.Fields {
+		fields = append(fields, field.Field+" "+field.Message)
+	}
+
+	return fmt.Errorf("%w: %s", apperr.ErrValidation, strings.Join(fields, ", "))
+}
+
+// runTokens handles `chirpy tokens <command>`.
+func runTokens(ctx context.Context, db store.Store, args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "revoke-all" {
+		return usageError(tokensUsage)
+	}
+
+	flags := flag.NewFlagSet("tokens revoke-all", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	email := flags.String("user", "", "only revoke this user's tokens")
+
+	err := flags.Parse(args[1:])
+	if err != nil || flags.NArg() > 0 {
+		return usageError(tokensUsage)
+	}
+
+	var revoked int64
+	if *email != "" {
+		user, err := db.GetUserByEmail(ctx, *email)
+		if err != nil {
+			return fmt.Errorf("couldn't find user %s: %w", *email, err)
+		}
+
+		revoked, err = db.RevokeUserRefreshTokens(ctx, user.ID)
+		if err != nil {
+			return fmt.Errorf("couldn't revoke refresh tokens of %s: %w", *email, err)
+		}
+	} else {
+		revoked, err = db.RevokeAllRefreshTokens(ctx)
+		if err != nil {
+			return fmt.Errorf("couldn't revoke refresh tokens: %w", err)
+		}
+	}
+
+	fmt.Fprintf(out, "revoked %d refresh tokens\n", revoked)
+	return nil
+}
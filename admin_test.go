@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/magicznykacpur/chirpy/internal/apperr"
+	"github.com/magicznykacpur/chirpy/internal/auth"
+	"github.com/magicznykacpur/chirpy/internal/config"
+)
+
+func TestRunUser(t *testing.T) {
+	cfg := newTestConfig(t)
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	err := runUser(ctx, cfg.db, []string{"create", "-red", "walt@example.com"}, strings.NewReader("heisenberg99\n"), &out)
+	if err != nil {
+		t.Fatalf("couldn't create user: %v", err)
+	}
+
+	user, err := cfg.db.GetUserByEmail(ctx, "walt@example.com")
+	if err != nil {
+		t.Fatalf("couldn't get created user: %v", err)
+	}
+	if !user.IsChirpyRed.Bool {
+		t.Error("user created with -red should have Chirpy Red")
+	}
+
+	err = runUser(ctx, cfg.db, []string{"create", "jesse@example.com"}, strings.NewReader("short\n"), &out)
+	if !errors.Is(err, apperr.ErrValidation) || !strings.Contains(err.Error(), "password too short") {
+		t.Errorf("weak password error mismatch --> %v <--", err)
+	}
+
+	refreshToken := createTestRefreshToken(t, cfg, user.ID, time.Hour)
+
+	err = runUser(ctx, cfg.db, []string{"reset-password", "walt@example.com"}, strings.NewReader("sayMyName42\n"), &out)
+	if err != nil {
+		t.Fatalf("couldn't reset password: %v", err)
+	}
+
+	user, _ = cfg.db.GetUserByEmail(ctx, "walt@example.com")
+	if auth.CheckPasswordHash(user.HashedPassword, "sayMyName42") != nil {
+		t.Error("password was not reset")
+	}
+
+	stored, _ := cfg.db.GetRefreshToken(ctx, refreshToken)
+	if !stored.RevokedAt.Valid {
+		t.Error("resetting the password should revoke refresh tokens")
+	}
+
+	cases := [][]string{
+		{},
+		{"create"},
+		{"promote", "-red", "walt@example.com"},
+		{"promote", "-remove", "walt@example.com"},
+		{"rename", "walt@example.com"},
+	}
+	for _, args := range cases {
+		err := runUser(ctx, cfg.db, args, strings.NewReader(""), &out)
+		if !errors.Is(err, errUsage) {
+			t.Errorf("%v: usage error mismatch --> %v != %v <--", args, err, errUsage)
+		}
+	}
+
+	err = runUser(ctx, cfg.db, []string{"promote", "nobody@example.com"}, strings.NewReader(""), &out)
+	if !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("unknown user error mismatch --> %v != %v <--", err, apperr.ErrNotFound)
+	}
+
+	for _, args := range [][]string{{"moderator", "walt@example.com"}, {"moderator", "-remove", "walt@example.com"}} {
+		err = runUser(ctx, cfg.db, args, strings.NewReader(""), &out)
+		if err != nil {
+			t.Fatalf("%v: couldn't run: %v", args, err)
+		}
+
+		isModerator, _ := cfg.db.IsModerator(ctx, user.ID)
+		if isModerator == (args[1] == "-remove") {
+			t.Errorf("%v: moderator mismatch --> %v <--", args, isModerator)
+		}
+	}
+}
+
+func TestRunUserDisable(t *testing.T) {
+	cfg := newTestConfig(t)
+	ctx := context.Background()
+
+	user := createTestUser(t, cfg, "walt@example.com")
+	refreshToken := createTestRefreshToken(t, cfg, user.ID, time.Hour)
+
+	var out bytes.Buffer
+	err := runUser(ctx, cfg.db, []string{"disable", "walt@example.com"}, strings.NewReader(""), &out)
+	if err != nil {
+		t.Fatalf("couldn't disable user: %v", err)
+	}
+	if !strings.Contains(out.String(), "revoked 1 refresh tokens") {
+		t.Errorf("output mismatch --> %q <--", out.String())
+	}
+
+	w := doRequest(t, cfg, http.MethodPost, "/api/login", `{"email": "walt@example.com", "password": "`+testPassword+`"}`, nil)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("disabled login status mismatch --> %d != %d <--", w.Code, http.StatusForbidden)
+	}
+
+	w = doRequest(t, cfg, http.MethodPost, "/api/login", `{"email": "walt@example.com", "password": "wrong"}`, nil)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("disabled wrong password status mismatch --> %d != %d <--", w.Code, http.StatusUnauthorized)
+	}
+
+	w = doRequest(t, cfg, http.MethodPost, "/api/refresh", "", http.Header{"Authorization": {"Bearer " + refreshToken}})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("disabled refresh status mismatch --> %d != %d <--", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRunTokens(t *testing.T) {
+	cfg := newTestConfig(t)
+	ctx := context.Background()
+
+	walt := createTestUser(t, cfg, "walt@example.com")
+	jesse := createTestUser(t, cfg, "jesse@example.com")
+	createTestRefreshToken(t, cfg, walt.ID, time.Hour)
+	createTestRefreshToken(t, cfg, jesse.ID, time.Hour)
+	createTestRefreshToken(t, cfg, jesse.ID, time.Hour)
+
+	cases := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"revoke-all", "-user", "walt@example.com"}, expected: "revoked 1 refresh tokens\n"},
+		{args: []string{"revoke-all"}, expected: "revoked 2 refresh tokens\n"},
+		{args: []string{"revoke-all"}, expected: "revoked 0 refresh tokens\n"},
+	}
+
+	for _, c := range cases {
+		var out bytes.Buffer
+		err := runTokens(ctx, cfg.db, c.args, &out)
+		if err != nil {
+			t.Fatalf("%v: couldn't revoke tokens: %v", c.args, err)
+		}
+		if out.String() != c.expected {
+			t.Errorf("%v: output mismatch --> %q != %q <--", c.args, out.String(), c.expected)
+		}
+	}
+
+	err := runTokens(ctx, cfg.db, []string{"revoke"}, &bytes.Buffer{})
+	if !errors.Is(err, errUsage) {
+		t.Errorf("usage error mismatch --> %v != %v <--", err, errUsage)
+	}
+}
+
+func TestRunSeed(t *testing.T) {
+	cfg := newTestConfig(t)
+	ctx := context.Background()
+	conf := config.Config{Platform: "dev", Limits: config.Limits{ChirpLength: 40}}
+
+	var out bytes.Buffer
+	err := runSeed(ctx, cfg.db, conf, []string{"-users", "3", "-chirps", "4"}, &out)
+	if err != nil {
+		t.Fatalf("couldn't seed: %v", err)
+	}
+
+	users, _ := cfg.db.GetUsers(ctx)
+	chirps, _ := cfg.db.GetAllChirps(ctx)
+	if len(users) != 3 || len(chirps) != 12 {
+		t.Errorf("seeded rows mismatch --> %d %d != 3 12 <--", len(users), len(chirps))
+	}
+
+	for _, chirp := range chirps {
+		if len([]rune(chirp.Body)) > conf.Limits.ChirpLength {
+			t.Errorf("chirp longer than the limit --> %q <--", chirp.Body)
+		}
+	}
+
+	w := doRequest(t, cfg, http.MethodPost, "/api/login", `{"email": "`+users[0].Email+`", "password": "`+defaultSeedPassword+`"}`, nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("seeded login status mismatch --> %d != %d <--", w.Code, http.StatusOK)
+	}
+
+	// the same seed picks the same emails again
+	err = runSeed(ctx, cfg.db, conf, []string{"-users", "3", "-chirps", "0"}, &out)
+	if err != nil {
+		t.Fatalf("couldn't seed twice: %v", err)
+	}
+	users, _ = cfg.db.GetUsers(ctx)
+	if len(users) != 6 {
+		t.Errorf("reseeded users mismatch --> %d != 6 <--", len(users))
+	}
+
+	conf.Platform = "prod"
+	err = runSeed(ctx, cfg.db, conf, nil, &out)
+	if err == nil || !strings.Contains(err.Error(), "refusing to seed") {
+		t.Errorf("prod seed error mismatch --> %v <--", err)
+	}
+}
@@ -0,0 +1,8 @@
+// Package api embeds the OpenAPI document describing the HTTP API, so the
+// binary serves the same contract the tests check the handlers against.
+package api
+
+import _ "embed"
+
+//go:embed openapi.json
+var OpenAPI []byte
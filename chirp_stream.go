@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/internal/apperr"
+	"github.com/magicznykacpur/chirpy/internal/config"
+	"github.com/magicznykacpur/chirpy/internal/database"
+	"github.com/magicznykacpur/chirpy/internal/health"
+	"github.com/magicznykacpur/chirpy/internal/metrics"
+	"github.com/magicznykacpur/chirpy/internal/store"
+	"github.com/magicznykacpur/chirpy/internal/stream"
+)
+
+// streamRetry is how long browsers wait before reconnecting a dropped
+// stream, in milliseconds.
+const streamRetry = 2000
+
+// streamReset tells a client that resumed too late to reload the chirps, the
+// events it missed are gone.
+const streamReset = "reset"
+
+type chirpDeletedRes struct {
+	Id     string `json:"id"`
+	UserId string `json:"user_id"`
+}
+
+// newStreamPublisher returns what the handlers publish chirp events to. The
+// postgres fan-out also starts listening for the events of every instance,
+// stop ends the listener and is nil without one.
+func newStreamPublisher(fanout string, db *sql.DB, dbUrl string, broker *stream.Broker, checker *health.Checker) (publisher stream.Publisher, stop func(context.Context) error, err error) {
+	if fanout != config.StreamFanoutPostgres {
+		return broker, nil, nil
+	}
+
+	backend, err := store.Backend(dbUrl)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if backend != store.BackendPostgres {
+		return nil, nil, fmt.Errorf("the postgres stream fan-out needs a postgres DB_URL, got %s", backend)
+	}
+
+	worker := checker.Worker("chirp event listener")
+	stop = startWorker(func(ctx context.Context) {
+		err := stream.Listen(ctx, dbUrl, broker, worker)
+		if err != nil {
+			slog.ErrorContext(ctx, "chirp event listener stopped", "error", err)
+			worker.Report(err)
+		}
+	})
+
+	return stream.NewPostgresPublisher(db), stop, nil
+}
+
+// publishChirpEvent tells the streams about a chirp change. Failing only
+// costs the live update, so it is logged instead of failing the request.
+func (cfg *apiConfig) publishChirpEvent(ctx context.Context, eventType string, chirp database.Chirp, data any) {
+	event, err := stream.NewEvent(eventType, chirp.ID, chirp.UserID, data)
+	if err == nil {
+		err = cfg.events.Publish(ctx, event)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "couldn't publish chirp event", "type", eventType, "error", err)
+	}
+}
+
+// handlerChirpStream pushes chirp events as server-sent events until the
+// client leaves, the server shuts down or the client falls so far behind
+// that the broker drops it. Dropped clients reconnect with Last-Event-ID and
+// get what they missed from the broker's history.
+func (cfg *apiConfig) handlerChirpStream(w http.ResponseWriter, r *http.Request) {
+	var authorId uuid.UUID
+	if authorParam := r.URL.Query().Get("author_id"); authorParam != "" {
+		var err error
+		authorId, err = uuid.Parse(authorParam)
+		if err != nil {
+			respondWithError(w, r, apperr.InvalidField("author_id", "id malformed"))
+			return
+		}
+	}
+
+	subscription, resumed := cfg.broker.Subscribe(authorId, r.Header.Get("Last-Event-ID"))
+	defer subscription.Close()
+
+	cfg.metrics.StreamOpened(metrics.TransportSSE)
+	defer func() { cfg.metrics.StreamClosed(metrics.TransportSSE, subscription.Lagged()) }()
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	// nginx buffers responses unless told otherwise
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	sse := sseWriter{w: w, rc: http.NewResponseController(w), timeout: cfg.streamWriteTimeout}
+
+	err := sse.write(fmt.Sprintf("retry: %d\n\n", streamRetry))
+	if err == nil && !resumed {
+		err = sse.write("event: " + streamReset + "\ndata: {}\n\n")
+	}
+	for _, event := range subscription.Replay {
+		if err != nil {
+			break
+		}
+		err = sse.event(event)
+	}
+
+	heartbeat := time.NewTicker(cfg.streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for err == nil {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			err = sse.write(": heartbeat\n\n")
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return
+			}
+			err = sse.event(event)
+		}
+	}
+
+	slog.InfoContext(r.Context(), "chirp stream closed", "error", err)
+}
+
+// sseWriter writes server-sent events and flushes each one. Every write gets
+// its own deadline, the server's WriteTimeout would otherwise cut the stream
+// off, and a client that stops reading is let go once it passes.
+type sseWriter struct {
+	w       io.Writer
+	rc      *http.ResponseController
+	timeout time.Duration
+}
+
+func (s sseWriter) write(text string) error {
+	// not every writer supports deadlines, e.g. in tests
+	s.rc.SetWriteDeadline(time.Now().Add(s.timeout))
+
+	_, err := io.WriteString(s.w, text)
+	if err != nil {
+		return err
+	}
+
+	return s.rc.Flush()
+}
+
+func (s sseWriter) event(event stream.Event) error {
+	return s.write(fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data))
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sseEvent struct {
+	id, event, data, comment string
+}
+
+// openStream connects to the chirp stream of server and returns a reader
+// for its events, the response body is closed with the test.
+func openStream(t *testing.T, server *httptest.Server, query, lastEventId string) *bufio.Reader {
+	t.Helper()
+
+	r, err := http.NewRequest(http.MethodGet, server.URL+"/api/chirps/stream"+query, nil)
+	if err != nil {
+		t.Fatalf("couldn't create request: %v", err)
+	}
+	if lastEventId != "" {
+		r.Header.Set("Last-Event-ID", lastEventId)
+	}
+
+	res, err := server.Client().Do(r)
+	if err != nil {
+		t.Fatalf("couldn't open stream: %v", err)
+	}
+	t.Cleanup(func() { res.Body.Close() })
+
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("stream response mismatch --> %d %q <--", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+
+	return bufio.NewReader(res.Body)
+}
+
+// readEvent reads the next block of fields, the retry hint is skipped.
+func readEvent(t *testing.T, reader *bufio.Reader) (sseEvent, error) {
+	t.Helper()
+
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return event, err
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if event == (sseEvent{}) {
+				continue
+			}
+			return event, nil
+		}
+
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "id":
+			event.id = value
+		case "event":
+			event.event = value
+		case "data":
+			event.data = value
+		case "":
+			event.comment = value
+		}
+	}
+}
+
+func TestHandlerChirpStream(t *testing.T) {
+	cfg := newTestConfig(t)
+	server := httptest.NewServer(cfg.routes())
+	t.Cleanup(server.Close)
+
+	walt := createTestUser(t, cfg, "walt@example.com")
+	jesse := createTestUser(t, cfg, "jesse@example.com")
+
+	everyone := openStream(t, server, "", "")
+	waltOnly := openStream(t, server, "?author_id="+walt.ID.String(), "")
+
+	doRequest(t, cfg, http.MethodPost, "/api/chirps", `{"body": "Jesse, we need to cook"}`, bearerHeader(t, walt.ID))
+	doRequest(t, cfg, http.MethodPost, "/api/chirps", `{"body": "Yeah, science!"}`, bearerHeader(t, jesse.ID))
+
+	created, err := readEvent(t, waltOnly)
+	if err != nil {
+		t.Fatalf("couldn't read event: %v", err)
+	}
+
+	var chirp chirpRes
+	err = json.Unmarshal([]byte(created.data), &chirp)
+	if err != nil || created.event != "chirp.created" || chirp.Body != "Jesse, we need to cook" || chirp.UserId != walt.ID.String() {
+		t.Errorf("created event mismatch --> %+v %v <--", created, err)
+	}
+
+	w := doRequest(t, cfg, http.MethodDelete, "/api/chirps/"+chirp.Id, "", bearerHeader(t, walt.ID))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("couldn't delete chirp: %d", w.Code)
+	}
+
+	// jesse's chirp is filtered out
+	deleted, err := readEvent(t, waltOnly)
+	if err != nil || deleted.event != "chirp.deleted" || !strings.Contains(deleted.data, chirp.Id) {
+		t.Errorf("deleted event mismatch --> %+v %v <--", deleted, err)
+	}
+
+	var types []string
+	for range 3 {
+		event, err := readEvent(t, everyone)
+		if err != nil {
+			t.Fatalf("couldn't read event: %v", err)
+		}
+		types = append(types, event.event)
+	}
+	if strings.Join(types, " ") != "chirp.created chirp.created chirp.deleted" {
+		t.Errorf("everyone events mismatch --> %v <--", types)
+	}
+
+	// resuming after the first event replays the other two
+	resumed := openStream(t, server, "", created.id)
+	for _, expected := range []string{"chirp.created", "chirp.deleted"} {
+		event, err := readEvent(t, resumed)
+		if err != nil || event.event != expected {
+			t.Errorf("replayed event mismatch --> %+v %v != %s <--", event, err, expected)
+		}
+	}
+
+	reset := openStream(t, server, "", "forgotten")
+	event, err := readEvent(t, reset)
+	if err != nil || event.event != streamReset {
+		t.Errorf("reset event mismatch --> %+v %v <--", event, err)
+	}
+
+	// shutting down ends every stream
+	cfg.broker.Close()
+	_, err = readEvent(t, everyone)
+	if err != io.EOF {
+		t.Errorf("stream should end on shutdown --> %v <--", err)
+	}
+}
+
+func TestHandlerChirpStreamOutlivesWriteTimeout(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.streamHeartbeat = 50 * time.Millisecond
+
+	server := httptest.NewUnstartedServer(middlewareRequestId(cfg.routes()))
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	t.Cleanup(server.Close)
+
+	stream := openStream(t, server, "", "")
+
+	deadline := time.Now().Add(4 * server.Config.WriteTimeout)
+	for time.Now().Before(deadline) {
+		event, err := readEvent(t, stream)
+		if err != nil || event.comment != "heartbeat" {
+			t.Fatalf("heartbeat mismatch --> %+v %v <--", event, err)
+		}
+	}
+}
@@ -1,62 +1,198 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"io"
+	"errors"
+	"fmt"
 	"net/http"
-	"os"
 	"sort"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/internal/apperr"
 	"github.com/magicznykacpur/chirpy/internal/auth"
 	"github.com/magicznykacpur/chirpy/internal/cleaner"
 	"github.com/magicznykacpur/chirpy/internal/database"
+	"github.com/magicznykacpur/chirpy/internal/entities"
+	"github.com/magicznykacpur/chirpy/internal/logging"
+	"github.com/magicznykacpur/chirpy/internal/stream"
+	"github.com/magicznykacpur/chirpy/internal/validate"
 )
 
 type createChirpRQ struct {
 	Body string `json:"body"`
 }
 
+func (rq createChirpRQ) validate(maxLength int) error {
+	return validate.Check(
+		validate.Field("body", rq.Body, validate.Required(), validate.MaxLength(maxLength)),
+	)
+}
+
 type chirpRes struct {
-	Id        string    `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserId    string    `json:"user_id"`
+	Id        string      `json:"id"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	Body      string      `json:"body"`
+	UserId    string      `json:"user_id"`
+	Entities  []entityRes `json:"entities"`
 }
 
-func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request) {
-	defer r.Body.Close()
+// entityRes is a mention, hashtag or url in a chirp body. Start and End
+// count runes, End is exclusive.
+type entityRes struct {
+	Type   string `json:"type"`
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+	Value  string `json:"value"`
+	UserId string `json:"user_id,omitempty"`
+}
+
+func newChirpRes(chirp database.Chirp, entities []database.ChirpEntity) chirpRes {
+	response := chirpRes{
+		Id:        chirp.ID.String(),
+		CreatedAt: chirp.CreatedAt,
+		UpdatedAt: chirp.UpdatedAt,
+		Body:      chirp.Body,
+		UserId:    chirp.UserID.String(),
+		Entities:  []entityRes{},
+	}
+
+	for _, entity := range entities {
+		res := entityRes{
+			Type:  entity.Kind,
+			Start: int(entity.StartOffset),
+			End:   int(entity.EndOffset),
+			Value: entity.Value,
+		}
+		if entity.UserID.Valid {
+			res.UserId = entity.UserID.UUID.String()
+		}
+		response.Entities = append(response.Entities, res)
+	}
+
+	return response
+}
+
+// chirpResList loads the entities of chirps in one query and returns their
+// responses in the same order.
+func (cfg *apiConfig) chirpResList(ctx context.Context, chirps []database.Chirp) ([]chirpRes, error) {
+	ids := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		ids = append(ids, chirp.ID)
+	}
+
+	entities, err := cfg.db.GetChirpEntities(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't retrieve chirp entities: %w", err)
+	}
+
+	byChirp := map[uuid.UUID][]database.ChirpEntity{}
+	for _, entity := range entities {
+		byChirp[entity.ChirpID] = append(byChirp[entity.ChirpID], entity)
+	}
+
+	responses := make([]chirpRes, 0, len(chirps))
+	for _, chirp := range chirps {
+		responses = append(responses, newChirpRes(chirp, byChirp[chirp.ID]))
+	}
+
+	return responses, nil
+}
+
+// createChirpEntities stores the entities parsed from the body of chirp.
+// Mentions of usernames nobody has are left as plain text.
+func (cfg *apiConfig) createChirpEntities(ctx context.Context, chirp database.Chirp) ([]database.ChirpEntity, error) {
+	stored := []database.ChirpEntity{}
+	for _, entity := range entities.Parse(chirp.Body) {
+		arg := database.CreateChirpEntityParams{
+			ChirpID:     chirp.ID,
+			Kind:        entity.Type,
+			StartOffset: int32(entity.Start),
+			EndOffset:   int32(entity.End),
+			Value:       entity.Value,
+		}
+
+		if entity.Type == entities.Mention {
+			user, err := cfg.db.GetUserByUsername(ctx, entity.Value)
+			if errors.Is(err, apperr.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			arg.UserID = uuid.NullUUID{UUID: user.ID, Valid: true}
+		}
+
+		err := cfg.db.CreateChirpEntity(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		stored = append(stored, database.ChirpEntity{
+			ChirpID:     arg.ChirpID,
+			Kind:        arg.Kind,
+			StartOffset: arg.StartOffset,
+			EndOffset:   arg.EndOffset,
+			Value:       arg.Value,
+			UserID:      arg.UserID,
+		})
+	}
+
+	return stored, nil
+}
+
+// notifyMentions tells every user mentioned in chirp about it once, except
+// its author.
+func (cfg *apiConfig) notifyMentions(ctx context.Context, chirp database.Chirp, chirpEntities []database.ChirpEntity) {
+	notified := map[uuid.UUID]bool{chirp.UserID: true}
+	for _, entity := range chirpEntities {
+		if entity.Kind != entities.Mention || notified[entity.UserID.UUID] {
+			continue
+		}
+		notified[entity.UserID.UUID] = true
+
+		cfg.notify(ctx, database.CreateNotificationParams{
+			UserID:  entity.UserID.UUID,
+			Kind:    notificationMention,
+			ActorID: uuid.NullUUID{UUID: chirp.UserID, Valid: true},
+			ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		})
+	}
+}
 
+func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		writeError(err, "unauthorized", http.StatusUnauthorized, w)
+		respondWithError(w, r, apperr.Unauthorized("unauthorized", err))
 		return
 	}
 
-	userId, err := auth.ValidateJWT(token, os.Getenv("JWT_SECRET"))
+	userId, err := auth.ValidateJWT(token, cfg.jwtSecret)
 	if err != nil {
-		writeError(err, "cannot validate jwt token", http.StatusUnauthorized, w)
+		respondWithError(w, r, apperr.Unauthorized("cannot validate jwt token", err))
 		return
 	}
 
-	bytes, err := io.ReadAll(r.Body)
+	logging.SetUserId(r.Context(), userId)
+
+	var createChirpRQ createChirpRQ
+	err = decodeJSON(w, r, maxChirpBodyBytes, &createChirpRQ)
 	if err != nil {
-		writeError(err, "request body invalid", http.StatusBadRequest, w)
+		respondWithError(w, r, err)
 		return
 	}
 
-	var createChirpRQ createChirpRQ
-	err = json.Unmarshal(bytes, &createChirpRQ)
-	if err != nil || createChirpRQ.Body == "" {
-		writeError(err, "bad request, check if chirp contains body", http.StatusBadRequest, w)
+	createChirpRQ.Body, err = cleaner.Normalize(createChirpRQ.Body)
+	if err != nil {
+		respondWithError(w, r, apperr.InvalidField("body", "must be valid unicode text"))
 		return
 	}
 
-	if len(createChirpRQ.Body) > 140 {
-		writeError(nil, "chirp body too long, max 140 characters", http.StatusBadRequest, w)
+	err = createChirpRQ.validate(cfg.chirpMaxLength)
+	if err != nil {
+		respondWithError(w, r, err)
 		return
 	}
 
@@ -67,21 +203,27 @@ func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request)
 		},
 	)
 	if err != nil {
-		writeError(err, "couldn't create chirp", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't create chirp: %w", err))
 		return
 	}
 
-	response := chirpRes{
-		Id:        chirp.ID.String(),
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserId:    chirp.UserID.String(),
+	// the store has no transactions, a chirp without its entities would
+	// never show up under its hashtags
+	chirpEntities, err := cfg.createChirpEntities(r.Context(), chirp)
+	if err != nil {
+		cfg.db.DeleteChirp(r.Context(), database.DeleteChirpParams{ID: chirp.ID, UserID: userId})
+		respondWithError(w, r, fmt.Errorf("couldn't create chirp entities: %w", err))
+		return
 	}
+	cfg.metrics.ChirpCreated()
+	cfg.notifyMentions(r.Context(), chirp, chirpEntities)
+
+	response := newChirpRes(chirp, chirpEntities)
+	cfg.publishChirpEvent(r.Context(), stream.ChirpCreated, chirp, response)
 
 	responseBytes, err := json.Marshal(response)
 	if err != nil {
-		writeError(err, "couldn't marshal response", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't marshal response: %w", err))
 		return
 	}
 
@@ -110,7 +252,7 @@ func (cfg *apiConfig) handlerGetAllChirps(w http.ResponseWriter, r *http.Request
 	if authorParam != "" {
 		authorId, err = uuid.Parse(authorParam)
 		if err != nil {
-			writeError(err, "id malformed", http.StatusBadRequest, w)
+			respondWithError(w, r, apperr.InvalidField("author_id", "id malformed"))
 			return
 		}
 	}
@@ -123,6 +265,34 @@ func (cfg *apiConfig) handlerGetAllChirps(w http.ResponseWriter, r *http.Request
 		chirps, err = cfg.db.GetAllChirps(r.Context())
 	}
 
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't retrieve chirps: %w", err))
+		return
+	}
+
+	cfg.respondWithChirps(w, r, chirps, sortParam)
+}
+
+// handlerGetChirpsByHashtag lists the chirps tagged with the tag in the
+// path, given with or without its #, in any case.
+func (cfg *apiConfig) handlerGetChirpsByHashtag(w http.ResponseWriter, r *http.Request) {
+	tag, ok := entities.NormalizeHashtag(r.PathValue("tag"))
+	if !ok {
+		respondWithError(w, r, apperr.InvalidField("tag", "must be a hashtag like chirpy"))
+		return
+	}
+
+	chirps, err := cfg.db.GetChirpsByHashtag(r.Context(), tag)
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't retrieve chirps: %w", err))
+		return
+	}
+
+	cfg.respondWithChirps(w, r, chirps, r.URL.Query().Get("sort"))
+}
+
+// respondWithChirps writes chirps, oldest first unless sortParam is desc.
+func (cfg *apiConfig) respondWithChirps(w http.ResponseWriter, r *http.Request, chirps []database.Chirp, sortParam string) {
 	if sortParam == "desc" {
 		sort.Slice(chirps,
 			func(i, j int) bool {
@@ -131,31 +301,19 @@ func (cfg *apiConfig) handlerGetAllChirps(w http.ResponseWriter, r *http.Request
 		)
 	}
 
+	chirpResList, err := cfg.chirpResList(r.Context(), chirps)
 	if err != nil {
-		writeError(err, "couldn't retrieve chirps", http.StatusInternalServerError, w)
+		respondWithError(w, r, err)
 		return
 	}
 
-	chirpResList := []chirpRes{}
-	for _, chirp := range chirps {
-		chirpResList = append(chirpResList,
-			chirpRes{
-				Id:        chirp.ID.String(),
-				CreatedAt: chirp.CreatedAt,
-				UpdatedAt: chirp.UpdatedAt,
-				Body:      chirp.Body,
-				UserId:    chirp.UserID.String(),
-			},
-		)
-	}
-
 	responseBytes, err := json.Marshal(chirpResList)
 	if err != nil {
-		writeError(err, "couldn't marshall response", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't marshal response: %w", err))
 		return
 	}
 
-	w.Header().Set("Contet-Type", "application/json")
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write(responseBytes)
 }
@@ -163,32 +321,25 @@ func (cfg *apiConfig) handlerGetAllChirps(w http.ResponseWriter, r *http.Request
 func (cfg *apiConfig) handlerGetChirpById(w http.ResponseWriter, r *http.Request) {
 	chirpId, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		writeError(err, "couldn't parse id", http.StatusBadRequest, w)
+		respondWithError(w, r, apperr.InvalidField("id", "couldn't parse id"))
 		return
 	}
 
 	chirp, err := cfg.db.GetChirp(r.Context(), chirpId)
-	if err != nil && strings.Contains(err.Error(), "no rows in result set") {
-		writeError(nil, "chirp not found", http.StatusNotFound, w)
+	if err != nil {
+		respondWithError(w, r, err)
 		return
 	}
 
-	if err != nil && !strings.Contains(err.Error(), "no rows in result set") {
-		writeError(err, "couldn't retrieve chirp", http.StatusInternalServerError, w)
+	chirpResList, err := cfg.chirpResList(r.Context(), []database.Chirp{chirp})
+	if err != nil {
+		respondWithError(w, r, err)
 		return
 	}
 
-	chirpRes := chirpRes{
-		Id:        chirp.ID.String(),
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserId:    chirp.UserID.String(),
-	}
-
-	responseBytes, err := json.Marshal(chirpRes)
+	responseBytes, err := json.Marshal(chirpResList[0])
 	if err != nil {
-		writeError(err, "couldn't marshal response", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't marshal response: %w", err))
 		return
 	}
 
@@ -200,30 +351,33 @@ func (cfg *apiConfig) handlerGetChirpById(w http.ResponseWriter, r *http.Request
 func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		writeError(err, "couldn't get bearer token", http.StatusUnauthorized, w)
+		respondWithError(w, r, apperr.Unauthorized("couldn't get bearer token", err))
 		return
 	}
 
-	userId, err := auth.ValidateJWT(token, os.Getenv("JWT_SECRET"))
+	userId, err := auth.ValidateJWT(token, cfg.jwtSecret)
 	if err != nil {
-		writeError(err, "token invalid", http.StatusUnauthorized, w)
+		respondWithError(w, r, apperr.Unauthorized("token invalid", err))
 		return
 	}
 
+	logging.SetUserId(r.Context(), userId)
+
 	defer r.Body.Close()
 	chirpId, err := uuid.Parse(r.PathValue("id"))
 	if err != nil {
-		writeError(err, "couldn't parse id", http.StatusBadRequest, w)
+		respondWithError(w, r, apperr.InvalidField("id", "couldn't parse id"))
 		return
 	}
 
 	chirp, err := cfg.db.GetChirp(r.Context(), chirpId)
-	if err != nil && strings.Contains(err.Error(), "sql: no rows in result set") {
-		writeError(nil, "chirp not found", http.StatusNotFound, w)
+	if err != nil {
+		respondWithError(w, r, err)
 		return
 	}
+
 	if chirp.UserID != userId {
-		writeError(err, "cannot delete other users chirps", http.StatusForbidden, w)
+		respondWithError(w, r, apperr.Forbidden("cannot delete other users chirps"))
 		return
 	}
 
@@ -234,9 +388,10 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 		},
 	)
 	if err != nil {
-		writeError(err, "couldn't delete chirp", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't delete chirp: %w", err))
 		return
 	}
+	cfg.publishChirpEvent(r.Context(), stream.ChirpDeleted, chirp, chirpDeletedRes{Id: chirp.ID.String(), UserId: chirp.UserID.String()})
 
 	w.WriteHeader(http.StatusNoContent)
 }
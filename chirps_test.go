@@ -0,0 +1,413 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestHandlerCreateChirp(t *testing.T) {
+	cfg := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com")
+
+	cases := []struct {
+		name         string
+		body         string
+		header       http.Header
+		expected     int
+		expectedBody string
+	}{
+		{
+			name:         "valid chirp",
+			body:         `{"body": "hello chirpy"}`,
+			header:       bearerHeader(t, user.ID),
+			expected:     http.StatusCreated,
+			expectedBody: "hello chirpy",
+		},
+		{
+			name:         "bad words are cleaned",
+			body:         `{"body": "what a kerfuffle, Sharbert"}`,
+			header:       bearerHeader(t, user.ID),
+			expected:     http.StatusCreated,
+			expectedBody: "what a ****, ****",
+		},
+		{
+			name:     "missing token",
+			body:     `{"body": "hello chirpy"}`,
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "invalid token",
+			body:     `{"body": "hello chirpy"}`,
+			header:   http.Header{"Authorization": {"Bearer not-a-jwt"}},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "empty body",
+			body:     `{"body": ""}`,
+			header:   bearerHeader(t, user.ID),
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "malformed json",
+			body:     `{"body":`,
+			header:   bearerHeader(t, user.ID),
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:         "multi-byte characters count once",
+			body:         `{"body": "` + strings.Repeat("é", 140) + `"}`,
+			header:       bearerHeader(t, user.ID),
+			expected:     http.StatusCreated,
+			expectedBody: strings.Repeat("é", 140),
+		},
+		{
+			name:         "emoji count as one character",
+			body:         `{"body": "` + strings.Repeat("\U0001f600", 140) + `"}`,
+			header:       bearerHeader(t, user.ID),
+			expected:     http.StatusCreated,
+			expectedBody: strings.Repeat("\U0001f600", 140),
+		},
+		{
+			name:         "text is normalised",
+			body:         `{"body": "cafe\u0301\u200b"}`,
+			header:       bearerHeader(t, user.ID),
+			expected:     http.StatusCreated,
+			expectedBody: "café",
+		},
+		{
+			name:         "combining marks don't hide bad words",
+			body:         `{"body": "what a ke\u0301rf\u200buffle"}`,
+			header:       bearerHeader(t, user.ID),
+			expected:     http.StatusCreated,
+			expectedBody: "what a ****",
+		},
+		{
+			name:     "only invisible characters",
+			body:     `{"body": "\u200b\u200d"}`,
+			header:   bearerHeader(t, user.ID),
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid utf-8",
+			body:     "{\"body\": \"hello \xff\"}",
+			header:   bearerHeader(t, user.ID),
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "lone surrogate",
+			body:     `{"body": "hello \ud800"}`,
+			header:   bearerHeader(t, user.ID),
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "too long",
+			body:     `{"body": "` + strings.Repeat("a", 141) + `"}`,
+			header:   bearerHeader(t, user.ID),
+			expected: http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		w := doRequest(t, cfg, http.MethodPost, "/api/chirps", c.body, c.header)
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+			continue
+		}
+
+		if c.expected != http.StatusCreated {
+			continue
+		}
+
+		var chirp chirpRes
+		if err := json.Unmarshal(w.Body.Bytes(), &chirp); err != nil {
+			t.Fatalf("%s: couldn't unmarshal response: %v", c.name, err)
+		}
+
+		if chirp.Body != c.expectedBody {
+			t.Errorf("%s: body mismatch --> %s != %s <--", c.name, chirp.Body, c.expectedBody)
+		}
+
+		if chirp.UserId != user.ID.String() {
+			t.Errorf("%s: user id mismatch --> %s != %s <--", c.name, chirp.UserId, user.ID)
+		}
+	}
+}
+
+func TestHandlerGetAllChirps(t *testing.T) {
+	cfg := newTestConfig(t)
+	walt := createTestUser(t, cfg, "walt@example.com")
+	jesse := createTestUser(t, cfg, "jesse@example.com")
+
+	first := createTestChirp(t, cfg, walt.ID, "first")
+	time.Sleep(time.Millisecond)
+	second := createTestChirp(t, cfg, jesse.ID, "second")
+	time.Sleep(time.Millisecond)
+	third := createTestChirp(t, cfg, walt.ID, "third")
+
+	cases := []struct {
+		name     string
+		target   string
+		expected int
+		ids      []uuid.UUID
+	}{
+		{
+			name:     "all ascending",
+			target:   "/api/chirps",
+			expected: http.StatusOK,
+			ids:      []uuid.UUID{first.ID, second.ID, third.ID},
+		},
+		{
+			name:     "all descending",
+			target:   "/api/chirps?sort=desc",
+			expected: http.StatusOK,
+			ids:      []uuid.UUID{third.ID, second.ID, first.ID},
+		},
+		{
+			name:     "by author",
+			target:   "/api/chirps?author_id=" + walt.ID.String(),
+			expected: http.StatusOK,
+			ids:      []uuid.UUID{first.ID, third.ID},
+		},
+		{
+			name:     "by author descending",
+			target:   "/api/chirps?author_id=" + walt.ID.String() + "&sort=desc",
+			expected: http.StatusOK,
+			ids:      []uuid.UUID{third.ID, first.ID},
+		},
+		{
+			name:     "unknown author",
+			target:   "/api/chirps?author_id=" + uuid.NewString(),
+			expected: http.StatusOK,
+			ids:      []uuid.UUID{},
+		},
+		{
+			name:     "malformed author",
+			target:   "/api/chirps?author_id=walt",
+			expected: http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		w := doRequest(t, cfg, http.MethodGet, c.target, "", nil)
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+			continue
+		}
+
+		if c.expected != http.StatusOK {
+			continue
+		}
+
+		var chirps []chirpRes
+		if err := json.Unmarshal(w.Body.Bytes(), &chirps); err != nil {
+			t.Fatalf("%s: couldn't unmarshal response: %v", c.name, err)
+		}
+
+		if len(chirps) != len(c.ids) {
+			t.Errorf("%s: length mismatch --> %d != %d <--", c.name, len(chirps), len(c.ids))
+			continue
+		}
+
+		for i, id := range c.ids {
+			if chirps[i].Id != id.String() {
+				t.Errorf("%s: chirp %d mismatch --> %s != %s <--", c.name, i, chirps[i].Id, id)
+			}
+		}
+	}
+}
+
+func TestHandlerGetChirpById(t *testing.T) {
+	cfg := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com")
+	chirp := createTestChirp(t, cfg, user.ID, "hello chirpy")
+
+	cases := []struct {
+		name     string
+		id       string
+		expected int
+	}{
+		{name: "existing", id: chirp.ID.String(), expected: http.StatusOK},
+		{name: "missing", id: uuid.NewString(), expected: http.StatusNotFound},
+		{name: "malformed", id: "not-a-uuid", expected: http.StatusBadRequest},
+	}
+
+	for _, c := range cases {
+		w := doRequest(t, cfg, http.MethodGet, "/api/chirps/"+c.id, "", nil)
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+			continue
+		}
+
+		if c.expected != http.StatusOK {
+			continue
+		}
+
+		var response chirpRes
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: couldn't unmarshal response: %v", c.name, err)
+		}
+
+		if response.Id != chirp.ID.String() || response.Body != chirp.Body {
+			t.Errorf("%s: chirp mismatch --> %+v <--", c.name, response)
+		}
+	}
+}
+
+func TestHandlerDeleteChirp(t *testing.T) {
+	cfg := newTestConfig(t)
+	walt := createTestUser(t, cfg, "walt@example.com")
+	jesse := createTestUser(t, cfg, "jesse@example.com")
+
+	waltsChirp := createTestChirp(t, cfg, walt.ID, "say my name")
+	jessesChirp := createTestChirp(t, cfg, jesse.ID, "yeah science")
+
+	cases := []struct {
+		name     string
+		id       string
+		header   http.Header
+		expected int
+	}{
+		{
+			name:     "missing token",
+			id:       waltsChirp.ID.String(),
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "invalid token",
+			id:       waltsChirp.ID.String(),
+			header:   http.Header{"Authorization": {"Bearer not-a-jwt"}},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "malformed id",
+			id:       "not-a-uuid",
+			header:   bearerHeader(t, walt.ID),
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "missing chirp",
+			id:       uuid.NewString(),
+			header:   bearerHeader(t, walt.ID),
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "other users chirp",
+			id:       jessesChirp.ID.String(),
+			header:   bearerHeader(t, walt.ID),
+			expected: http.StatusForbidden,
+		},
+		{
+			name:     "own chirp",
+			id:       waltsChirp.ID.String(),
+			header:   bearerHeader(t, walt.ID),
+			expected: http.StatusNoContent,
+		},
+		{
+			name:     "already deleted",
+			id:       waltsChirp.ID.String(),
+			header:   bearerHeader(t, walt.ID),
+			expected: http.StatusNotFound,
+		},
+	}
+
+	for _, c := range cases {
+		w := doRequest(t, cfg, http.MethodDelete, "/api/chirps/"+c.id, "", c.header)
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+		}
+	}
+}
+
+func TestHandlerChirpEntities(t *testing.T) {
+	cfg := newTestConfig(t)
+	walt := createTestUser(t, cfg, "walt@example.com")
+	jesse := createTestUser(t, cfg, "jesse@example.com")
+
+	w := doRequest(t, cfg, http.MethodPut, "/api/users",
+		`{"email": "jesse@example.com", "password": "`+testPassword+`", "username": "jesse_p"}`, bearerHeader(t, jesse.ID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("set username status mismatch --> %d != %d <--", w.Code, http.StatusOK)
+	}
+
+	w = doRequest(t, cfg, http.MethodPost, "/api/chirps",
+		`{"body": "@jesse_p @jesse_p @nobody #Chemistry class https://example.com"}`, bearerHeader(t, walt.ID))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status mismatch --> %d != %d <--", w.Code, http.StatusCreated)
+	}
+
+	var created chirpRes
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("couldn't unmarshal response: %v", err)
+	}
+
+	expected := []entityRes{
+		{Type: "mention", Start: 0, End: 8, Value: "jesse_p", UserId: jesse.ID.String()},
+		{Type: "mention", Start: 9, End: 17, Value: "jesse_p", UserId: jesse.ID.String()},
+		{Type: "hashtag", Start: 26, End: 36, Value: "chemistry"},
+		{Type: "url", Start: 43, End: 62, Value: "https://example.com"},
+	}
+	if !slices.Equal(created.Entities, expected) {
+		t.Errorf("entities mismatch --> %+v != %+v <--", created.Entities, expected)
+	}
+
+	w = doRequest(t, cfg, http.MethodGet, "/api/chirps/"+created.Id, "", nil)
+	var fetched chirpRes
+	if err := json.Unmarshal(w.Body.Bytes(), &fetched); err != nil {
+		t.Fatalf("couldn't unmarshal response: %v", err)
+	}
+	if !slices.Equal(fetched.Entities, expected) {
+		t.Errorf("fetched entities mismatch --> %+v != %+v <--", fetched.Entities, expected)
+	}
+
+	// mentioning someone twice notifies them once
+	unread, err := cfg.db.CountUnreadNotifications(context.Background(), jesse.ID)
+	if err != nil || unread != 1 {
+		t.Errorf("mention notifications mismatch --> %d %v != 1 <--", unread, err)
+	}
+
+	createTestChirp(t, cfg, jesse.ID, "no tags here")
+
+	cases := []struct {
+		name     string
+		tag      string
+		expected int
+		chirps   int
+	}{
+		{name: "tag", tag: "chemistry", expected: http.StatusOK, chirps: 1},
+		{name: "tag with hash and case", tag: "%23CHEMISTRY", expected: http.StatusOK, chirps: 1},
+		{name: "unused tag", tag: "physics", expected: http.StatusOK, chirps: 0},
+		{name: "number", tag: "2008", expected: http.StatusBadRequest},
+		{name: "punctuation", tag: "blue-sky", expected: http.StatusBadRequest},
+	}
+
+	for _, c := range cases {
+		w := doRequest(t, cfg, http.MethodGet, "/api/hashtags/"+c.tag+"/chirps", "", nil)
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+			continue
+		}
+
+		if c.expected != http.StatusOK {
+			continue
+		}
+
+		var response []chirpRes
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: couldn't unmarshal response: %v", c.name, err)
+		}
+
+		if len(response) != c.chirps {
+			t.Errorf("%s: chirps mismatch --> %d != %d <--", c.name, len(response), c.chirps)
+		}
+		if len(response) > 0 && response[0].Id != created.Id {
+			t.Errorf("%s: chirp mismatch --> %s != %s <--", c.name, response[0].Id, created.Id)
+		}
+	}
+}
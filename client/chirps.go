@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Chirp struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Body      string    `json:"body"`
+	UserID    uuid.UUID `json:"user_id"`
+	Entities  []Entity  `json:"entities"`
+}
+
+// Entity is a mention, hashtag or URL in a chirp body. Start and End count
+// runes, End is exclusive, so the text is []rune(chirp.Body)[Start:End].
+type Entity struct {
+	Type  string `json:"type"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Value string `json:"value"`
+	// UserID is the mentioned user, uuid.Nil on hashtags and URLs
+	UserID uuid.UUID `json:"user_id"`
+}
+
+type Sort string
+
+const (
+	SortAsc  Sort = "asc"
+	SortDesc Sort = "desc"
+)
+
+// ListChirpsParams filters and orders ListChirps, the zero value lists
+// every chirp oldest first.
+type ListChirpsParams struct {
+	AuthorID uuid.UUID
+	Sort     Sort
+}
+
+type createChirpRQ struct {
+	Body string `json:"body"`
+}
+
+// CreateChirp posts a chirp as the logged in user.
+func (c *Client) CreateChirp(ctx context.Context, body string) (Chirp, error) {
+	var chirp Chirp
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/chirps",
+		body:   createChirpRQ{Body: body},
+		auth:   authAccess,
+	}, &chirp)
+	return chirp, err
+}
+
+func (c *Client) ListChirps(ctx context.Context, params ListChirpsParams) ([]Chirp, error) {
+	query := url.Values{}
+	if params.AuthorID != uuid.Nil {
+		query.Set("author_id", params.AuthorID.String())
+	}
+	if params.Sort != "" {
+		query.Set("sort", string(params.Sort))
+	}
+
+	var chirps []Chirp
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/chirps",
+		query:  query,
+	}, &chirps)
+	return chirps, err
+}
+
+// ListHashtagChirps lists the chirps tagged with tag, given with or without
+// its #.
+func (c *Client) ListHashtagChirps(ctx context.Context, tag string, sort Sort) ([]Chirp, error) {
+	query := url.Values{}
+	if sort != "" {
+		query.Set("sort", string(sort))
+	}
+
+	var chirps []Chirp
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/hashtags/" + url.PathEscape(tag) + "/chirps",
+		query:  query,
+	}, &chirps)
+	return chirps, err
+}
+
+func (c *Client) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	var chirp Chirp
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/chirps/" + id.String(),
+	}, &chirp)
+	return chirp, err
+}
+
+// DeleteChirp deletes one of the logged in user's chirps.
+func (c *Client) DeleteChirp(ctx context.Context, id uuid.UUID) error {
+	return c.do(ctx, request{
+		method: http.MethodDelete,
+		path:   "/api/chirps/" + id.String(),
+		auth:   authAccess,
+	}, nil)
+}
@@ -0,0 +1,233 @@
+// Package client is the Go SDK for the Chirpy API.
+//
+//	c := client.New("https://chirpy.example.com")
+//	_, err := c.Login(ctx, "walt@example.com", "heisenberg99")
+//	chirp, err := c.CreateChirp(ctx, "I am the one who knocks")
+//
+// After Login the client sends the access token with every request that
+// needs one and trades the refresh token for a new access token when the
+// server rejects it. Failed requests return an *Error that matches one of
+// the sentinels in errors.go with errors.Is.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Client calls a Chirpy server, it is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	polkaKey   string
+	onTokens   func(Tokens)
+
+	mu     sync.Mutex
+	tokens Tokens
+
+	// refreshMu keeps concurrent requests from refreshing the same expired
+	// token several times
+	refreshMu sync.Mutex
+}
+
+// Tokens are the credentials handed out by Login. Persist them to resume a
+// session without logging in again.
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces http.DefaultClient, e.g. to set timeouts or a
+// custom transport.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithTokens resumes a session from previously stored tokens.
+func WithTokens(tokens Tokens) Option {
+	return func(c *Client) { c.tokens = tokens }
+}
+
+// WithPolkaKey sets the API key the webhook methods authenticate with.
+func WithPolkaKey(key string) Option {
+	return func(c *Client) { c.polkaKey = key }
+}
+
+// OnTokens registers a function called whenever the tokens change, after a
+// login, a refresh or a revoke.
+func OnTokens(fn func(Tokens)) Option {
+	return func(c *Client) { c.onTokens = fn }
+}
+
+// New returns a client for the server at baseURL, e.g.
+// http://localhost:8080.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Tokens returns the current session tokens.
+func (c *Client) Tokens() Tokens {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tokens
+}
+
+func (c *Client) setTokens(tokens Tokens) {
+	c.mu.Lock()
+	c.tokens = tokens
+	c.mu.Unlock()
+
+	if c.onTokens != nil {
+		c.onTokens(tokens)
+	}
+}
+
+// auth picks the Authorization header a request is sent with.
+type auth int
+
+const (
+	authNone auth = iota
+	authAccess
+	authRefresh
+	authPolka
+)
+
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   any
+	auth   auth
+}
+
+// do sends rq and decodes a successful response into dst, unless dst is
+// nil. A request sent with an access token the server rejects is retried
+// once after a refresh.
+func (c *Client) do(ctx context.Context, rq request, dst any) error {
+	var body []byte
+	if rq.body != nil {
+		var err error
+		body, err = json.Marshal(rq.body)
+		if err != nil {
+			return fmt.Errorf("couldn't marshal request: %w", err)
+		}
+	}
+
+	accessToken := c.Tokens().AccessToken
+	err := c.send(ctx, rq, body, dst)
+	if rq.auth != authAccess || !errors.Is(err, ErrUnauthorized) {
+		return err
+	}
+
+	refreshErr := c.refreshAfter(ctx, accessToken)
+	if refreshErr != nil {
+		// the caller is more interested in the original failure
+		return err
+	}
+
+	return c.send(ctx, rq, body, dst)
+}
+
+// refreshAfter refreshes the access token unless another request already
+// replaced the rejected one.
+func (c *Client) refreshAfter(ctx context.Context, rejected string) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	if c.Tokens().AccessToken != rejected {
+		return nil
+	}
+
+	_, err := c.Refresh(ctx)
+	return err
+}
+
+func (c *Client) send(ctx context.Context, rq request, body []byte, dst any) error {
+	target := c.baseURL + rq.path
+	if len(rq.query) > 0 {
+		target += "?" + rq.query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	r, err := http.NewRequestWithContext(ctx, rq.method, target, reader)
+	if err != nil {
+		return fmt.Errorf("couldn't create request: %w", err)
+	}
+
+	r.Header.Set("Accept", "application/json")
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	err = c.authorize(r, rq.auth)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.httpClient.Do(r)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", rq.method, rq.path, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return decodeError(res)
+	}
+
+	if dst == nil {
+		return nil
+	}
+
+	err = json.NewDecoder(res.Body).Decode(dst)
+	if err != nil {
+		return fmt.Errorf("couldn't decode %s %s response: %w", rq.method, rq.path, err)
+	}
+
+	return nil
+}
+
+func (c *Client) authorize(r *http.Request, kind auth) error {
+	tokens := c.Tokens()
+
+	switch kind {
+	case authAccess:
+		if tokens.AccessToken == "" && tokens.RefreshToken == "" {
+			return ErrNotLoggedIn
+		}
+		// an empty access token is rejected and then refreshed
+		r.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	case authRefresh:
+		if tokens.RefreshToken == "" {
+			return ErrNotLoggedIn
+		}
+		r.Header.Set("Authorization", "Bearer "+tokens.RefreshToken)
+	case authPolka:
+		if c.polkaKey == "" {
+			return ErrNoPolkaKey
+		}
+		r.Header.Set("Authorization", "ApiKey "+c.polkaKey)
+	}
+
+	return nil
+}
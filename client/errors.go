@@ -0,0 +1,109 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Sentinels for the problem types the server returns, and for requests the
+// client can't send, match them with errors.Is.
+var (
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrTooLarge     = errors.New("request too large")
+	ErrUnsupported  = errors.New("unsupported media type")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrInternal     = errors.New("internal server error")
+	ErrNotLoggedIn  = errors.New("not logged in")
+	ErrNoPolkaKey   = errors.New("client has no polka key, use WithPolkaKey")
+)
+
+// problemTypes maps the server's problem type URIs to the sentinels.
+var problemTypes = map[string]error{
+	"/problems/validation":             ErrValidation,
+	"/problems/unauthorized":           ErrUnauthorized,
+	"/problems/forbidden":              ErrForbidden,
+	"/problems/not-found":              ErrNotFound,
+	"/problems/conflict":               ErrConflict,
+	"/problems/too-large":              ErrTooLarge,
+	"/problems/unsupported-media-type": ErrUnsupported,
+	"/problems/rate-limited":           ErrRateLimited,
+	"/problems/internal":               ErrInternal,
+}
+
+// statusErrors matches responses without a problem body, e.g. from a proxy
+// in front of the server, by status.
+var statusErrors = map[int]error{
+	http.StatusBadRequest:            ErrValidation,
+	http.StatusUnauthorized:          ErrUnauthorized,
+	http.StatusForbidden:             ErrForbidden,
+	http.StatusNotFound:              ErrNotFound,
+	http.StatusConflict:              ErrConflict,
+	http.StatusRequestEntityTooLarge: ErrTooLarge,
+	http.StatusUnsupportedMediaType:  ErrUnsupported,
+	http.StatusTooManyRequests:       ErrRateLimited,
+	http.StatusInternalServerError:   ErrInternal,
+}
+
+// Error is an RFC 9457 problem returned by the server.
+type Error struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail"`
+	Instance  string       `json:"instance"`
+	RequestId string       `json:"request_id"`
+	Errors    []FieldError `json:"errors"`
+
+	// RetryAfter is how long a rate limited client should wait.
+	RetryAfter time.Duration `json:"-"`
+}
+
+// FieldError names a request field that broke a validation rule.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	message := e.Detail
+	if message == "" {
+		message = e.Title
+	}
+	return fmt.Sprintf("chirpy: %d %s", e.Status, message)
+}
+
+func (e *Error) Is(target error) bool {
+	sentinel, ok := problemTypes[e.Type]
+	if !ok {
+		sentinel = statusErrors[e.Status]
+	}
+	return sentinel != nil && target == sentinel
+}
+
+// decodeError turns a failed response into an *Error, a body that isn't a
+// problem, e.g. from a proxy, keeps the status line.
+func decodeError(res *http.Response) error {
+	problem := &Error{Status: res.StatusCode, Title: http.StatusText(res.StatusCode)}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 64<<10))
+	if err == nil {
+		json.Unmarshal(body, problem)
+	}
+	problem.Status = res.StatusCode
+
+	seconds, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err == nil {
+		problem.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return problem
+}
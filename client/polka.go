@@ -0,0 +1,31 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// Polka webhook events, the server only acts on EventUserUpgraded.
+const EventUserUpgraded = "user.upgraded"
+
+type webhookRQ struct {
+	Event string `json:"event"`
+	Data  struct {
+		UserID string `json:"user_id"`
+	} `json:"data"`
+}
+
+// SendWebhook delivers a Polka payment event, it needs WithPolkaKey.
+func (c *Client) SendWebhook(ctx context.Context, event string, userID uuid.UUID) error {
+	rq := webhookRQ{Event: event}
+	rq.Data.UserID = userID.String()
+
+	return c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/polka/webhooks",
+		body:   rq,
+		auth:   authPolka,
+	}, nil)
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type User struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Email       string    `json:"email"`
+	Username    string    `json:"username,omitempty"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+}
+
+// Profile is the public side of a user, it never carries the email.
+type Profile struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	AvatarURL   string    `json:"avatar_url"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+	ChirpCount  int64     `json:"chirp_count"`
+}
+
+// ProfileUpdate changes the fields that aren't nil, an empty string clears
+// a field. The username can be changed but not cleared.
+type ProfileUpdate struct {
+	Username    *string `json:"username,omitempty"`
+	DisplayName *string `json:"display_name,omitempty"`
+	Bio         *string `json:"bio,omitempty"`
+	AvatarURL   *string `json:"avatar_url,omitempty"`
+}
+
+type credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type loginRes struct {
+	User
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type tokenRes struct {
+	Token string `json:"token"`
+}
+
+// CreateUser signs up a new user, it doesn't log them in.
+func (c *Client) CreateUser(ctx context.Context, email, password string) (User, error) {
+	var user User
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/users",
+		body:   credentials{Email: email, Password: password},
+	}, &user)
+	return user, err
+}
+
+// Login starts a session, later requests are sent as the logged in user.
+func (c *Client) Login(ctx context.Context, email, password string) (User, error) {
+	var res loginRes
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/login",
+		body:   credentials{Email: email, Password: password},
+	}, &res)
+	if err != nil {
+		return User{}, err
+	}
+
+	c.setTokens(Tokens{AccessToken: res.Token, RefreshToken: res.RefreshToken})
+	return res.User, nil
+}
+
+// UpdateUser changes the logged in user's email and password.
+func (c *Client) UpdateUser(ctx context.Context, email, password string) (User, error) {
+	var user User
+	err := c.do(ctx, request{
+		method: http.MethodPut,
+		path:   "/api/users",
+		body:   credentials{Email: email, Password: password},
+		auth:   authAccess,
+	}, &user)
+	return user, err
+}
+
+// GetProfile looks a user up by their username, in any case.
+func (c *Client) GetProfile(ctx context.Context, handle string) (Profile, error) {
+	var profile Profile
+	err := c.do(ctx, request{
+		method: http.MethodGet,
+		path:   "/api/users/" + url.PathEscape(handle),
+	}, &profile)
+	return profile, err
+}
+
+// UpdateProfile changes the logged in user's public profile.
+func (c *Client) UpdateProfile(ctx context.Context, update ProfileUpdate) (Profile, error) {
+	var profile Profile
+	err := c.do(ctx, request{
+		method: http.MethodPatch,
+		path:   "/api/users",
+		body:   update,
+		auth:   authAccess,
+	}, &profile)
+	return profile, err
+}
+
+// Refresh trades the refresh token for a new access token. Requests do this
+// on their own when the access token expires, call it to refresh ahead of
+// time.
+func (c *Client) Refresh(ctx context.Context) (string, error) {
+	var res tokenRes
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/refresh",
+		auth:   authRefresh,
+	}, &res)
+	if err != nil {
+		return "", err
+	}
+
+	c.setTokens(Tokens{AccessToken: res.Token, RefreshToken: c.Tokens().RefreshToken})
+	return res.Token, nil
+}
+
+// Revoke ends the session, the refresh token stops working and the client
+// forgets both tokens.
+func (c *Client) Revoke(ctx context.Context) error {
+	err := c.do(ctx, request{
+		method: http.MethodPost,
+		path:   "/api/revoke",
+		auth:   authRefresh,
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	c.setTokens(Tokens{})
+	return nil
+}
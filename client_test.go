@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/client"
+)
+
+// newTestServer mounts the real handlers for the client tests.
+func newTestServer(t *testing.T) (*apiConfig, *httptest.Server) {
+	t.Helper()
+
+	cfg := newTestConfig(t)
+	server := httptest.NewServer(cfg.routes())
+	t.Cleanup(server.Close)
+
+	return cfg, server
+}
+
+func TestClientSession(t *testing.T) {
+	_, server := newTestServer(t)
+	ctx := context.Background()
+	c := client.New(server.URL, client.WithPolkaKey(testPolkaKey))
+
+	created, err := c.CreateUser(ctx, "walt@example.com", testPassword)
+	if err != nil {
+		t.Fatalf("couldn't create user: %v", err)
+	}
+
+	_, err = c.CreateChirp(ctx, "Say my name")
+	if !errors.Is(err, client.ErrNotLoggedIn) {
+		t.Errorf("create chirp before login error mismatch --> %v != %v <--", err, client.ErrNotLoggedIn)
+	}
+
+	user, err := c.Login(ctx, "walt@example.com", testPassword)
+	if err != nil {
+		t.Fatalf("couldn't log in: %v", err)
+	}
+	if user.ID != created.ID || c.Tokens().AccessToken == "" || c.Tokens().RefreshToken == "" {
+		t.Fatalf("login mismatch --> %+v %+v <--", user, c.Tokens())
+	}
+
+	first, err := c.CreateChirp(ctx, "I am the one who knocks")
+	if err != nil {
+		t.Fatalf("couldn't create chirp: %v", err)
+	}
+	second, err := c.CreateChirp(ctx, "Say my name #Heisenberg")
+	if err != nil {
+		t.Fatalf("couldn't create chirp: %v", err)
+	}
+	if first.UserID != user.ID {
+		t.Errorf("chirp author mismatch --> %s != %s <--", first.UserID, user.ID)
+	}
+
+	chirps, err := c.ListChirps(ctx, client.ListChirpsParams{AuthorID: user.ID, Sort: client.SortDesc})
+	if err != nil {
+		t.Fatalf("couldn't list chirps: %v", err)
+	}
+	if len(chirps) != 2 || chirps[0].ID != second.ID {
+		t.Errorf("chirps mismatch --> %+v <--", chirps)
+	}
+
+	chirp, err := c.GetChirp(ctx, first.ID)
+	if err != nil || chirp.Body != first.Body {
+		t.Errorf("get chirp mismatch --> %+v %v <--", chirp, err)
+	}
+
+	tagged, err := c.ListHashtagChirps(ctx, "#heisenberg", client.SortAsc)
+	if err != nil || len(tagged) != 1 || tagged[0].ID != second.ID {
+		t.Errorf("hashtag chirps mismatch --> %+v %v <--", tagged, err)
+	}
+	if len(second.Entities) != 1 || second.Entities[0].Value != "heisenberg" {
+		t.Errorf("chirp entities mismatch --> %+v <--", second.Entities)
+	}
+
+	err = c.DeleteChirp(ctx, first.ID)
+	if err != nil {
+		t.Errorf("couldn't delete chirp: %v", err)
+	}
+
+	_, err = c.GetChirp(ctx, first.ID)
+	if !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("deleted chirp error mismatch --> %v != %v <--", err, client.ErrNotFound)
+	}
+
+	err = c.SendWebhook(ctx, client.EventUserUpgraded, user.ID)
+	if err != nil {
+		t.Errorf("couldn't send webhook: %v", err)
+	}
+
+	updated, err := c.UpdateUser(ctx, "heisenberg@example.com", "newPassword1")
+	if err != nil {
+		t.Fatalf("couldn't update user: %v", err)
+	}
+	if updated.Email != "heisenberg@example.com" || !updated.IsChirpyRed {
+		t.Errorf("updated user mismatch --> %+v <--", updated)
+	}
+
+	handle, bio := "Heisenberg", "chemistry teacher"
+	profile, err := c.UpdateProfile(ctx, client.ProfileUpdate{Username: &handle, Bio: &bio})
+	if err != nil {
+		t.Fatalf("couldn't update profile: %v", err)
+	}
+	if profile.ID != user.ID || profile.Bio != bio || profile.ChirpCount != 1 {
+		t.Errorf("updated profile mismatch --> %+v <--", profile)
+	}
+
+	profile, err = c.GetProfile(ctx, "heisenberg")
+	if err != nil || profile.Username != handle {
+		t.Errorf("profile mismatch --> %+v %v <--", profile, err)
+	}
+
+	_, err = c.Refresh(ctx)
+	if err != nil {
+		t.Errorf("couldn't refresh: %v", err)
+	}
+
+	err = c.Revoke(ctx)
+	if err != nil {
+		t.Errorf("couldn't revoke: %v", err)
+	}
+	if c.Tokens() != (client.Tokens{}) {
+		t.Errorf("tokens kept after revoke --> %+v <--", c.Tokens())
+	}
+}
+
+func TestClientRefreshesExpiredAccessToken(t *testing.T) {
+	cfg, server := newTestServer(t)
+	ctx := context.Background()
+
+	user := createTestUser(t, cfg, "walt@example.com")
+	refreshToken := createTestRefreshToken(t, cfg, user.ID, time.Hour)
+
+	var saved []client.Tokens
+	c := client.New(server.URL,
+		client.WithTokens(client.Tokens{AccessToken: "expired", RefreshToken: refreshToken}),
+		client.OnTokens(func(tokens client.Tokens) { saved = append(saved, tokens) }),
+	)
+
+	chirp, err := c.CreateChirp(ctx, "I am the one who knocks")
+	if err != nil {
+		t.Fatalf("couldn't create chirp: %v", err)
+	}
+	if chirp.UserID != user.ID {
+		t.Errorf("chirp author mismatch --> %s != %s <--", chirp.UserID, user.ID)
+	}
+
+	if len(saved) != 1 || saved[0].AccessToken == "expired" || saved[0].RefreshToken != refreshToken {
+		t.Errorf("saved tokens mismatch --> %+v <--", saved)
+	}
+
+	// once the refresh token is gone the original 401 surfaces
+	c = client.New(server.URL, client.WithTokens(client.Tokens{AccessToken: "expired", RefreshToken: "revoked"}))
+	_, err = c.CreateChirp(ctx, "Say my name")
+	if !errors.Is(err, client.ErrUnauthorized) {
+		t.Errorf("error mismatch --> %v != %v <--", err, client.ErrUnauthorized)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	cfg, server := newTestServer(t)
+	ctx := context.Background()
+
+	walt := createTestUser(t, cfg, "walt@example.com")
+	jesse := createTestUser(t, cfg, "jesse@example.com")
+	chirp := createTestChirp(t, cfg, jesse.ID, "Yeah, science!")
+
+	cl := client.New(server.URL)
+	_, err := cl.Login(ctx, walt.Email, testPassword)
+	if err != nil {
+		t.Fatalf("couldn't log in: %v", err)
+	}
+
+	_, err = cl.CreateUser(ctx, "walt", "walt")
+	var problem *client.Error
+	if !errors.As(err, &problem) || !errors.Is(err, client.ErrValidation) {
+		t.Fatalf("validation error mismatch --> %v <--", err)
+	}
+	if problem.Status != 400 || len(problem.Errors) != 2 || problem.Errors[0].Field != "email" {
+		t.Errorf("problem mismatch --> %+v <--", problem)
+	}
+
+	cases := []struct {
+		name     string
+		call     func() error
+		expected error
+	}{
+		{
+			name:     "email taken",
+			call:     func() error { _, err := cl.CreateUser(ctx, jesse.Email, testPassword); return err },
+			expected: client.ErrConflict,
+		},
+		{
+			name:     "wrong password",
+			call:     func() error { _, err := cl.Login(ctx, walt.Email, "wrongPassword1"); return err },
+			expected: client.ErrUnauthorized,
+		},
+		{
+			name:     "someone else's chirp",
+			call:     func() error { return cl.DeleteChirp(ctx, chirp.ID) },
+			expected: client.ErrForbidden,
+		},
+		{
+			name:     "missing chirp",
+			call:     func() error { _, err := cl.GetChirp(ctx, uuid.New()); return err },
+			expected: client.ErrNotFound,
+		},
+		{
+			name:     "webhook without key",
+			call:     func() error { return cl.SendWebhook(ctx, client.EventUserUpgraded, walt.ID) },
+			expected: client.ErrNoPolkaKey,
+		},
+	}
+
+	for _, c := range cases {
+		err := c.call()
+		if !errors.Is(err, c.expected) {
+			t.Errorf("%s: error mismatch --> %v != %v <--", c.name, err, c.expected)
+		}
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = cl.ListChirps(canceled, client.ListChirpsParams{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled error mismatch --> %v != %v <--", err, context.Canceled)
+	}
+}
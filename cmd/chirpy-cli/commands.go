@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/client"
+)
+
+// newFlags returns a flag set for a command that reports mistakes as
+// errUsage.
+func (c *cli) newFlags(name, args string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: chirpy-cli %s %s\n", name, args)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+func (c *cli) login(ctx context.Context, args []string) error {
+	flags := c.newFlags("login", "-email EMAIL")
+	email := flags.String("email", "", "account email")
+	if flags.Parse(args) != nil || *email == "" || flags.NArg() > 0 {
+		flags.Usage()
+		return errUsage
+	}
+
+	password := c.getenv("CHIRPY_PASSWORD")
+	if password == "" {
+		fmt.Fprint(c.stderr, "Password: ")
+		line, err := bufio.NewReader(c.stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("couldn't read password: %w", err)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	// a new login starts from a clean slate, whatever was stored before
+	c.session = session{Server: c.server}
+	c.loggedIn = false
+
+	user, err := c.client().Login(ctx, *email, password)
+	if err != nil {
+		return err
+	}
+
+	c.session.Email = user.Email
+	c.session.UserID = user.ID
+	err = saveSession(c.sessionPath, c.session)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stderr, "logged in as %s\n", user.Email)
+	return nil
+}
+
+func (c *cli) logout(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		fmt.Fprintln(c.stderr, "usage: chirpy-cli logout")
+		return errUsage
+	}
+
+	if !c.loggedIn {
+		return nil
+	}
+
+	err := c.client().Revoke(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't revoke the refresh token, the session is kept: %w", err)
+	}
+
+	return removeSession(c.sessionPath)
+}
+
+func (c *cli) sessionCommand(ctx context.Context, args []string) error {
+	action := "show"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	if len(args) > 1 || (action != "show" && action != "refresh") {
+		fmt.Fprintln(c.stderr, "usage: chirpy-cli session [show|refresh]")
+		return errUsage
+	}
+
+	err := c.requireSession()
+	if err != nil {
+		return err
+	}
+
+	if action == "refresh" {
+		_, err = c.client().Refresh(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.out.session(c.session)
+}
+
+// token prints a freshly refreshed access token, so scripts can call the
+// API with curl without handling the refresh themselves.
+func (c *cli) token(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		fmt.Fprintln(c.stderr, "usage: chirpy-cli token")
+		return errUsage
+	}
+
+	err := c.requireSession()
+	if err != nil {
+		return err
+	}
+
+	token, err := c.client().Refresh(ctx)
+	if err != nil {
+		return err
+	}
+
+	if c.out.format == outputJSON {
+		return c.out.json(map[string]string{"token": token})
+	}
+	_, err = fmt.Fprintln(c.out.w, token)
+	return err
+}
+
+func (c *cli) post(ctx context.Context, args []string) error {
+	err := c.requireSession()
+	if err != nil {
+		return err
+	}
+
+	body := strings.Join(args, " ")
+	if len(args) == 0 || body == "-" {
+		text, err := io.ReadAll(c.stdin)
+		if err != nil {
+			return fmt.Errorf("couldn't read chirp from stdin: %w", err)
+		}
+		body = strings.TrimRight(string(text), "\n")
+	}
+
+	chirp, err := c.client().CreateChirp(ctx, body)
+	if err != nil {
+		return err
+	}
+
+	return c.out.chirp(chirp)
+}
+
+func (c *cli) timeline(ctx context.Context, args []string) error {
+	flags := c.newFlags("timeline", "[-author ID|me] [-sort asc|desc] [-limit N] [-page N]")
+	author := flags.String("author", "", "only chirps by this user id, me for your own")
+	order := flags.String("sort", string(client.SortDesc), "creation date order, asc or desc")
+	limit := flags.Int("limit", 20, "chirps per page")
+	page := flags.Int("page", 1, "page to show, starting at 1")
+	if flags.Parse(args) != nil || flags.NArg() > 0 {
+		return errUsage
+	}
+
+	if *order != string(client.SortAsc) && *order != string(client.SortDesc) {
+		fmt.Fprintf(c.stderr, "unknown sort order %q, use asc or desc\n", *order)
+		return errUsage
+	}
+	if *limit < 1 || *page < 1 {
+		fmt.Fprintln(c.stderr, "-limit and -page must be at least 1")
+		return errUsage
+	}
+
+	params := client.ListChirpsParams{Sort: client.Sort(*order)}
+	switch *author {
+	case "":
+	case "me":
+		err := c.requireSession()
+		if err != nil {
+			return err
+		}
+		params.AuthorID = c.session.UserID
+	default:
+		id, err := uuid.Parse(*author)
+		if err != nil {
+			return fmt.Errorf("-author must be a user id or me: %w", err)
+		}
+		params.AuthorID = id
+	}
+
+	chirps, err := c.client().ListChirps(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	chirps, pages := paginate(chirps, *page, *limit)
+	if c.out.format == outputTable {
+		defer fmt.Fprintf(c.stderr, "page %d of %d\n", *page, pages)
+	}
+
+	return c.out.chirps(chirps)
+}
+
+// paginate returns the chirps on page, counted from 1, and the number of
+// pages. The API returns every chirp at once, so the pages are cut here.
+func paginate(chirps []client.Chirp, page, limit int) ([]client.Chirp, int) {
+	pages := max(1, (len(chirps)+limit-1)/limit)
+
+	start := (page - 1) * limit
+	if start >= len(chirps) {
+		return nil, pages
+	}
+
+	end := min(start+limit, len(chirps))
+	return chirps[start:end], pages
+}
+
+func (c *cli) delete(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		fmt.Fprintln(c.stderr, "usage: chirpy-cli delete ID")
+		return errUsage
+	}
+
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("chirp id must be a uuid: %w", err)
+	}
+
+	err = c.requireSession()
+	if err != nil {
+		return err
+	}
+
+	err = c.client().DeleteChirp(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stderr, "deleted chirp %s\n", id)
+	return nil
+}
@@ -0,0 +1,195 @@
+// Command chirpy-cli talks to a Chirpy server from the terminal and from
+// scripts.
+//
+//	chirpy-cli login -email walt@example.com
+//	chirpy-cli post "I am the one who knocks"
+//	chirpy-cli -o json timeline -author me -sort desc -limit 10
+//
+// login stores the session in the user config dir, later commands reuse it
+// and keep the access token fresh.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/magicznykacpur/chirpy/client"
+)
+
+const defaultServer = "http://localhost:8080"
+
+// errUsage is returned for bad command lines, the flag package has already
+// printed what was wrong.
+var errUsage = errors.New("usage")
+
+const usage = `usage: chirpy-cli [-server URL] [-o table|json] <command> [arguments]
+
+commands:
+  login -email EMAIL        log in, the password is read from CHIRPY_PASSWORD or stdin
+  logout                    revoke the refresh token and forget the session
+  session [show|refresh]    show the stored session or refresh its access token
+  token                     print a fresh access token for use with curl and friends
+  post [TEXT|-]             post a chirp, - or no text reads it from stdin
+  timeline [flags]          list chirps, see chirpy-cli timeline -h
+  delete ID                 delete one of your chirps
+
+the server defaults to CHIRPY_SERVER, then the logged in server, then ` + defaultServer + `
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv)
+	if errors.Is(err, errUsage) {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "chirpy-cli:", describe(err))
+		os.Exit(1)
+	}
+}
+
+// cli holds what every command needs: where to talk to, how to print and
+// the stored session.
+type cli struct {
+	stdin       io.Reader
+	stderr      io.Writer
+	getenv      func(string) string
+	out         printer
+	server      string
+	sessionPath string
+	session     session
+	loggedIn    bool
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer, getenv func(string) string) error {
+	flags := flag.NewFlagSet("chirpy-cli", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { fmt.Fprint(stderr, usage) }
+
+	server := flags.String("server", "", "server URL")
+	format := flags.String("o", outputTable, "output format, table or json")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return errUsage
+	}
+
+	if *format != outputTable && *format != outputJSON {
+		fmt.Fprintf(stderr, "unknown output format %q, use table or json\n", *format)
+		return errUsage
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errUsage
+	}
+
+	path, err := sessionPath(getenv)
+	if err != nil {
+		return err
+	}
+
+	stored, loggedIn, err := loadSession(path)
+	if err != nil {
+		return err
+	}
+
+	c := &cli{
+		stdin:       stdin,
+		stderr:      stderr,
+		getenv:      getenv,
+		out:         printer{w: stdout, format: *format},
+		server:      firstNonEmpty(*server, getenv("CHIRPY_SERVER"), stored.Server, defaultServer),
+		sessionPath: path,
+		session:     stored,
+		loggedIn:    loggedIn,
+	}
+
+	command, rest := flags.Arg(0), flags.Args()[1:]
+	switch command {
+	case "login":
+		return c.login(ctx, rest)
+	case "logout":
+		return c.logout(ctx, rest)
+	case "session":
+		return c.sessionCommand(ctx, rest)
+	case "token":
+		return c.token(ctx, rest)
+	case "post":
+		return c.post(ctx, rest)
+	case "timeline":
+		return c.timeline(ctx, rest)
+	case "delete":
+		return c.delete(ctx, rest)
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n", command)
+		flags.Usage()
+		return errUsage
+	}
+}
+
+// client returns an API client for the stored session, refreshed tokens
+// are written back so the next command picks them up.
+func (c *cli) client() *client.Client {
+	return client.New(c.server,
+		client.WithTokens(c.session.tokens()),
+		client.OnTokens(func(tokens client.Tokens) {
+			if tokens.RefreshToken == "" {
+				return
+			}
+			c.session.AccessToken = tokens.AccessToken
+			c.session.RefreshToken = tokens.RefreshToken
+			err := saveSession(c.sessionPath, c.session)
+			if err != nil {
+				fmt.Fprintln(c.stderr, "chirpy-cli: warning:", err)
+			}
+		}),
+	)
+}
+
+// requireSession fails commands that act as a user before anyone logged in.
+func (c *cli) requireSession() error {
+	if !c.loggedIn {
+		return errors.New("not logged in, run chirpy-cli login first")
+	}
+	return nil
+}
+
+// describe turns API problems into one readable line, listing the fields a
+// validation error names.
+func describe(err error) string {
+	var problem *client.Error
+	if !errors.As(err, &problem) {
+		return err.Error()
+	}
+
+	message := problem.Detail
+	if message == "" {
+		message = problem.Title
+	}
+	for _, field := range problem.Errors {
+		message += fmt.Sprintf("\n  %s: %s", field.Field, field.Message)
+	}
+	if errors.Is(err, client.ErrRateLimited) && problem.RetryAfter > 0 {
+		message += fmt.Sprintf(", retry in %s", problem.RetryAfter)
+	}
+
+	return message
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/client"
+)
+
+var testUserID = uuid.MustParse("7d3a4c8e-1f6b-4e0a-9c2d-5b8e1a3f6c90")
+
+// newFakeServer answers the routes the cli uses the way chirpy does, the
+// client itself is tested against the real handlers.
+func newFakeServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	var chirps []client.Chirp
+	mux := http.NewServeMux()
+
+	writeJSON := func(w http.ResponseWriter, status int, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+	authorized := func(r *http.Request, token string) bool {
+		return r.Header.Get("Authorization") == "Bearer "+token
+	}
+	unauthorized := func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"type": "/problems/unauthorized", "title": "Unauthorized", "status": 401, "detail": "incorrect email or password"}`))
+	}
+
+	mux.HandleFunc("POST /api/login", func(w http.ResponseWriter, r *http.Request) {
+		var rq struct{ Email, Password string }
+		json.NewDecoder(r.Body).Decode(&rq)
+		if rq.Password != "heisenberg99" {
+			unauthorized(w)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"id": testUserID, "email": rq.Email, "token": "access", "refresh_token": "refresh",
+		})
+	})
+	mux.HandleFunc("POST /api/refresh", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r, "refresh") {
+			unauthorized(w)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"token": "access"})
+	})
+	mux.HandleFunc("POST /api/revoke", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("POST /api/chirps", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r, "access") {
+			unauthorized(w)
+			return
+		}
+		var rq struct{ Body string }
+		json.NewDecoder(r.Body).Decode(&rq)
+		chirp := client.Chirp{ID: uuid.New(), CreatedAt: time.Now(), Body: rq.Body, UserID: testUserID}
+		chirps = append(chirps, chirp)
+		writeJSON(w, http.StatusCreated, chirp)
+	})
+	mux.HandleFunc("GET /api/chirps", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, chirps)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func runCLI(t *testing.T, env map[string]string, stdin string, args ...string) (string, error) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr, func(key string) string { return env[key] })
+	return stdout.String(), err
+}
+
+func TestCLI(t *testing.T) {
+	server := newFakeServer(t)
+	env := map[string]string{"CHIRPY_CONFIG_DIR": t.TempDir(), "CHIRPY_SERVER": server.URL}
+
+	_, err := runCLI(t, env, "", "post", "too early")
+	if err == nil || !strings.Contains(err.Error(), "not logged in") {
+		t.Errorf("post before login error mismatch --> %v <--", err)
+	}
+
+	_, err = runCLI(t, env, "wrong\n", "login", "-email", "walt@example.com")
+	if !errors.Is(err, client.ErrUnauthorized) {
+		t.Errorf("wrong password error mismatch --> %v != %v <--", err, client.ErrUnauthorized)
+	}
+
+	_, err = runCLI(t, env, "heisenberg99\n", "login", "-email", "walt@example.com")
+	if err != nil {
+		t.Fatalf("couldn't log in: %v", err)
+	}
+
+	info, err := os.Stat(filepath.Join(env["CHIRPY_CONFIG_DIR"], "session.json"))
+	if err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("session file mismatch --> %v %v <--", info, err)
+	}
+
+	for i := range 3 {
+		_, err = runCLI(t, env, "", "post", "chirp", fmt.Sprint(i))
+		if err != nil {
+			t.Fatalf("couldn't post: %v", err)
+		}
+	}
+
+	_, err = runCLI(t, env, "from stdin\n", "post", "-")
+	if err != nil {
+		t.Fatalf("couldn't post from stdin: %v", err)
+	}
+
+	out, err := runCLI(t, env, "", "-o", "json", "timeline", "-author", "me", "-limit", "3", "-page", "2")
+	if err != nil {
+		t.Fatalf("couldn't list timeline: %v", err)
+	}
+
+	var chirps []client.Chirp
+	err = json.Unmarshal([]byte(out), &chirps)
+	if err != nil {
+		t.Fatalf("couldn't unmarshal timeline %q: %v", out, err)
+	}
+	if len(chirps) != 1 || chirps[0].Body != "from stdin" {
+		t.Errorf("timeline page mismatch --> %+v <--", chirps)
+	}
+
+	out, err = runCLI(t, env, "", "timeline")
+	if err != nil || !strings.HasPrefix(out, "ID") || strings.Count(out, "\n") != 5 {
+		t.Errorf("timeline table mismatch --> %q %v <--", out, err)
+	}
+
+	out, err = runCLI(t, env, "", "token")
+	if err != nil || out != "access\n" {
+		t.Errorf("token mismatch --> %q %v <--", out, err)
+	}
+
+	_, err = runCLI(t, env, "", "logout")
+	if err != nil {
+		t.Fatalf("couldn't log out: %v", err)
+	}
+
+	_, err = runCLI(t, env, "", "session")
+	if err == nil {
+		t.Error("session should fail after logout")
+	}
+}
+
+func TestCLIUsage(t *testing.T) {
+	env := map[string]string{"CHIRPY_CONFIG_DIR": t.TempDir()}
+
+	cases := [][]string{
+		{},
+		{"unknown"},
+		{"-o", "yaml", "timeline"},
+		{"login"},
+		{"timeline", "-sort", "sideways"},
+		{"timeline", "-limit", "0"},
+		{"delete"},
+	}
+
+	for _, args := range cases {
+		_, err := runCLI(t, env, "", args...)
+		if !errors.Is(err, errUsage) {
+			t.Errorf("%v: error mismatch --> %v != %v <--", args, err, errUsage)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	chirps := make([]client.Chirp, 5)
+
+	cases := []struct {
+		page, limit   int
+		length, pages int
+	}{
+		{page: 1, limit: 2, length: 2, pages: 3},
+		{page: 3, limit: 2, length: 1, pages: 3},
+		{page: 4, limit: 2, length: 0, pages: 3},
+		{page: 1, limit: 20, length: 5, pages: 1},
+	}
+
+	for _, c := range cases {
+		got, pages := paginate(chirps, c.page, c.limit)
+		if len(got) != c.length || pages != c.pages {
+			t.Errorf("page %d limit %d mismatch --> %d %d != %d %d <--", c.page, c.limit, len(got), pages, c.length, c.pages)
+		}
+	}
+
+	_, pages := paginate(nil, 1, 20)
+	if pages != 1 {
+		t.Errorf("empty pages mismatch --> %d != 1 <--", pages)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	err := &client.Error{
+		Type:   "/problems/validation",
+		Status: http.StatusBadRequest,
+		Detail: "request has invalid fields",
+		Errors: []client.FieldError{{Field: "body", Message: "too long, max 140 characters"}},
+	}
+
+	expected := "request has invalid fields\n  body: too long, max 140 characters"
+	if message := describe(fmt.Errorf("post: %w", err)); message != expected {
+		t.Errorf("message mismatch --> %q != %q <--", message, expected)
+	}
+}
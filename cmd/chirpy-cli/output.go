@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/magicznykacpur/chirpy/client"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// printer writes command results as an aligned table for people or as JSON
+// for scripts.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func (p printer) json(v any) error {
+	encoder := json.NewEncoder(p.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func (p printer) table(header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func (p printer) chirps(chirps []client.Chirp) error {
+	if p.format == outputJSON {
+		if chirps == nil {
+			chirps = []client.Chirp{}
+		}
+		return p.json(chirps)
+	}
+
+	rows := make([][]string, 0, len(chirps))
+	for _, chirp := range chirps {
+		rows = append(rows, []string{
+			chirp.ID.String(),
+			chirp.CreatedAt.Local().Format(time.DateTime),
+			chirp.UserID.String(),
+			singleLine(chirp.Body),
+		})
+	}
+	return p.table([]string{"ID", "CREATED", "AUTHOR", "BODY"}, rows)
+}
+
+func (p printer) chirp(chirp client.Chirp) error {
+	if p.format == outputJSON {
+		return p.json(chirp)
+	}
+	return p.chirps([]client.Chirp{chirp})
+}
+
+func (p printer) session(s session) error {
+	if p.format == outputJSON {
+		return p.json(struct {
+			Server string `json:"server"`
+			Email  string `json:"email"`
+			UserID string `json:"user_id"`
+		}{s.Server, s.Email, s.UserID.String()})
+	}
+	return p.table([]string{"SERVER", "EMAIL", "USER ID"}, [][]string{{s.Server, s.Email, s.UserID.String()}})
+}
+
+// singleLine keeps multi line chirps on one table row.
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
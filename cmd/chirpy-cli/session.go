@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/client"
+)
+
+// session is what login leaves behind in the user config dir so later
+// commands run as the same user.
+type session struct {
+	Server       string    `json:"server"`
+	Email        string    `json:"email"`
+	UserID       uuid.UUID `json:"user_id"`
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+}
+
+func (s session) tokens() client.Tokens {
+	return client.Tokens{AccessToken: s.AccessToken, RefreshToken: s.RefreshToken}
+}
+
+// sessionPath is $CHIRPY_CONFIG_DIR/session.json, falling back to
+// chirpy/session.json in the user config dir, e.g. ~/.config on linux.
+func sessionPath(getenv func(string) string) (string, error) {
+	dir := getenv("CHIRPY_CONFIG_DIR")
+	if dir == "" {
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("couldn't find the user config dir, set CHIRPY_CONFIG_DIR: %w", err)
+		}
+		dir = filepath.Join(configDir, "chirpy")
+	}
+
+	return filepath.Join(dir, "session.json"), nil
+}
+
+// loadSession returns the stored session, ok is false when nobody is logged
+// in.
+func loadSession(path string) (s session, ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return session{}, false, nil
+	}
+	if err != nil {
+		return session{}, false, fmt.Errorf("couldn't read session: %w", err)
+	}
+
+	err = json.Unmarshal(data, &s)
+	if err != nil {
+		return session{}, false, fmt.Errorf("couldn't parse session %s, log in again: %w", path, err)
+	}
+
+	return s, true, nil
+}
+
+// saveSession writes s readable by the owner only, the refresh token is as
+// good as the password for 60 days.
+func saveSession(path string, s session) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return fmt.Errorf("couldn't create config dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("couldn't marshal session: %w", err)
+	}
+
+	// write next to the old file and rename, so a crash never leaves half
+	// a session behind
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, data, 0o600)
+	if err != nil {
+		return fmt.Errorf("couldn't write session: %w", err)
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return fmt.Errorf("couldn't write session: %w", err)
+	}
+
+	return nil
+}
+
+func removeSession(path string) error {
+	err := os.Remove(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("couldn't remove session: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/magicznykacpur/chirpy/internal/apperr"
+)
+
+// Body size limits per route, generous compared to the largest valid
+// request so only abuse hits them.
+const (
+	maxChirpBodyBytes   = 4 << 10
+	maxUserBodyBytes    = 4 << 10
+	maxWebhookBodyBytes = 64 << 10
+)
+
+const jsonContentType = "application/json"
+
+// decodeJSON reads a single JSON object from r into dst. The body must be
+// application/json and at most maxBytes long, and may only contain the
+// fields dst declares. The returned errors are apperr kinds that
+// respondWithError turns into 415, 413 or 400 responses.
+func decodeJSON(w http.ResponseWriter, r *http.Request, maxBytes int64, dst any) error {
+	contentType := r.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != jsonContentType {
+		return apperr.UnsupportedMediaType(contentType, jsonContentType)
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	defer r.Body.Close()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return decodeError(err)
+	}
+
+	// encoding/json would quietly turn invalid bytes into U+FFFD
+	if !utf8.Valid(body) {
+		return apperr.Validation("request body is not valid utf-8", nil)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+
+	err = decoder.Decode(dst)
+	if err != nil {
+		return decodeError(err)
+	}
+
+	// a second value, or anything but whitespace, after the object
+	err = decoder.Decode(&struct{}{})
+	if !errors.Is(err, io.EOF) {
+		return apperr.Validation("request body must contain a single json object", err)
+	}
+
+	return nil
+}
+
+func decodeError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return apperr.TooLarge(maxBytesErr.Limit)
+	case errors.Is(err, io.EOF):
+		return apperr.Validation("request body cannot be empty", err)
+	case errors.As(err, &syntaxErr):
+		return apperr.Validation(fmt.Sprintf("request body is not valid json, error at byte %d", syntaxErr.Offset), err)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return apperr.Validation("request body is not valid json, it ends too early", err)
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return apperr.Validation("request body must be a json object", err)
+		}
+		return apperr.InvalidField(typeErr.Field, "must be a json "+jsonKind(typeErr.Type.Kind()))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no error type for unknown fields
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return apperr.InvalidField(field, "unknown field")
+	default:
+		return apperr.Validation("request body is not valid json", err)
+	}
+}
+
+// jsonKind names a Go kind the way a client writing JSON would.
+func jsonKind(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "number"
+	}
+}
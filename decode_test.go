@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	cfg := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com")
+	authorization := bearerHeader(t, user.ID).Get("Authorization")
+
+	cases := []struct {
+		name           string
+		contentType    string
+		body           string
+		expected       int
+		expectedType   string
+		expectedFields []problemFieldRes
+	}{
+		{name: "valid", contentType: "application/json", body: `{"body": "hello"}`, expected: http.StatusCreated},
+		{name: "charset parameter", contentType: "application/json; charset=utf-8", body: `{"body": "hello"}`, expected: http.StatusCreated},
+		{name: "missing content type", contentType: "", body: `{"body": "hello"}`, expected: http.StatusUnsupportedMediaType, expectedType: problemMediaType},
+		{name: "form content type", contentType: "application/x-www-form-urlencoded", body: `body=hello`, expected: http.StatusUnsupportedMediaType, expectedType: problemMediaType},
+		{name: "too large", contentType: "application/json", body: `{"body": "` + strings.Repeat("a", maxChirpBodyBytes) + `"}`, expected: http.StatusRequestEntityTooLarge, expectedType: problemTooLarge},
+		{name: "empty", contentType: "application/json", body: "", expected: http.StatusBadRequest, expectedType: problemValidation},
+		{name: "malformed", contentType: "application/json", body: `{"body": `, expected: http.StatusBadRequest, expectedType: problemValidation},
+		{name: "syntax error", contentType: "application/json", body: `{"body" "hello"}`, expected: http.StatusBadRequest, expectedType: problemValidation},
+		{name: "not an object", contentType: "application/json", body: `["hello"]`, expected: http.StatusBadRequest, expectedType: problemValidation},
+		{
+			name: "unknown field", contentType: "application/json", body: `{"body": "hello", "author": "walt"}`,
+			expected: http.StatusBadRequest, expectedType: problemValidation,
+			expectedFields: []problemFieldRes{{Field: "author", Message: "unknown field"}},
+		},
+		{
+			name: "wrong type", contentType: "application/json", body: `{"body": 42}`,
+			expected: http.StatusBadRequest, expectedType: problemValidation,
+			expectedFields: []problemFieldRes{{Field: "body", Message: "must be a json string"}},
+		},
+		{name: "trailing object", contentType: "application/json", body: `{"body": "hello"}{"body": "again"}`, expected: http.StatusBadRequest, expectedType: problemValidation},
+		{name: "trailing garbage", contentType: "application/json", body: `{"body": "hello"} nope`, expected: http.StatusBadRequest, expectedType: problemValidation},
+		{name: "trailing whitespace", contentType: "application/json", body: "{\"body\": \"hello\"}\n  ", expected: http.StatusCreated},
+	}
+
+	for _, c := range cases {
+		header := http.Header{"Authorization": {authorization}, "Content-Type": {c.contentType}}
+		w := doRequest(t, cfg, http.MethodPost, "/api/chirps", c.body, header)
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+			continue
+		}
+
+		if c.expectedType == "" {
+			continue
+		}
+
+		var problem problemRes
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("%s: couldn't unmarshal problem: %v", c.name, err)
+		}
+
+		if problem.Type != c.expectedType {
+			t.Errorf("%s: type mismatch --> %s != %s <--", c.name, problem.Type, c.expectedType)
+		}
+
+		if len(c.expectedFields) > 0 && (len(problem.Errors) != 1 || problem.Errors[0] != c.expectedFields[0]) {
+			t.Errorf("%s: fields mismatch --> %+v != %+v <--", c.name, problem.Errors, c.expectedFields)
+		}
+	}
+}
+
+func TestDecodeJSONNestedField(t *testing.T) {
+	cfg := newTestConfig(t)
+	header := http.Header{"Authorization": {"ApiKey " + testPolkaKey}}
+
+	w := doRequest(t, cfg, http.MethodPost, "/api/polka/webhooks", `{"event": "user.upgraded", "data": {"user_id": 7}}`, header)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status mismatch --> %d != %d <--", w.Code, http.StatusBadRequest)
+	}
+
+	var problem problemRes
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("couldn't unmarshal problem: %v", err)
+	}
+
+	if len(problem.Errors) != 1 || problem.Errors[0].Field != "data.user_id" {
+		t.Errorf("fields mismatch --> %+v <--", problem.Errors)
+	}
+}
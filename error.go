@@ -2,29 +2,116 @@ package main
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
+	"log/slog"
 	"net/http"
+
+	"github.com/magicznykacpur/chirpy/internal/apperr"
+	"github.com/magicznykacpur/chirpy/internal/logging"
 )
 
-type errorRes struct {
-	Message string `json:"error"`
+// problemRes is an RFC 9457 problem details response.
+type problemRes struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	RequestId string            `json:"request_id,omitempty"`
+	Errors    []problemFieldRes `json:"errors,omitempty"`
+}
+
+type problemFieldRes struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
-func writeError(err error, message string, status int, w http.ResponseWriter) {
-	w.WriteHeader(status)
+// Problem type URIs are part of the API contract, clients switch on them, so
+// never change an existing one.
+const (
+	problemValidation   = "/problems/validation"
+	problemUnauthorized = "/problems/unauthorized"
+	problemForbidden    = "/problems/forbidden"
+	problemNotFound     = "/problems/not-found"
+	problemConflict     = "/problems/conflict"
+	problemRateLimited  = "/problems/rate-limited"
+	problemTooLarge     = "/problems/too-large"
+	problemMediaType    = "/problems/unsupported-media-type"
+	problemInternal     = "/problems/internal"
+)
+
+// respondWithError is the single place that turns an error into a response.
+// The status and problem type come from the apperr kind the error matches,
+// anything else is an internal error that is logged but never shown to the
+// client.
+func respondWithError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := problemFromError(err)
+	problem.Instance = r.URL.Path
+	problem.RequestId = requestId(r)
 
-	response := errorRes{}
-	if err == nil {
-		response.Message = message
+	if problem.Status == http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "internal error", "error", err)
 	} else {
-		response.Message = fmt.Sprintf("%s: %v", message, err)
+		slog.InfoContext(r.Context(), "request failed", "status", problem.Status, "error", err)
 	}
 
-	bytes, err := json.Marshal(response)
+	bytes, err := json.Marshal(problem)
 	if err != nil {
-		w.Write([]byte("marshalling error"))
-	} else {
-		w.Header().Set("Contet-Type", "application/json")
-		w.Write(bytes)
+		slog.ErrorContext(r.Context(), "couldn't marshal problem", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	w.Write(bytes)
+}
+
+func problemFromError(err error) problemRes {
+	var validationErr *apperr.ValidationError
+	var unauthorizedErr *apperr.UnauthorizedError
+	var forbiddenErr *apperr.ForbiddenError
+	var notFoundErr *apperr.NotFoundError
+	var conflictErr *apperr.ConflictError
+	var rateLimitedErr *apperr.RateLimitedError
+	var tooLargeErr *apperr.TooLargeError
+	var mediaTypeErr *apperr.UnsupportedMediaTypeError
+
+	switch {
+	case errors.As(err, &validationErr):
+		problem := newProblem(problemValidation, http.StatusBadRequest, validationErr.Message)
+		for _, field := range validationErr.Fields {
+			problem.Errors = append(problem.Errors, problemFieldRes{Field: field.Field, Message: field.Message})
+		}
+		return problem
+	case errors.As(err, &unauthorizedErr):
+		return newProblem(problemUnauthorized, http.StatusUnauthorized, unauthorizedErr.Message)
+	case errors.As(err, &forbiddenErr):
+		return newProblem(problemForbidden, http.StatusForbidden, forbiddenErr.Message)
+	case errors.As(err, &notFoundErr):
+		return newProblem(problemNotFound, http.StatusNotFound, notFoundErr.Error())
+	case errors.As(err, &conflictErr):
+		return newProblem(problemConflict, http.StatusConflict, conflictErr.Message)
+	case errors.As(err, &rateLimitedErr):
+		return newProblem(problemRateLimited, http.StatusTooManyRequests, "too many requests, slow down")
+	case errors.As(err, &tooLargeErr):
+		return newProblem(problemTooLarge, http.StatusRequestEntityTooLarge, tooLargeErr.Error())
+	case errors.As(err, &mediaTypeErr):
+		return newProblem(problemMediaType, http.StatusUnsupportedMediaType, mediaTypeErr.Error())
+	default:
+		return newProblem(problemInternal, http.StatusInternalServerError, "")
+	}
+}
+
+func newProblem(problemType string, status int, detail string) problemRes {
+	return problemRes{
+		Type:   problemType,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
 	}
 }
+
+func requestId(r *http.Request) string {
+	return logging.RequestId(r.Context())
+}
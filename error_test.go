@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/magicznykacpur/chirpy/internal/apperr"
+	"github.com/magicznykacpur/chirpy/internal/logging"
+)
+
+func TestProblemFromError(t *testing.T) {
+	cases := []struct {
+		err          error
+		expected     int
+		expectedType string
+	}{
+		{err: apperr.Validation("chirp body too long", nil), expected: http.StatusBadRequest, expectedType: problemValidation},
+		{err: apperr.Unauthorized("token invalid", nil), expected: http.StatusUnauthorized, expectedType: problemUnauthorized},
+		{err: apperr.Forbidden("cannot delete other users chirps"), expected: http.StatusForbidden, expectedType: problemForbidden},
+		{err: apperr.NotFound("chirp", nil), expected: http.StatusNotFound, expectedType: problemNotFound},
+		{err: apperr.Conflict("email already taken", nil), expected: http.StatusConflict, expectedType: problemConflict},
+		{err: apperr.RateLimited(time.Second), expected: http.StatusTooManyRequests, expectedType: problemRateLimited},
+		{err: apperr.TooLarge(1024), expected: http.StatusRequestEntityTooLarge, expectedType: problemTooLarge},
+		{err: apperr.UnsupportedMediaType("", "application/json"), expected: http.StatusUnsupportedMediaType, expectedType: problemMediaType},
+		{err: fmt.Errorf("couldn't create chirp: %w", apperr.NotFound("user", nil)), expected: http.StatusNotFound, expectedType: problemNotFound},
+		{err: errors.New("connection refused"), expected: http.StatusInternalServerError, expectedType: problemInternal},
+	}
+
+	for _, c := range cases {
+		problem := problemFromError(c.err)
+		if problem.Status != c.expected {
+			t.Errorf("%v: status mismatch --> %d != %d <--", c.err, problem.Status, c.expected)
+		}
+
+		if problem.Type != c.expectedType {
+			t.Errorf("%v: type mismatch --> %s != %s <--", c.err, problem.Type, c.expectedType)
+		}
+
+		if problem.Title != http.StatusText(c.expected) {
+			t.Errorf("%v: title mismatch --> %s <--", c.err, problem.Title)
+		}
+	}
+}
+
+func TestRespondWithError(t *testing.T) {
+	cases := []struct {
+		name           string
+		err            error
+		expected       int
+		expectedDetail string
+		expectedFields []problemFieldRes
+	}{
+		{
+			name:           "validation with field",
+			err:            apperr.InvalidField("body", "chirp body cannot be empty"),
+			expected:       http.StatusBadRequest,
+			expectedDetail: "chirp body cannot be empty",
+			expectedFields: []problemFieldRes{{Field: "body", Message: "chirp body cannot be empty"}},
+		},
+		{
+			name:           "unauthorized hides cause",
+			err:            apperr.Unauthorized("token invalid", errors.New("signature is invalid")),
+			expected:       http.StatusUnauthorized,
+			expectedDetail: "token invalid",
+		},
+		{
+			name:     "internal hides message",
+			err:      fmt.Errorf("couldn't create chirp: %w", errors.New("pq: connection refused")),
+			expected: http.StatusInternalServerError,
+		},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodPost, "/api/chirps", nil)
+		r = r.WithContext(logging.WithRequestId(r.Context(), "request-1"))
+		w := httptest.NewRecorder()
+
+		respondWithError(w, r, c.err)
+
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+		}
+
+		if w.Header().Get("Content-Type") != "application/problem+json" {
+			t.Errorf("%s: content type mismatch --> %s <--", c.name, w.Header().Get("Content-Type"))
+		}
+
+		if strings.Contains(w.Body.String(), "pq:") || strings.Contains(w.Body.String(), "signature") {
+			t.Errorf("%s: response leaks internal error: %s", c.name, w.Body.String())
+		}
+
+		var problem problemRes
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("%s: couldn't unmarshal problem: %v", c.name, err)
+		}
+
+		if problem.Detail != c.expectedDetail {
+			t.Errorf("%s: detail mismatch --> %s != %s <--", c.name, problem.Detail, c.expectedDetail)
+		}
+
+		if problem.RequestId != "request-1" || problem.Instance != "/api/chirps" {
+			t.Errorf("%s: request id or instance mismatch --> %+v <--", c.name, problem)
+		}
+
+		if len(problem.Errors) != len(c.expectedFields) {
+			t.Errorf("%s: field errors mismatch --> %+v != %+v <--", c.name, problem.Errors, c.expectedFields)
+			continue
+		}
+
+		for i, field := range c.expectedFields {
+			if problem.Errors[i] != field {
+				t.Errorf("%s: field error mismatch --> %+v != %+v <--", c.name, problem.Errors[i], field)
+			}
+		}
+	}
+}
@@ -1,9 +1,83 @@
 package main
 
-import "net/http"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
 
-func handlerHealth(w http.ResponseWriter, r *http.Request) {
-	w.Header().Add("Content-Type", "text/plain; charset=utf-8")
+	"github.com/magicznykacpur/chirpy/internal/health"
+	"github.com/magicznykacpur/chirpy/internal/migrate"
+)
+
+type livenessRes struct {
+	Status string `json:"status"`
+}
+
+// handlerLiveness only tells the orchestrator the process is up, it never
+// looks at dependencies so a database outage doesn't get the pod restarted.
+func handlerLiveness(w http.ResponseWriter, r *http.Request) {
+	responseBytes, err := json.Marshal(livenessRes{Status: "ok"})
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't marshal response: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("OK"))
+	w.Write(responseBytes)
+}
+
+// handlerReadiness runs the dependency checks and answers 503 while any of
+// them fails or the server is draining.
+func (cfg *apiConfig) handlerReadiness(w http.ResponseWriter, r *http.Request) {
+	report := cfg.health.Run(r.Context())
+
+	responseBytes, err := json.Marshal(report)
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't marshal response: %w", err))
+		return
+	}
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+		slog.WarnContext(r.Context(), "not ready", "status", report.Status)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	w.Write(responseBytes)
+}
+
+// databaseCheck fails while ping can't reach the database.
+func databaseCheck(ping func(context.Context) error) health.Check {
+	return func(ctx context.Context) error {
+		err := ping(ctx)
+		if err != nil {
+			return health.Fail("unreachable", err)
+		}
+
+		return nil
+	}
+}
+
+// migrationsCheck fails while the database is behind the migrations
+// embedded in the binary. A database ahead of the binary passes, so a
+// rollback to the previous release can still serve.
+func migrationsCheck(migrator *migrate.Migrator) health.Check {
+	return func(ctx context.Context) error {
+		current, target, err := migrator.Versions(ctx)
+		if err != nil {
+			return health.Fail("couldn't read migration version", err)
+		}
+
+		if current < target {
+			return health.Fail(fmt.Sprintf("behind by %d migrations", target-current), nil)
+		}
+
+		return nil
+	}
 }
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/magicznykacpur/chirpy/internal/health"
+	"github.com/magicznykacpur/chirpy/internal/migrate"
+	"github.com/magicznykacpur/chirpy/internal/store"
+	_ "modernc.org/sqlite"
+)
+
+func TestHandlerLiveness(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.health.Add("database", func(context.Context) error { return errors.New("connection refused") })
+
+	for _, target := range []string{"/api/livez", "/api/healthz"} {
+		w := doRequest(t, cfg, http.MethodGet, target, "", nil)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: status mismatch --> %d != %d <--", target, w.Code, http.StatusOK)
+		}
+
+		if w.Body.String() != `{"status":"ok"}` {
+			t.Errorf("%s: body mismatch --> %s <--", target, w.Body.String())
+		}
+	}
+}
+
+func TestHandlerReadiness(t *testing.T) {
+	cases := []struct {
+		name           string
+		databaseErr    error
+		workerErr      error
+		drain          bool
+		expected       int
+		expectedStatus string
+	}{
+		{name: "ready", expected: http.StatusOK, expectedStatus: health.StatusReady},
+		{name: "database down", databaseErr: errors.New("dial tcp db.internal:5432: connection refused"), expected: http.StatusServiceUnavailable, expectedStatus: health.StatusNotReady},
+		{name: "worker failing", workerErr: errors.New("query failed"), expected: http.StatusServiceUnavailable, expectedStatus: health.StatusNotReady},
+		{name: "draining", drain: true, expected: http.StatusServiceUnavailable, expectedStatus: health.StatusDraining},
+	}
+
+	for _, c := range cases {
+		cfg := newTestConfig(t)
+		cfg.health.Add("database", databaseCheck(func(context.Context) error { return c.databaseErr }))
+		cfg.health.Worker("test").Report(c.workerErr)
+		if c.drain {
+			cfg.health.Drain()
+		}
+
+		w := doRequest(t, cfg, http.MethodGet, "/api/readyz", "", nil)
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+		}
+
+		var report health.Report
+		err := json.Unmarshal(w.Body.Bytes(), &report)
+		if err != nil {
+			t.Fatalf("%s: couldn't unmarshal report: %v", c.name, err)
+		}
+
+		if report.Status != c.expectedStatus {
+			t.Errorf("%s: report status mismatch --> %s != %s <--", c.name, report.Status, c.expectedStatus)
+		}
+
+		if c.drain {
+			continue
+		}
+
+		// driver errors can name hosts and queries, they are only logged
+		if strings.Contains(w.Body.String(), "db.internal") || strings.Contains(w.Body.String(), "query failed") {
+			t.Errorf("%s: report leaks the check error --> %s <--", c.name, w.Body.String())
+		}
+
+		for _, check := range []string{"database", "worker:test"} {
+			if _, ok := report.Checks[check]; !ok {
+				t.Errorf("%s: missing check %s", c.name, check)
+			}
+		}
+	}
+}
+
+func TestMigrationsCheck(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:"+t.TempDir()+"/chirpy.db")
+	if err != nil {
+		t.Fatalf("couldn't open database: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := migrate.New(db, store.BackendSQLite)
+	if err != nil {
+		t.Fatalf("couldn't create migrator: %v", err)
+	}
+
+	check := migrationsCheck(migrator)
+
+	err = check(context.Background())
+	var failure *health.Failure
+	if !errors.As(err, &failure) || !strings.HasPrefix(failure.Message, "behind by ") {
+		t.Errorf("unmigrated database error mismatch --> %v <--", err)
+	}
+
+	_, err = migrator.Up(context.Background())
+	if err != nil {
+		t.Fatalf("couldn't migrate: %v", err)
+	}
+
+	err = check(context.Background())
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+// Package apperr holds the domain errors shared by the store and the
+// handlers. Every error matches one of the sentinels below with errors.Is,
+// so callers never have to look at driver specific errors or messages.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrForbidden    = errors.New("forbidden")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrTooLarge     = errors.New("request too large")
+	ErrUnsupported  = errors.New("unsupported media type")
+)
+
+// NotFoundError reports a missing row, Resource names what was looked up.
+type NotFoundError struct {
+	Resource string
+	Err      error
+}
+
+func NotFound(resource string, err error) error {
+	return &NotFoundError{Resource: resource, Err: err}
+}
+
+func (e *NotFoundError) Error() string        { return e.Resource + " not found" }
+func (e *NotFoundError) Unwrap() error        { return e.Err }
+func (e *NotFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// ConflictError reports a write that clashes with existing data, such as a
+// second user with the same email.
+type ConflictError struct {
+	Message string
+	Err     error
+}
+
+func Conflict(message string, err error) error {
+	return &ConflictError{Message: message, Err: err}
+}
+
+func (e *ConflictError) Error() string        { return e.Message }
+func (e *ConflictError) Unwrap() error        { return e.Err }
+func (e *ConflictError) Is(target error) bool { return target == ErrConflict }
+
+// ForbiddenError reports an authenticated user acting on something they
+// don't own.
+type ForbiddenError struct {
+	Message string
+}
+
+func Forbidden(message string) error {
+	return &ForbiddenError{Message: message}
+}
+
+func (e *ForbiddenError) Error() string        { return e.Message }
+func (e *ForbiddenError) Is(target error) bool { return target == ErrForbidden }
+
+// ValidationError reports a request that is malformed or breaks a rule.
+// Fields lists the offending request fields when they are known.
+type ValidationError struct {
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func Validation(message string, err error) error {
+	return &ValidationError{Message: message, Err: err}
+}
+
+// InvalidField reports a single request field that breaks a rule.
+func InvalidField(field, message string) error {
+	return &ValidationError{
+		Message: message,
+		Fields:  []FieldError{{Field: field, Message: message}},
+	}
+}
+
+// InvalidFields reports several request fields that break rules at once.
+func InvalidFields(fields []FieldError) error {
+	message := "request has invalid fields"
+	if len(fields) == 1 {
+		message = fields[0].Field + " " + fields[0].Message
+	}
+
+	return &ValidationError{Message: message, Fields: fields}
+}
+
+func (e *ValidationError) Error() string        { return withCause(e.Message, e.Err) }
+func (e *ValidationError) Unwrap() error        { return e.Err }
+func (e *ValidationError) Is(target error) bool { return target == ErrValidation }
+
+// UnauthorizedError reports missing or invalid credentials.
+type UnauthorizedError struct {
+	Message string
+	Err     error
+}
+
+func Unauthorized(message string, err error) error {
+	return &UnauthorizedError{Message: message, Err: err}
+}
+
+func (e *UnauthorizedError) Error() string        { return withCause(e.Message, e.Err) }
+func (e *UnauthorizedError) Unwrap() error        { return e.Err }
+func (e *UnauthorizedError) Is(target error) bool { return target == ErrUnauthorized }
+
+// RateLimitedError reports a client that used up its request budget,
+// RetryAfter is how long until it may try again.
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func RateLimited(retryAfter time.Duration) error {
+	return &RateLimitedError{RetryAfter: retryAfter}
+}
+
+func (e *RateLimitedError) Error() string        { return "rate limit exceeded" }
+func (e *RateLimitedError) Is(target error) bool { return target == ErrRateLimited }
+
+// TooLargeError reports a request body over the route's limit of Limit
+// bytes.
+type TooLargeError struct {
+	Limit int64
+}
+
+func TooLarge(limit int64) error {
+	return &TooLargeError{Limit: limit}
+}
+
+func (e *TooLargeError) Error() string {
+	return fmt.Sprintf("request body too large, max %d bytes", e.Limit)
+}
+func (e *TooLargeError) Is(target error) bool { return target == ErrTooLarge }
+
+// UnsupportedMediaTypeError reports a request body in a format the route
+// doesn't accept.
+type UnsupportedMediaTypeError struct {
+	ContentType string
+	Expected    string
+}
+
+func UnsupportedMediaType(contentType, expected string) error {
+	return &UnsupportedMediaTypeError{ContentType: contentType, Expected: expected}
+}
+
+func (e *UnsupportedMediaTypeError) Error() string {
+	if e.ContentType == "" {
+		return "missing Content-Type, expected " + e.Expected
+	}
+	return fmt.Sprintf("unsupported Content-Type %s, expected %s", e.ContentType, e.Expected)
+}
+func (e *UnsupportedMediaTypeError) Is(target error) bool { return target == ErrUnsupported }
+
+func withCause(message string, err error) string {
+	if err == nil {
+		return message
+	}
+	return message + ": " + err.Error()
+}
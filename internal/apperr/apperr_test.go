@@ -0,0 +1,47 @@
+package apperr
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSentinels(t *testing.T) {
+	cases := []struct {
+		err      error
+		sentinel error
+		message  string
+	}{
+		{err: NotFound("chirp", sql.ErrNoRows), sentinel: ErrNotFound, message: "chirp not found"},
+		{err: Conflict("email already taken", nil), sentinel: ErrConflict, message: "email already taken"},
+		{err: Forbidden("cannot delete other users chirps"), sentinel: ErrForbidden, message: "cannot delete other users chirps"},
+		{err: Validation("chirp body too long", nil), sentinel: ErrValidation, message: "chirp body too long"},
+		{err: Unauthorized("token invalid", errors.New("expired")), sentinel: ErrUnauthorized, message: "token invalid: expired"},
+		{err: RateLimited(time.Second), sentinel: ErrRateLimited, message: "rate limit exceeded"},
+		{err: TooLarge(1024), sentinel: ErrTooLarge, message: "request body too large, max 1024 bytes"},
+		{err: UnsupportedMediaType("text/plain", "application/json"), sentinel: ErrUnsupported, message: "unsupported Content-Type text/plain, expected application/json"},
+	}
+
+	for _, c := range cases {
+		wrapped := fmt.Errorf("handler: %w", c.err)
+		if !errors.Is(wrapped, c.sentinel) {
+			t.Errorf("%v should match %v", c.err, c.sentinel)
+		}
+
+		if c.err.Error() != c.message {
+			t.Errorf("message mismatch --> %s != %s <--", c.err.Error(), c.message)
+		}
+
+		for _, other := range []error{ErrNotFound, ErrConflict, ErrForbidden, ErrValidation, ErrUnauthorized, ErrRateLimited, ErrTooLarge, ErrUnsupported} {
+			if other != c.sentinel && errors.Is(c.err, other) {
+				t.Errorf("%v shouldn't match %v", c.err, other)
+			}
+		}
+	}
+
+	if !errors.Is(NotFound("user", sql.ErrNoRows), sql.ErrNoRows) {
+		t.Errorf("not found should keep its cause")
+	}
+}
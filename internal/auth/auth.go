@@ -40,26 +40,42 @@ func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (str
 }
 
 func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
+	userId, _, err := ValidateJWTWithExpiry(tokenString, tokenSecret)
+	return userId, err
+}
+
+// ValidateJWTWithExpiry also returns when the token expires, for connections
+// that outlive the request and must end with the token. The time is zero
+// when the token doesn't expire.
+func ValidateJWTWithExpiry(tokenString, tokenSecret string) (uuid.UUID, time.Time, error) {
 	claims := jwt.MapClaims{}
 	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
 		return []byte(tokenSecret), nil
 	})
 
 	if err != nil {
-		return uuid.UUID{}, err
+		return uuid.UUID{}, time.Time{}, err
 	}
 
 	subject, err := claims.GetSubject()
 	if err != nil {
-		return uuid.UUID{}, err
+		return uuid.UUID{}, time.Time{}, err
 	}
 
 	userId, err := uuid.Parse(subject)
 	if err != nil {
-		return uuid.UUID{}, err
+		return uuid.UUID{}, time.Time{}, err
+	}
+
+	expiresAt, err := claims.GetExpirationTime()
+	if err != nil {
+		return uuid.UUID{}, time.Time{}, err
+	}
+	if expiresAt == nil {
+		return userId, time.Time{}, nil
 	}
 
-	return userId, nil
+	return userId, expiresAt.Time, nil
 }
 
 func GetBearerToken(header http.Header) (string, error) {
@@ -77,6 +77,30 @@ func TestJWTTokenExpiration(t *testing.T) {
 	}
 }
 
+func TestJWTTokenExpiry(t *testing.T) {
+	testId, _ := uuid.Parse("b592343d-b059-4d87-a1db-69d3c8accccf")
+	secret := "very-secret-secret"
+
+	token, err := MakeJWT(testId, secret, time.Hour)
+	if err != nil {
+		t.Fatalf("cannot create token: %v", err)
+	}
+
+	id, expiresAt, err := ValidateJWTWithExpiry(token, secret)
+	if err != nil {
+		t.Fatalf("cannot validate token: %v", err)
+	}
+
+	if id != testId {
+		t.Errorf("validation failed, id's do not match: --> %v != %v <--", id, testId)
+	}
+
+	// the claim has second precision
+	if until := time.Until(expiresAt); until < time.Hour-2*time.Second || until > time.Hour {
+		t.Errorf("expiry mismatch --> %v <--", expiresAt)
+	}
+}
+
 func TestGetBearerToken(t *testing.T) {
 	headerNoAuth := http.Header{}
 	headerNoAuth.Add("Content-Type", "application/json")
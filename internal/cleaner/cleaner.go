@@ -2,18 +2,69 @@ package cleaner
 
 import (
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 const asterisks = "****"
 
+// letter is a base character of the body with the combining marks that
+// follow it, start and end are its byte range.
+type letter struct {
+	folded     rune
+	start, end int
+}
+
+// CleanBodyBy replaces every occurrence of key in body with asterisks.
+// Matching ignores case and combining marks, so accents stacked on a word
+// don't hide it. body should be Normalize'd first so invisible characters
+// can't split a word either.
 func CleanBodyBy(body, key string) string {
-	keyTitle := strings.Join([]string{strings.ToUpper(key[:1]), key[1:]}, "")
-	keyLower := strings.ToLower(key)
-	keyUpper := strings.ToUpper(key)
+	keyRunes := []rune(strings.ToLower(key))
+	if len(keyRunes) == 0 {
+		return body
+	}
+
+	letters := []letter{}
+	for i, r := range body {
+		end := i + utf8.RuneLen(r)
+
+		if unicode.Is(unicode.Mn, r) && len(letters) > 0 {
+			letters[len(letters)-1].end = end
+			continue
+		}
+
+		// the first rune of the decomposition is the base letter, é
+		// folds to e
+		base, _ := utf8.DecodeRuneInString(norm.NFD.String(string(r)))
+		letters = append(letters, letter{folded: unicode.ToLower(base), start: i, end: end})
+	}
+
+	var cleaned strings.Builder
+	written := 0
+	for i := 0; i+len(keyRunes) <= len(letters); {
+		if !matches(letters[i:i+len(keyRunes)], keyRunes) {
+			i++
+			continue
+		}
+
+		cleaned.WriteString(body[written:letters[i].start])
+		cleaned.WriteString(asterisks)
+		written = letters[i+len(keyRunes)-1].end
+		i += len(keyRunes)
+	}
+	cleaned.WriteString(body[written:])
 
-	cleaned := strings.ReplaceAll(body, keyTitle, asterisks)
-	cleaned = strings.ReplaceAll(cleaned, keyLower, asterisks)
-	cleaned = strings.ReplaceAll(cleaned, keyUpper, asterisks)
+	return cleaned.String()
+}
 
-	return cleaned
-}
\ No newline at end of file
+func matches(letters []letter, key []rune) bool {
+	for i, l := range letters {
+		if l.folded != key[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -54,3 +54,25 @@ func TestCleanBody(t *testing.T) {
 		}
 	}
 }
+
+func TestCleanBodyIgnoresMarksAndCase(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "mixed case", input: "what a KerFuffle", expected: "what a ****"},
+		{name: "precomposed accent", input: "what a kérfuffle", expected: "what a ****"},
+		{name: "combining accent", input: "what a ke\u0301rfuffle", expected: "what a ****"},
+		{name: "stacked marks at the end", input: "kerfuffle\u0301\u0308!", expected: "****!"},
+		{name: "other text keeps its accents", input: "café kerfuffle", expected: "café ****"},
+		{name: "no match", input: "kerfu ffle", expected: "kerfu ffle"},
+	}
+
+	for _, c := range cases {
+		actual := CleanBodyBy(c.input, "kerfuffle")
+		if actual != c.expected {
+			t.Errorf("%s: actual doesn't match expected --> %s != %s <--", c.name, actual, c.expected)
+		}
+	}
+}
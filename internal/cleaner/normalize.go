@@ -0,0 +1,86 @@
+package cleaner
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+var ErrInvalidUTF8 = errors.New("text is not valid utf-8")
+
+const (
+	zeroWidthJoiner   = '\u200d'
+	variationSelector = '\ufe0f'
+)
+
+// Normalize prepares user text for storage and filtering: it rejects
+// invalid UTF-8, strips control and invisible characters and returns the
+// NFC form, so equal looking texts compare equal. Newlines and tabs are
+// kept, as are zero width joiners inside emoji sequences.
+func Normalize(text string) (string, error) {
+	// encoding/json turns invalid bytes and lone surrogates into U+FFFD
+	if !utf8.ValidString(text) || strings.ContainsRune(text, utf8.RuneError) {
+		return "", ErrInvalidUTF8
+	}
+
+	runes := []rune(text)
+	var b strings.Builder
+	b.Grow(len(text))
+
+	for i, r := range runes {
+		if r == zeroWidthJoiner {
+			if i > 0 && i < len(runes)-1 && joinsEmoji(runes[i-1], runes[i+1]) {
+				b.WriteRune(r)
+			}
+			continue
+		}
+
+		if invisible(r) {
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	return norm.NFC.String(b.String()), nil
+}
+
+// Length counts user perceived characters, an emoji made of several code
+// points counts once.
+func Length(text string) int {
+	return uniseg.GraphemeClusterCount(text)
+}
+
+// invisible reports control characters (Cc) and format characters (Cf),
+// the latter covers zero width spaces and joiners, soft hyphens, the byte
+// order mark and bidi overrides, none of them has a visible glyph.
+func invisible(r rune) bool {
+	if r == '\n' || r == '\t' {
+		return false
+	}
+
+	return unicode.Is(unicode.Cc, r) || (unicode.Is(unicode.Cf, r) && !emojiTag(r))
+}
+
+// joinsEmoji reports whether a zero width joiner between prev and next is
+// part of an emoji sequence such as woman technologist (U+1F469 U+200D
+// U+1F4BB) or the rainbow flag.
+func joinsEmoji(prev, next rune) bool {
+	return (isEmoji(prev) || prev == variationSelector) && isEmoji(next)
+}
+
+// isEmoji covers symbols and the supplementary emoji blocks, skin tone
+// modifiers included.
+func isEmoji(r rune) bool {
+	return unicode.Is(unicode.So, r) || (r >= 0x1f000 && r <= 0x1faff)
+}
+
+// emojiTag reports the tag characters that spell out subdivision flags,
+// e.g. the flag of Scotland.
+func emojiTag(r rune) bool {
+	return r >= 0xe0020 && r <= 0xe007f
+}
@@ -0,0 +1,67 @@
+package cleaner
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "plain", input: "hello chirpy", expected: "hello chirpy"},
+		{name: "nfc", input: "cafe\u0301", expected: "café"},
+		{name: "zero width space", input: "kerf\u200buffle", expected: "kerfuffle"},
+		{name: "zero width joiner between letters", input: "kerf\u200duffle", expected: "kerfuffle"},
+		{name: "byte order mark", input: "\ufeffhello", expected: "hello"},
+		{name: "bidi override", input: "hello \u202eworld", expected: "hello world"},
+		{name: "control characters", input: "hello\x00\x07\rworld", expected: "helloworld"},
+		{name: "newlines and tabs kept", input: "hello\n\tworld", expected: "hello\n\tworld"},
+		{name: "emoji zwj sequence kept", input: "\U0001f469\u200d\U0001f4bb", expected: "\U0001f469\u200d\U0001f4bb"},
+		{name: "flag with variation selector kept", input: "\U0001f3f3\ufe0f\u200d\U0001f308", expected: "\U0001f3f3\ufe0f\u200d\U0001f308"},
+		{name: "dangling joiner", input: "\U0001f469\u200d", expected: "\U0001f469"},
+	}
+
+	for _, c := range cases {
+		actual, err := Normalize(c.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.name, err)
+			continue
+		}
+
+		if actual != c.expected {
+			t.Errorf("%s: actual doesn't match expected --> %q != %q <--", c.name, actual, c.expected)
+		}
+	}
+
+	for _, input := range []string{"hello \xff world", "lone \ufffd surrogate"} {
+		_, err := Normalize(input)
+		if !errors.Is(err, ErrInvalidUTF8) {
+			t.Errorf("%q: expected ErrInvalidUTF8, got %v", input, err)
+		}
+	}
+}
+
+func TestLength(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected int
+	}{
+		{input: "hello", expected: 5},
+		{input: "café", expected: 4},
+		{input: "cafe\u0301", expected: 4},
+		{input: strings.Repeat("\U0001f600", 40), expected: 40},
+		{input: "\U0001f469\u200d\U0001f4bb", expected: 1},
+		{input: "\U0001f1f5\U0001f1f1", expected: 1},
+	}
+
+	for _, c := range cases {
+		actual := Length(c.input)
+		if actual != c.expected {
+			t.Errorf("%q: length mismatch --> %d != %d <--", c.input, actual, c.expected)
+		}
+	}
+}
@@ -0,0 +1,367 @@
+// Package config loads chirpy's settings into a typed struct.
+//
+// Values are layered, later sources win:
+//   - the defaults from Default
+//   - an optional YAML file named by CONFIG_FILE
+//   - a .env file in the working directory
+//   - the process environment
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/magicznykacpur/chirpy/internal/ratelimit"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Port           string    `yaml:"port"`
+	FilepathRoot   string    `yaml:"filepath_root"`
+	Platform       string    `yaml:"platform"`
+	DBURL          string    `yaml:"db_url"`
+	JWTSecret      string    `yaml:"jwt_secret"`
+	PolkaKey       string    `yaml:"polka_key"`
+	MigrateOnBoot  bool      `yaml:"migrate_on_boot"`
+	TracesExporter string    `yaml:"otel_traces_exporter"`
+	Tokens         Tokens    `yaml:"tokens"`
+	Limits         Limits    `yaml:"limits"`
+	Server         Server    `yaml:"server"`
+	RateLimit      RateLimit `yaml:"rate_limit"`
+	Stream         Stream    `yaml:"stream"`
+}
+
+type Tokens struct {
+	// AccessTTL is how long a JWT access token stays valid.
+	AccessTTL time.Duration `yaml:"access_ttl"`
+	// RefreshTTL is how long a refresh token stays valid.
+	RefreshTTL time.Duration `yaml:"refresh_ttl"`
+}
+
+type Limits struct {
+	// ChirpLength is the maximum length of a chirp body.
+	ChirpLength int `yaml:"chirp_length"`
+}
+
+type Server struct {
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout is how long in-flight requests get to finish once
+	// the server is asked to stop.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// DrainDelay is how long the server keeps serving after it starts
+	// failing readiness, so load balancers notice before it stops
+	// accepting connections.
+	DrainDelay time.Duration `yaml:"drain_delay"`
+}
+
+// Stream fan-outs.
+const (
+	StreamFanoutMemory   = "memory"
+	StreamFanoutPostgres = "postgres"
+)
+
+type Stream struct {
+	// Fanout carries chirp events between instances, memory for a single
+	// instance or postgres to use LISTEN/NOTIFY.
+	Fanout string `yaml:"fanout"`
+	// Heartbeat is how often an idle stream sends a comment and a websocket
+	// a ping, so proxies don't close them.
+	Heartbeat time.Duration `yaml:"heartbeat"`
+	// Buffer is how many events a slow client may fall behind before its
+	// stream is closed.
+	Buffer int `yaml:"buffer"`
+	// History is how many recent events are kept for clients resuming
+	// with Last-Event-ID.
+	History int `yaml:"history"`
+}
+
+// Rate limit policy names, each one guards a route.
+const (
+	RateLimitCreateChirp = "create_chirp"
+	RateLimitCreateUser  = "create_user"
+	RateLimitLogin       = "login"
+)
+
+// Rate limit stores.
+const (
+	RateLimitStoreMemory   = "memory"
+	RateLimitStorePostgres = "postgres"
+)
+
+type RateLimit struct {
+	// Store holds the buckets, memory for a single instance or postgres to
+	// share them across a fleet.
+	Store string `yaml:"store"`
+	// TrustedProxies are the addresses or CIDR ranges whose X-Forwarded-For
+	// header is believed when finding the client address.
+	TrustedProxies []string              `yaml:"trusted_proxies"`
+	Policies       map[string]RatePolicy `yaml:"policies"`
+}
+
+// RatePolicy lets Limit requests through in a burst and refills over
+// Window. A zero Limit turns the policy off.
+type RatePolicy struct {
+	Limit  int           `yaml:"limit"`
+	Window time.Duration `yaml:"window"`
+}
+
+func Default() Config {
+	return Config{
+		Port:         "8080",
+		FilepathRoot: ".",
+		Tokens: Tokens{
+			AccessTTL:  time.Hour,
+			RefreshTTL: 60 * 24 * time.Hour,
+		},
+		Limits: Limits{
+			ChirpLength: 140,
+		},
+		Server: Server{
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       10 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Stream: Stream{
+			Fanout:    StreamFanoutMemory,
+			Heartbeat: 15 * time.Second,
+			Buffer:    64,
+			History:   1000,
+		},
+		RateLimit: RateLimit{
+			Store: RateLimitStoreMemory,
+			Policies: map[string]RatePolicy{
+				RateLimitCreateChirp: {Limit: 30, Window: time.Minute},
+				RateLimitCreateUser:  {Limit: 10, Window: time.Hour},
+				RateLimitLogin:       {Limit: 10, Window: time.Minute},
+			},
+		},
+	}
+}
+
+// Load reads .env, the optional CONFIG_FILE and the environment on top of
+// the defaults. The result isn't validated, call Validate before serving.
+func Load() (Config, error) {
+	godotenv.Load()
+
+	return load(os.LookupEnv)
+}
+
+func load(lookup func(string) (string, bool)) (Config, error) {
+	cfg := Default()
+
+	path, ok := lookup("CONFIG_FILE")
+	if ok && path != "" {
+		err := readFile(path, &cfg)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	err := readEnv(lookup, &cfg)
+	if err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+func readFile(path string, cfg *Config) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("couldn't open config file: %w", err)
+	}
+	defer file.Close()
+
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+
+	err = decoder.Decode(cfg)
+	if err != nil {
+		return fmt.Errorf("couldn't parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func readEnv(lookup func(string) (string, bool), cfg *Config) error {
+	texts := []struct {
+		env   string
+		value *string
+	}{
+		{env: "PORT", value: &cfg.Port},
+		{env: "FILEPATH_ROOT", value: &cfg.FilepathRoot},
+		{env: "PLATFORM", value: &cfg.Platform},
+		{env: "DB_URL", value: &cfg.DBURL},
+		{env: "JWT_SECRET", value: &cfg.JWTSecret},
+		{env: "POLKA_KEY", value: &cfg.PolkaKey},
+		{env: "OTEL_TRACES_EXPORTER", value: &cfg.TracesExporter},
+		{env: "RATE_LIMIT_STORE", value: &cfg.RateLimit.Store},
+		{env: "STREAM_FANOUT", value: &cfg.Stream.Fanout},
+	}
+	for _, s := range texts {
+		raw, ok := lookup(s.env)
+		if ok && raw != "" {
+			*s.value = raw
+		}
+	}
+
+	durations := []struct {
+		env   string
+		value *time.Duration
+	}{
+		{env: "ACCESS_TOKEN_TTL", value: &cfg.Tokens.AccessTTL},
+		{env: "REFRESH_TOKEN_TTL", value: &cfg.Tokens.RefreshTTL},
+		{env: "READ_HEADER_TIMEOUT", value: &cfg.Server.ReadHeaderTimeout},
+		{env: "READ_TIMEOUT", value: &cfg.Server.ReadTimeout},
+		{env: "WRITE_TIMEOUT", value: &cfg.Server.WriteTimeout},
+		{env: "IDLE_TIMEOUT", value: &cfg.Server.IdleTimeout},
+		{env: "SHUTDOWN_TIMEOUT", value: &cfg.Server.ShutdownTimeout},
+		{env: "DRAIN_DELAY", value: &cfg.Server.DrainDelay},
+		{env: "STREAM_HEARTBEAT", value: &cfg.Stream.Heartbeat},
+	}
+	for _, d := range durations {
+		raw, ok := lookup(d.env)
+		if !ok || raw == "" {
+			continue
+		}
+
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be a duration like 10s, got %q", d.env, raw)
+		}
+		*d.value = parsed
+	}
+
+	raw, ok := lookup("TRUSTED_PROXIES")
+	if ok && raw != "" {
+		cfg.RateLimit.TrustedProxies = strings.Split(raw, ",")
+	}
+
+	numbers := []struct {
+		env   string
+		value *int
+	}{
+		{env: "CHIRP_MAX_LENGTH", value: &cfg.Limits.ChirpLength},
+		{env: "STREAM_BUFFER", value: &cfg.Stream.Buffer},
+		{env: "STREAM_HISTORY", value: &cfg.Stream.History},
+	}
+	for _, n := range numbers {
+		raw, ok := lookup(n.env)
+		if !ok || raw == "" {
+			continue
+		}
+
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be a number, got %q", n.env, raw)
+		}
+		*n.value = parsed
+	}
+
+	raw, ok = lookup("MIGRATE_ON_BOOT")
+	if ok && raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("MIGRATE_ON_BOOT must be true or false, got %q", raw)
+		}
+		cfg.MigrateOnBoot = parsed
+	}
+
+	return nil
+}
+
+// Validate reports every missing or out of range setting the server can't
+// start without.
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.DBURL == "" {
+		errs = append(errs, errors.New("DB_URL is required"))
+	}
+	if c.JWTSecret == "" {
+		errs = append(errs, errors.New("JWT_SECRET is required"))
+	}
+	if c.PolkaKey == "" {
+		errs = append(errs, errors.New("POLKA_KEY is required"))
+	}
+
+	port, err := strconv.Atoi(c.Port)
+	if err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT must be between 1 and 65535, got %q", c.Port))
+	}
+
+	if c.FilepathRoot == "" {
+		errs = append(errs, errors.New("FILEPATH_ROOT cannot be empty"))
+	}
+
+	if c.Limits.ChirpLength < 1 {
+		errs = append(errs, fmt.Errorf("CHIRP_MAX_LENGTH must be positive, got %d", c.Limits.ChirpLength))
+	}
+
+	durations := []struct {
+		env   string
+		value time.Duration
+	}{
+		{env: "ACCESS_TOKEN_TTL", value: c.Tokens.AccessTTL},
+		{env: "REFRESH_TOKEN_TTL", value: c.Tokens.RefreshTTL},
+		{env: "READ_HEADER_TIMEOUT", value: c.Server.ReadHeaderTimeout},
+		{env: "READ_TIMEOUT", value: c.Server.ReadTimeout},
+		{env: "WRITE_TIMEOUT", value: c.Server.WriteTimeout},
+		{env: "IDLE_TIMEOUT", value: c.Server.IdleTimeout},
+		{env: "SHUTDOWN_TIMEOUT", value: c.Server.ShutdownTimeout},
+		{env: "STREAM_HEARTBEAT", value: c.Stream.Heartbeat},
+	}
+	for _, d := range durations {
+		if d.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be a positive duration, got %s", d.env, d.value))
+		}
+	}
+
+	if c.Server.DrainDelay < 0 {
+		errs = append(errs, fmt.Errorf("DRAIN_DELAY cannot be negative, got %s", c.Server.DrainDelay))
+	}
+
+	if c.RateLimit.Store != RateLimitStoreMemory && c.RateLimit.Store != RateLimitStorePostgres {
+		errs = append(errs, fmt.Errorf("RATE_LIMIT_STORE must be memory or postgres, got %q", c.RateLimit.Store))
+	}
+
+	if c.Stream.Fanout != StreamFanoutMemory && c.Stream.Fanout != StreamFanoutPostgres {
+		errs = append(errs, fmt.Errorf("STREAM_FANOUT must be memory or postgres, got %q", c.Stream.Fanout))
+	}
+	if c.Stream.Buffer < 1 {
+		errs = append(errs, fmt.Errorf("STREAM_BUFFER must be positive, got %d", c.Stream.Buffer))
+	}
+	if c.Stream.History < 0 {
+		errs = append(errs, fmt.Errorf("STREAM_HISTORY cannot be negative, got %d", c.Stream.History))
+	}
+
+	_, err = ratelimit.ParseTrustedProxies(c.RateLimit.TrustedProxies)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("TRUSTED_PROXIES: %w", err))
+	}
+
+	known := Default().RateLimit.Policies
+	for name, policy := range c.RateLimit.Policies {
+		if _, ok := known[name]; !ok {
+			errs = append(errs, fmt.Errorf("unknown rate limit policy %q", name))
+		}
+		if policy.Limit < 0 {
+			errs = append(errs, fmt.Errorf("rate limit policy %q can't have a negative limit", name))
+		}
+		if policy.Limit > 0 && policy.Window <= 0 {
+			errs = append(errs, fmt.Errorf("rate limit policy %q needs a positive window", name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
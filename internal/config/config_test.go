@@ -0,0 +1,202 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func lookupFrom(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func validEnv() map[string]string {
+	return map[string]string{
+		"DB_URL":     "sqlite://chirpy.db",
+		"JWT_SECRET": "very-secret-secret",
+		"POLKA_KEY":  "polka-key",
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := load(lookupFrom(validEnv()))
+	if err != nil {
+		t.Fatalf("couldn't load config: %v", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("defaults should be valid: %v", err)
+	}
+
+	if cfg.Port != "8080" || cfg.FilepathRoot != "." {
+		t.Errorf("unexpected defaults: port %q, root %q", cfg.Port, cfg.FilepathRoot)
+	}
+
+	if cfg.Tokens.AccessTTL != time.Hour {
+		t.Errorf("access ttl mismatch --> %s != %s <--", cfg.Tokens.AccessTTL, time.Hour)
+	}
+
+	if cfg.Tokens.RefreshTTL != 60*24*time.Hour {
+		t.Errorf("refresh ttl mismatch --> %s != %s <--", cfg.Tokens.RefreshTTL, 60*24*time.Hour)
+	}
+
+	if cfg.Limits.ChirpLength != 140 {
+		t.Errorf("chirp length mismatch --> %d != %d <--", cfg.Limits.ChirpLength, 140)
+	}
+}
+
+func TestLoadEnvOverrides(t *testing.T) {
+	env := validEnv()
+	env["PORT"] = "9000"
+	env["ACCESS_TOKEN_TTL"] = "15m"
+	env["CHIRP_MAX_LENGTH"] = "280"
+	env["MIGRATE_ON_BOOT"] = "true"
+	env["SHUTDOWN_TIMEOUT"] = "2m"
+	env["TRUSTED_PROXIES"] = "10.0.0.0/8,192.168.1.1"
+	env["STREAM_BUFFER"] = "16"
+
+	cfg, err := load(lookupFrom(env))
+	if err != nil {
+		t.Fatalf("couldn't load config: %v", err)
+	}
+
+	if cfg.Port != "9000" {
+		t.Errorf("port mismatch --> %q != %q <--", cfg.Port, "9000")
+	}
+	if cfg.Tokens.AccessTTL != 15*time.Minute {
+		t.Errorf("access ttl mismatch --> %s != %s <--", cfg.Tokens.AccessTTL, 15*time.Minute)
+	}
+	if cfg.Limits.ChirpLength != 280 {
+		t.Errorf("chirp length mismatch --> %d != %d <--", cfg.Limits.ChirpLength, 280)
+	}
+	if cfg.Stream.Buffer != 16 {
+		t.Errorf("stream buffer mismatch --> %d != %d <--", cfg.Stream.Buffer, 16)
+	}
+	if !cfg.MigrateOnBoot {
+		t.Errorf("expected migrate on boot")
+	}
+	if cfg.Server.ShutdownTimeout != 2*time.Minute {
+		t.Errorf("shutdown timeout mismatch --> %s != %s <--", cfg.Server.ShutdownTimeout, 2*time.Minute)
+	}
+	if len(cfg.RateLimit.TrustedProxies) != 2 {
+		t.Errorf("trusted proxies mismatch --> %v <--", cfg.RateLimit.TrustedProxies)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chirpy.yaml")
+	content := `
+port: "9090"
+jwt_secret: from-file
+tokens:
+  access_ttl: 30m
+server:
+  write_timeout: 1m
+rate_limit:
+  policies:
+    login:
+      limit: 3
+      window: 1m
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("couldn't write config file: %v", err)
+	}
+
+	env := validEnv()
+	env["CONFIG_FILE"] = path
+	delete(env, "JWT_SECRET")
+	env["PORT"] = "7000"
+
+	cfg, err := load(lookupFrom(env))
+	if err != nil {
+		t.Fatalf("couldn't load config: %v", err)
+	}
+
+	if cfg.JWTSecret != "from-file" {
+		t.Errorf("jwt secret mismatch --> %q != %q <--", cfg.JWTSecret, "from-file")
+	}
+	if cfg.Port != "7000" {
+		t.Errorf("environment should win over the file --> %q != %q <--", cfg.Port, "7000")
+	}
+	if cfg.Tokens.AccessTTL != 30*time.Minute {
+		t.Errorf("access ttl mismatch --> %s != %s <--", cfg.Tokens.AccessTTL, 30*time.Minute)
+	}
+	if cfg.Server.WriteTimeout != time.Minute {
+		t.Errorf("write timeout mismatch --> %s != %s <--", cfg.Server.WriteTimeout, time.Minute)
+	}
+	if cfg.RateLimit.Policies[RateLimitLogin] != (RatePolicy{Limit: 3, Window: time.Minute}) {
+		t.Errorf("login policy mismatch --> %+v <--", cfg.RateLimit.Policies[RateLimitLogin])
+	}
+	if cfg.RateLimit.Policies[RateLimitCreateChirp] != Default().RateLimit.Policies[RateLimitCreateChirp] {
+		t.Errorf("policies missing from the file should keep defaults --> %+v <--", cfg.RateLimit.Policies[RateLimitCreateChirp])
+	}
+	if cfg.Server.ReadTimeout != 10*time.Second {
+		t.Errorf("unset values should keep defaults --> %s != %s <--", cfg.Server.ReadTimeout, 10*time.Second)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	unknownField := filepath.Join(t.TempDir(), "chirpy.yaml")
+	if err := os.WriteFile(unknownField, []byte("jwt_sercet: typo\n"), 0o600); err != nil {
+		t.Fatalf("couldn't write config file: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		env  map[string]string
+	}{
+		{name: "malformed duration", env: map[string]string{"WRITE_TIMEOUT": "soon"}},
+		{name: "malformed number", env: map[string]string{"CHIRP_MAX_LENGTH": "many"}},
+		{name: "malformed bool", env: map[string]string{"MIGRATE_ON_BOOT": "yes please"}},
+		{name: "missing file", env: map[string]string{"CONFIG_FILE": filepath.Join(t.TempDir(), "missing.yaml")}},
+		{name: "unknown file field", env: map[string]string{"CONFIG_FILE": unknownField}},
+	}
+
+	for _, c := range cases {
+		_, err := load(lookupFrom(c.env))
+		if err == nil {
+			t.Errorf("%s: expected an error", c.name)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name     string
+		modify   func(*Config)
+		expected string
+	}{
+		{name: "empty jwt secret", modify: func(c *Config) { c.JWTSecret = "" }, expected: "JWT_SECRET is required"},
+		{name: "empty polka key", modify: func(c *Config) { c.PolkaKey = "" }, expected: "POLKA_KEY is required"},
+		{name: "empty db url", modify: func(c *Config) { c.DBURL = "" }, expected: "DB_URL is required"},
+		{name: "bad port", modify: func(c *Config) { c.Port = "http" }, expected: "PORT must be"},
+		{name: "negative timeout", modify: func(c *Config) { c.Server.IdleTimeout = -time.Second }, expected: "IDLE_TIMEOUT must be"},
+		{name: "zero ttl", modify: func(c *Config) { c.Tokens.RefreshTTL = 0 }, expected: "REFRESH_TOKEN_TTL must be"},
+		{name: "negative drain delay", modify: func(c *Config) { c.Server.DrainDelay = -time.Second }, expected: "DRAIN_DELAY cannot be negative"},
+		{name: "unknown rate limit store", modify: func(c *Config) { c.RateLimit.Store = "redis" }, expected: "RATE_LIMIT_STORE must be"},
+		{name: "bad trusted proxy", modify: func(c *Config) { c.RateLimit.TrustedProxies = []string{"10.0.0.0/33"} }, expected: "TRUSTED_PROXIES"},
+		{name: "unknown policy", modify: func(c *Config) { c.RateLimit.Policies["logn"] = RatePolicy{Limit: 1, Window: time.Second} }, expected: `unknown rate limit policy "logn"`},
+		{name: "policy without window", modify: func(c *Config) { c.RateLimit.Policies[RateLimitLogin] = RatePolicy{Limit: 1} }, expected: "needs a positive window"},
+		{name: "zero chirp length", modify: func(c *Config) { c.Limits.ChirpLength = 0 }, expected: "CHIRP_MAX_LENGTH must be"},
+		{name: "unknown stream fanout", modify: func(c *Config) { c.Stream.Fanout = "kafka" }, expected: "STREAM_FANOUT must be"},
+		{name: "zero stream buffer", modify: func(c *Config) { c.Stream.Buffer = 0 }, expected: "STREAM_BUFFER must be"},
+	}
+
+	for _, c := range cases {
+		cfg, err := load(lookupFrom(validEnv()))
+		if err != nil {
+			t.Fatalf("couldn't load config: %v", err)
+		}
+		c.modify(&cfg)
+
+		err = cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("%s: error mismatch --> %v != %q <--", c.name, err, c.expected)
+		}
+	}
+}
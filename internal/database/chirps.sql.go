@@ -11,6 +11,17 @@ import (
 	"github.com/google/uuid"
 )
 
+const countChirpsByUser = `-- name: CountChirpsByUser :one
+SELECT COUNT(*) FROM chirps WHERE user_id = $1
+`
+
+func (q *Queries) CountChirpsByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countChirpsByUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createChirp = `-- name: CreateChirp :one
 INSERT INTO chirps(id, created_at, updated_at, body, user_id)
 VALUES (
@@ -105,6 +116,41 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 	return i, err
 }
 
+const getChirpsByHashtag = `-- name: GetChirpsByHashtag :many
+SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE id IN (
+    SELECT chirp_id FROM chirp_entities WHERE kind = 'hashtag' AND value = $1
+) ORDER BY created_at
+`
+
+func (q *Queries) GetChirpsByHashtag(ctx context.Context, value string) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByHashtag, value)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getChirpsByUser = `-- name: GetChirpsByUser :many
 SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE user_id = $1 ORDER BY created_at
 `
@@ -19,6 +19,36 @@ type Chirp struct {
 	UserID    uuid.UUID
 }
 
+type ChirpEntity struct {
+	ChirpID     uuid.UUID
+	Kind        string
+	StartOffset int32
+	EndOffset   int32
+	Value       string
+	UserID      uuid.NullUUID
+}
+
+type Moderator struct {
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Kind      string
+	ActorID   uuid.NullUUID
+	ChirpID   uuid.NullUUID
+	ReadAt    sql.NullTime
+}
+
+type RateLimitBucket struct {
+	Key       string
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
 type RefreshToken struct {
 	Token     string
 	CreatedAt time.Time
@@ -28,6 +58,12 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type TrendingExcludedTag struct {
+	Tag        string
+	CreatedAt  time.Time
+	ExcludedBy uuid.NullUUID
+}
+
 type User struct {
 	ID             uuid.UUID
 	CreatedAt      time.Time
@@ -35,4 +71,9 @@ type User struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    sql.NullBool
+	DisabledAt     sql.NullTime
+	Username       sql.NullString
+	DisplayName    string
+	Bio            string
+	AvatarUrl      string
 }
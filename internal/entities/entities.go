@@ -0,0 +1,184 @@
+// Package entities finds the @mentions, #hashtags and URLs in chirp bodies.
+// Offsets count runes, so clients in any language can slice the body
+// without agreeing on an encoding.
+package entities
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Entity types.
+const (
+	Mention = "mention"
+	Hashtag = "hashtag"
+	URL     = "url"
+)
+
+// Limits of the names after @ and #, longer runs are not entities at all.
+const (
+	MaxUsernameLength = 15
+	MaxHashtagLength  = 100
+)
+
+// Entity is one match in a body.
+type Entity struct {
+	Type string
+	// Start and End are rune offsets into the body, End is exclusive
+	Start, End int
+	// Value is the username, the lowercased tag or the URL, mentions and
+	// hashtags without their @ or #
+	Value string
+}
+
+var schemes = []string{"https://", "http://"}
+
+// Parse returns the entities of body in order. URLs win over what looks
+// like a mention or a hashtag inside them, and mentions and hashtags have
+// to start a word, so walt@example.com mentions nobody.
+func Parse(body string) []Entity {
+	runes := []rune(body)
+
+	var found []Entity
+	for i := 0; i < len(runes); {
+		if i > 0 && isWordRune(runes[i-1]) {
+			i++
+			continue
+		}
+
+		entity, ok := parseURL(runes, i)
+		if !ok {
+			entity, ok = parseName(runes, i)
+		}
+		if !ok {
+			i++
+			continue
+		}
+
+		found = append(found, entity)
+		i = entity.End
+	}
+
+	return found
+}
+
+// ValidUsername reports whether name could be mentioned: 1 to 15 ASCII
+// letters, digits or underscores.
+func ValidUsername(name string) bool {
+	if name == "" || len(name) > MaxUsernameLength {
+		return false
+	}
+
+	for _, r := range name {
+		if !isUsernameRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeHashtag returns the stored form of tag, lowercased and without
+// a leading #, and whether it is a valid hashtag.
+func NormalizeHashtag(tag string) (string, bool) {
+	runes := []rune(strings.TrimPrefix(tag, "#"))
+	if len(runes) == 0 || len(runes) > MaxHashtagLength {
+		return "", false
+	}
+
+	letter := false
+	for _, r := range runes {
+		if !isHashtagRune(r) {
+			return "", false
+		}
+		letter = letter || unicode.IsLetter(r)
+	}
+
+	// #1 is a number, not a topic
+	if !letter {
+		return "", false
+	}
+
+	return strings.ToLower(string(runes)), true
+}
+
+func parseName(runes []rune, start int) (Entity, bool) {
+	if runes[start] != '@' && runes[start] != '#' {
+		return Entity{}, false
+	}
+
+	isNameRune := isUsernameRune
+	if runes[start] == '#' {
+		isNameRune = isHashtagRune
+	}
+
+	end := start + 1
+	for end < len(runes) && isNameRune(runes[end]) {
+		end++
+	}
+
+	name := string(runes[start+1 : end])
+	if runes[start] == '@' {
+		if !ValidUsername(name) {
+			return Entity{}, false
+		}
+		return Entity{Type: Mention, Start: start, End: end, Value: name}, true
+	}
+
+	tag, ok := NormalizeHashtag(name)
+	if !ok {
+		return Entity{}, false
+	}
+	return Entity{Type: Hashtag, Start: start, End: end, Value: tag}, true
+}
+
+func parseURL(runes []rune, start int) (Entity, bool) {
+	rest := runes[start:]
+
+	var scheme string
+	for _, s := range schemes {
+		if len(rest) > len(s) && strings.EqualFold(string(rest[:len(s)]), s) {
+			scheme = s
+			break
+		}
+	}
+	if scheme == "" {
+		return Entity{}, false
+	}
+
+	end := start + len(scheme)
+	for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(`<>"`, runes[end]) {
+		end++
+	}
+
+	// punctuation closing the sentence isn't part of the link, a closing
+	// parenthesis only is when the link opened one
+	for end > start+len(scheme) {
+		last := runes[end-1]
+		url := string(runes[start:end])
+		if strings.ContainsRune(".,:;!?'", last) || (last == ')' && strings.Count(url, "(") < strings.Count(url, ")")) {
+			end--
+			continue
+		}
+		break
+	}
+
+	if end == start+len(scheme) {
+		return Entity{}, false
+	}
+
+	return Entity{Type: URL, Start: start, End: end, Value: string(runes[start:end])}, true
+}
+
+func isUsernameRune(r rune) bool {
+	return r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')
+}
+
+func isHashtagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.In(r, unicode.Mn, unicode.Mc)
+}
+
+// isWordRune reports whether r continues a word, entities can't start
+// right after one.
+func isWordRune(r rune) bool {
+	return isHashtagRune(r)
+}
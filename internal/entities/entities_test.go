@@ -0,0 +1,148 @@
+package entities
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []Entity
+	}{
+		{
+			input:    "no entities here",
+			expected: nil,
+		},
+		{
+			input: "@walt say my name #BreakingBad",
+			expected: []Entity{
+				{Type: Mention, Start: 0, End: 5, Value: "walt"},
+				{Type: Hashtag, Start: 18, End: 30, Value: "breakingbad"},
+			},
+		},
+		{
+			input: "żółć #Zażółć @jesse_p",
+			expected: []Entity{
+				{Type: Hashtag, Start: 5, End: 12, Value: "zażółć"},
+				{Type: Mention, Start: 13, End: 21, Value: "jesse_p"},
+			},
+		},
+		{
+			input: "read https://example.com/a_(b)?q=1#top, then (see http://x.io).",
+			expected: []Entity{
+				{Type: URL, Start: 5, End: 38, Value: "https://example.com/a_(b)?q=1#top"},
+				{Type: URL, Start: 50, End: 61, Value: "http://x.io"},
+			},
+		},
+		{
+			input: "https://example.com/@walt/#tag",
+			expected: []Entity{
+				{Type: URL, Start: 0, End: 30, Value: "https://example.com/@walt/#tag"},
+			},
+		},
+		{
+			input:    "walt@example.com isn't a mention, neither is x#tag nor #1",
+			expected: nil,
+		},
+		{
+			input:    "@" + strings.Repeat("a", 16) + " is too long, so is https:// alone",
+			expected: nil,
+		},
+		{
+			input: "(@saul), #tag.",
+			expected: []Entity{
+				{Type: Mention, Start: 1, End: 6, Value: "saul"},
+				{Type: Hashtag, Start: 9, End: 13, Value: "tag"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		entities := Parse(c.input)
+		if len(entities) != len(c.expected) {
+			t.Errorf("%q: entities mismatch --> %+v != %+v <--", c.input, entities, c.expected)
+			continue
+		}
+
+		for i := range entities {
+			if entities[i] != c.expected[i] {
+				t.Errorf("%q: entity %d mismatch --> %+v != %+v <--", c.input, i, entities[i], c.expected[i])
+			}
+		}
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+		ok       bool
+	}{
+		{input: "#Go", expected: "go", ok: true},
+		{input: "golang_2025", expected: "golang_2025", ok: true},
+		{input: "#", ok: false},
+		{input: "#2025", ok: false},
+		{input: "#go-lang", ok: false},
+		{input: strings.Repeat("a", MaxHashtagLength+1), ok: false},
+	}
+
+	for _, c := range cases {
+		tag, ok := NormalizeHashtag(c.input)
+		if tag != c.expected || ok != c.ok {
+			t.Errorf("%q: hashtag mismatch --> %q %v != %q %v <--", c.input, tag, ok, c.expected, c.ok)
+		}
+	}
+}
+
+// FuzzParse checks that entities are ordered, don't overlap and point at
+// the text they were parsed from.
+func FuzzParse(f *testing.F) {
+	seeds := []string{
+		"@walt say my name #BreakingBad https://example.com",
+		"walt@example.com #1 x#tag",
+		"(https://en.wikipedia.org/wiki/Chirp_(disambiguation)).",
+		"#Zażółć @@jesse ##tag HTTPS://EXAMPLE.COM",
+		"é #é \xff @\xff",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, body string) {
+		runes := []rune(body)
+		end := 0
+
+		for _, entity := range Parse(body) {
+			if entity.Start < end || entity.End <= entity.Start || entity.End > len(runes) {
+				t.Fatalf("entity out of place --> %+v after %d in %d runes <--", entity, end, len(runes))
+			}
+			end = entity.End
+
+			text := string(runes[entity.Start:entity.End])
+			switch entity.Type {
+			case Mention:
+				if text != "@"+entity.Value || !ValidUsername(entity.Value) {
+					t.Errorf("mention mismatch --> %q %+v <--", text, entity)
+				}
+			case Hashtag:
+				tag, ok := NormalizeHashtag(text)
+				if !ok || tag != entity.Value {
+					t.Errorf("hashtag mismatch --> %q %+v <--", text, entity)
+				}
+			case URL:
+				if text != entity.Value {
+					t.Errorf("url mismatch --> %q %+v <--", text, entity)
+				}
+			default:
+				t.Errorf("unknown entity type --> %+v <--", entity)
+			}
+
+			// an entity parses the same on its own
+			alone := Parse(text)
+			if len(alone) != 1 || alone[0].Type != entity.Type || alone[0].Value != entity.Value {
+				t.Errorf("entity parses differently alone --> %q %+v != %+v <--", text, alone, entity)
+			}
+		}
+	})
+}
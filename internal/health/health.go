@@ -0,0 +1,195 @@
+// Package health runs the dependency checks behind the readiness probe.
+package health
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusFailing  = "failing"
+	StatusReady    = "ready"
+	StatusNotReady = "not ready"
+	StatusDraining = "draining"
+)
+
+// Check returns an error when the dependency it looks at can't serve
+// traffic. It should give up once ctx is done.
+type Check func(ctx context.Context) error
+
+// The readiness probe is unauthenticated, so check errors are only logged
+// and the report shows a fixed message instead.
+const (
+	messageFailing  = "failing"
+	messageTimedOut = "timed out"
+	messageLastRun  = "last run failed"
+)
+
+// Failure is a check error whose Message is safe to show in the report,
+// Err carries the details that only go to the logs.
+type Failure struct {
+	Message string
+	Err     error
+}
+
+// Fail returns a Failure reporting message, err may be nil.
+func Fail(message string, err error) error {
+	return &Failure{Message: message, Err: err}
+}
+
+func (f *Failure) Error() string {
+	if f.Err == nil {
+		return f.Message
+	}
+	return f.Message + ": " + f.Err.Error()
+}
+
+func (f *Failure) Unwrap() error {
+	return f.Err
+}
+
+type CheckResult struct {
+	Status   string  `json:"status"`
+	Error    string  `json:"error,omitempty"`
+	Duration float64 `json:"duration_ms"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Ready reports whether the instance should receive traffic.
+func (r Report) Ready() bool {
+	return r.Status == StatusReady
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+type Checker struct {
+	timeout  time.Duration
+	draining atomic.Bool
+
+	mu     sync.Mutex
+	checks []namedCheck
+}
+
+// New returns a Checker that gives each check timeout to finish.
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+func (c *Checker) Add(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Worker registers a background worker under "worker:<name>". The worker
+// counts as healthy until it reports a failure.
+func (c *Checker) Worker(name string) *Worker {
+	worker := &Worker{}
+	c.Add("worker:"+name, worker.check)
+	return worker
+}
+
+// Drain marks the instance as not ready for good, so load balancers stop
+// sending it new requests while in-flight ones finish.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Run runs every check concurrently and collects the results. A draining
+// instance skips the checks.
+func (c *Checker) Run(ctx context.Context) Report {
+	if c.draining.Load() {
+		return Report{Status: StatusDraining}
+	}
+
+	c.mu.Lock()
+	checks := append([]namedCheck{}, c.checks...)
+	c.mu.Unlock()
+
+	results := make([]CheckResult, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.runCheck(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusReady, Checks: map[string]CheckResult{}}
+	for i, check := range checks {
+		report.Checks[check.name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusNotReady
+		}
+	}
+
+	return report
+}
+
+func (c *Checker) runCheck(ctx context.Context, check namedCheck) CheckResult {
+	checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.check(checkCtx)
+	duration := float64(time.Since(start).Microseconds()) / 1000
+
+	if err == nil {
+		return CheckResult{Status: StatusOK, Duration: duration}
+	}
+
+	slog.WarnContext(ctx, "readiness check failed", "check", check.name, "error", err)
+
+	message := messageFailing
+	var failure *Failure
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		message = messageTimedOut
+	case errors.As(err, &failure):
+		message = failure.Message
+	}
+
+	return CheckResult{Status: StatusFailing, Error: message, Duration: duration}
+}
+
+// Worker is the handle a background worker uses to report how its last run
+// went.
+type Worker struct {
+	mu      sync.Mutex
+	lastErr error
+}
+
+// Report records the outcome of the worker's latest run, nil clears an
+// earlier failure.
+func (w *Worker) Report(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.lastErr = err
+}
+
+func (w *Worker) check(context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.lastErr != nil {
+		return Fail(messageLastRun, w.lastErr)
+	}
+	return nil
+}
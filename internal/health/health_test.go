@@ -0,0 +1,85 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	checker := New(50 * time.Millisecond)
+	checker.Add("healthy", func(context.Context) error { return nil })
+
+	report := checker.Run(context.Background())
+	if !report.Ready() {
+		t.Fatalf("status mismatch --> %s != %s <--", report.Status, StatusReady)
+	}
+
+	if report.Checks["healthy"].Status != StatusOK {
+		t.Errorf("check status mismatch --> %s != %s <--", report.Checks["healthy"].Status, StatusOK)
+	}
+
+	checker.Add("broken", func(context.Context) error { return errors.New("dial tcp db.internal:5432: connection refused") })
+	checker.Add("behind", func(context.Context) error { return Fail("behind by 2 migrations", nil) })
+	checker.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report = checker.Run(context.Background())
+	if report.Ready() {
+		t.Fatalf("status mismatch --> %s != %s <--", report.Status, StatusNotReady)
+	}
+
+	expected := map[string]CheckResult{
+		"healthy": {Status: StatusOK},
+		"broken":  {Status: StatusFailing, Error: "failing"},
+		"behind":  {Status: StatusFailing, Error: "behind by 2 migrations"},
+		"slow":    {Status: StatusFailing, Error: "timed out"},
+	}
+	for name, want := range expected {
+		got := report.Checks[name]
+		if got.Status != want.Status || got.Error != want.Error {
+			t.Errorf("%s: result mismatch --> %+v != %+v <--", name, got, want)
+		}
+	}
+}
+
+func TestWorker(t *testing.T) {
+	checker := New(time.Second)
+	worker := checker.Worker("trending")
+
+	if !checker.Run(context.Background()).Ready() {
+		t.Fatalf("worker that hasn't run yet should count as healthy")
+	}
+
+	worker.Report(errors.New("query failed"))
+
+	report := checker.Run(context.Background())
+	if report.Ready() {
+		t.Fatalf("failed worker should make the instance not ready")
+	}
+	if report.Checks["worker:trending"].Error != "last run failed" {
+		t.Errorf("error mismatch --> %q != %q <--", report.Checks["worker:trending"].Error, "last run failed")
+	}
+
+	worker.Report(nil)
+	if !checker.Run(context.Background()).Ready() {
+		t.Errorf("a successful run should clear the failure")
+	}
+}
+
+func TestDrain(t *testing.T) {
+	checker := New(time.Second)
+	checker.Add("healthy", func(context.Context) error { return nil })
+	checker.Drain()
+
+	report := checker.Run(context.Background())
+	if report.Status != StatusDraining {
+		t.Errorf("status mismatch --> %s != %s <--", report.Status, StatusDraining)
+	}
+	if report.Ready() {
+		t.Errorf("draining instance shouldn't be ready")
+	}
+}
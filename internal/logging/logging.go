@@ -0,0 +1,89 @@
+// Package logging carries per-request values through the context and adds
+// them to every log line written with the slog *Context functions.
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+type contextKey struct{}
+
+// requestInfo is shared between the middleware and the handlers of a single
+// request, handlers fill in the user once they have authenticated it.
+type requestInfo struct {
+	requestId string
+
+	mu     sync.Mutex
+	userId uuid.UUID
+}
+
+// WithRequestId returns a context that carries requestId and has room for the
+// authenticated user.
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, contextKey{}, &requestInfo{requestId: requestId})
+}
+
+func RequestId(ctx context.Context) string {
+	info, ok := ctx.Value(contextKey{}).(*requestInfo)
+	if !ok {
+		return ""
+	}
+	return info.requestId
+}
+
+// SetUserId records the authenticated user of the request in ctx.
+func SetUserId(ctx context.Context, userId uuid.UUID) {
+	info, ok := ctx.Value(contextKey{}).(*requestInfo)
+	if !ok {
+		return
+	}
+
+	info.mu.Lock()
+	defer info.mu.Unlock()
+	info.userId = userId
+}
+
+func UserId(ctx context.Context) uuid.UUID {
+	info, ok := ctx.Value(contextKey{}).(*requestInfo)
+	if !ok {
+		return uuid.Nil
+	}
+
+	info.mu.Lock()
+	defer info.mu.Unlock()
+	return info.userId
+}
+
+// Handler adds the request id and user id found in the context to every
+// record before passing it on.
+type Handler struct {
+	slog.Handler
+}
+
+func NewHandler(next slog.Handler) *Handler {
+	return &Handler{Handler: next}
+}
+
+func (h *Handler) Handle(ctx context.Context, record slog.Record) error {
+	if requestId := RequestId(ctx); requestId != "" {
+		record.AddAttrs(slog.String("request_id", requestId))
+	}
+
+	if userId := UserId(ctx); userId != uuid.Nil {
+		record.AddAttrs(slog.String("user_id", userId.String()))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHandlerAddsRequestValues(t *testing.T) {
+	userId := uuid.New()
+
+	cases := []struct {
+		name              string
+		ctx               func() context.Context
+		expectedRequestId string
+		expectedUserId    string
+	}{
+		{
+			name:              "no request",
+			ctx:               context.Background,
+			expectedRequestId: "",
+			expectedUserId:    "",
+		},
+		{
+			name: "anonymous request",
+			ctx: func() context.Context {
+				return WithRequestId(context.Background(), "request-1")
+			},
+			expectedRequestId: "request-1",
+			expectedUserId:    "",
+		},
+		{
+			name: "authenticated request",
+			ctx: func() context.Context {
+				ctx := WithRequestId(context.Background(), "request-2")
+				SetUserId(ctx, userId)
+				return ctx
+			},
+			expectedRequestId: "request-2",
+			expectedUserId:    userId.String(),
+		},
+	}
+
+	for _, c := range cases {
+		var buffer bytes.Buffer
+		logger := slog.New(NewHandler(slog.NewJSONHandler(&buffer, nil))).With("component", "test")
+
+		logger.InfoContext(c.ctx(), "hello")
+
+		var line map[string]any
+		if err := json.Unmarshal(buffer.Bytes(), &line); err != nil {
+			t.Fatalf("%s: couldn't unmarshal log line: %v", c.name, err)
+		}
+
+		requestId, _ := line["request_id"].(string)
+		if requestId != c.expectedRequestId {
+			t.Errorf("%s: request id mismatch --> %s != %s <--", c.name, requestId, c.expectedRequestId)
+		}
+
+		userId, _ := line["user_id"].(string)
+		if userId != c.expectedUserId {
+			t.Errorf("%s: user id mismatch --> %s != %s <--", c.name, userId, c.expectedUserId)
+		}
+
+		if line["component"] != "test" {
+			t.Errorf("%s: attributes from With should be kept", c.name)
+		}
+	}
+}
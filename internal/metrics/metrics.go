@@ -0,0 +1,180 @@
+// Package metrics exposes the Prometheus series chirpy records.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "chirpy"
+
+const (
+	LoginSucceeded = "succeeded"
+	LoginFailed    = "failed"
+)
+
+// Webhook outcomes, kept to a fixed set so client input can't create series.
+const (
+	WebhookUpgraded     = "upgraded"
+	WebhookIgnored      = "ignored"
+	WebhookUnauthorized = "unauthorized"
+	WebhookInvalid      = "invalid"
+	WebhookUnknownUser  = "unknown_user"
+	WebhookFailed       = "failed"
+)
+
+// Event stream transports.
+const (
+	TransportSSE       = "sse"
+	TransportWebSocket = "websocket"
+)
+
+type Metrics struct {
+	registry        *prometheus.Registry
+	requestDuration *prometheus.HistogramVec
+	chirpsCreated   prometheus.Counter
+	logins          *prometheus.CounterVec
+	webhooks        *prometheus.CounterVec
+	rateLimited     *prometheus.CounterVec
+	streams         *prometheus.GaugeVec
+	streamsLagged   *prometheus.CounterVec
+}
+
+// New registers the chirpy series along with Go runtime and process metrics.
+// db may be nil, e.g. when running on the in-memory store, in which case no
+// connection pool stats are exported. fileserverHits reports the current
+// value of the /app hit counter.
+func New(db *sql.DB, fileserverHits func() float64) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "http_request_duration_seconds",
+				Help:      "Duration of HTTP requests by route pattern, method and status.",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"route", "method", "status"},
+		),
+		chirpsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "chirps_created_total",
+			Help:      "Number of chirps created.",
+		}),
+		logins: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "logins_total",
+				Help:      "Number of login attempts by result.",
+			},
+			[]string{"result"},
+		),
+		webhooks: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "polka_webhooks_total",
+				Help:      "Number of Polka webhooks received by outcome.",
+			},
+			[]string{"outcome"},
+		),
+		rateLimited: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "rate_limited_requests_total",
+				Help:      "Number of requests rejected by a rate limit policy.",
+			},
+			[]string{"policy"},
+		),
+		streams: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "chirp_streams",
+				Help:      "Number of open chirp event streams by transport.",
+			},
+			[]string{"transport"},
+		),
+		streamsLagged: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "chirp_streams_lagged_total",
+				Help:      "Number of chirp event streams closed for falling behind by transport.",
+			},
+			[]string{"transport"},
+		),
+	}
+
+	m.registry.MustRegister(
+		m.requestDuration,
+		m.chirpsCreated,
+		m.logins,
+		m.webhooks,
+		m.rateLimited,
+		m.streams,
+		m.streamsLagged,
+		prometheus.NewGaugeFunc(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "fileserver_hits",
+				Help:      "Number of /app requests since start or the last admin reset.",
+			},
+			fileserverHits,
+		),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	if db != nil {
+		m.registry.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+	}
+
+	return m
+}
+
+// Handler serves the registered series in the Prometheus text format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+func (m *Metrics) ObserveRequest(route, method string, status int, duration time.Duration) {
+	m.requestDuration.WithLabelValues(route, method, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ChirpCreated() {
+	m.chirpsCreated.Inc()
+}
+
+// Login records a login attempt, result is LoginSucceeded or LoginFailed.
+func (m *Metrics) Login(result string) {
+	m.logins.WithLabelValues(result).Inc()
+}
+
+// Webhook records the outcome of a Polka webhook, one of the Webhook*
+// constants.
+func (m *Metrics) Webhook(outcome string) {
+	m.webhooks.WithLabelValues(outcome).Inc()
+}
+
+// RateLimited records a request rejected by the named policy.
+func (m *Metrics) RateLimited(policy string) {
+	m.rateLimited.WithLabelValues(policy).Inc()
+}
+
+// StreamOpened counts an event stream up, transport is one of the Transport*
+// constants.
+func (m *Metrics) StreamOpened(transport string) {
+	m.streams.WithLabelValues(transport).Inc()
+}
+
+// StreamClosed counts the stream down, lagged ones are counted separately.
+func (m *Metrics) StreamClosed(transport string, lagged bool) {
+	m.streams.WithLabelValues(transport).Dec()
+	if lagged {
+		m.streamsLagged.WithLabelValues(transport).Inc()
+	}
+}
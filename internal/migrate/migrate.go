@@ -0,0 +1,85 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+
+	chirpysql "github.com/magicznykacpur/chirpy/sql"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// lockID is the Postgres advisory lock held while migrating, so replicas
+// starting at the same time apply migrations one after another.
+const lockID = 0x636869727079 // "chirpy"
+
+type Migrator struct {
+	provider *goose.Provider
+}
+
+// New returns a Migrator for db. backend is either "postgres" or "sqlite",
+// matching the DB_URL scheme the database was opened with.
+func New(db *sql.DB, backend string) (*Migrator, error) {
+	var dialect goose.Dialect
+	var migrations fs.FS
+	opts := []goose.ProviderOption{}
+
+	switch backend {
+	case "postgres":
+		locker, err := lock.NewPostgresSessionLocker(lock.WithLockID(lockID))
+		if err != nil {
+			return nil, err
+		}
+
+		dialect = goose.DialectPostgres
+		migrations = chirpysql.PostgresMigrations
+		opts = append(opts, goose.WithSessionLocker(locker))
+	case "sqlite":
+		dialect = goose.DialectSQLite3
+		migrations = chirpysql.SQLiteMigrations
+	default:
+		return nil, fmt.Errorf("unsupported migration backend: %q", backend)
+	}
+
+	provider, err := goose.NewProvider(dialect, db, migrations, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create migration provider: %v", err)
+	}
+
+	return &Migrator{provider: provider}, nil
+}
+
+func (m *Migrator) Up(ctx context.Context) ([]*goose.MigrationResult, error) {
+	return m.provider.Up(ctx)
+}
+
+func (m *Migrator) Down(ctx context.Context) (*goose.MigrationResult, error) {
+	return m.provider.Down(ctx)
+}
+
+// Redo rolls back the latest migration and applies it again.
+func (m *Migrator) Redo(ctx context.Context) ([]*goose.MigrationResult, error) {
+	down, err := m.provider.Down(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	up, err := m.provider.UpByOne(ctx)
+	if err != nil {
+		return []*goose.MigrationResult{down}, err
+	}
+
+	return []*goose.MigrationResult{down, up}, nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	return m.provider.Status(ctx)
+}
+
+// Versions returns the version the database is at and the latest version
+// embedded in the binary.
+func (m *Migrator) Versions(ctx context.Context) (current, target int64, err error) {
+	return m.provider.GetVersions(ctx)
+}
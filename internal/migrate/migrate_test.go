@@ -0,0 +1,130 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pressly/goose/v3"
+	_ "modernc.org/sqlite"
+)
+
+func TestMigrateSQLite(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "chirpy.db"))
+	if err != nil {
+		t.Fatalf("couldn't open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := New(db, "sqlite")
+	if err != nil {
+		t.Fatalf("couldn't create migrator: %v", err)
+	}
+
+	ctx := context.Background()
+
+	results, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("couldn't migrate up: %v", err)
+	}
+
+	current, target, err := migrator.Versions(ctx)
+	if err != nil {
+		t.Fatalf("couldn't get versions: %v", err)
+	}
+
+	if current != target || int64(len(results)) != target {
+		t.Errorf("versions mismatch --> %d != %d, applied %d <--", current, target, len(results))
+	}
+
+	results, err = migrator.Redo(ctx)
+	if err != nil || len(results) != 2 {
+		t.Fatalf("couldn't redo: %v", err)
+	}
+
+	_, err = migrator.Down(ctx)
+	if err != nil {
+		t.Fatalf("couldn't migrate down: %v", err)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("couldn't get status: %v", err)
+	}
+
+	last := statuses[len(statuses)-1]
+	if last.State != goose.StatePending {
+		t.Errorf("latest migration should be pending after down, got %s", last.State)
+	}
+
+	for _, status := range statuses[:len(statuses)-1] {
+		if status.State != goose.StateApplied {
+			t.Errorf("%s should be applied, got %s", status.Source.Path, status.State)
+		}
+	}
+}
+
+func TestMigrateSQLiteCaseDuplicateUsernames(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "chirpy.db"))
+	if err != nil {
+		t.Fatalf("couldn't open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	migrator, err := New(db, "sqlite")
+	if err != nil {
+		t.Fatalf("couldn't create migrator: %v", err)
+	}
+
+	ctx := context.Background()
+
+	// 12 makes usernames unique regardless of case
+	_, err = migrator.provider.UpTo(ctx, 11)
+	if err != nil {
+		t.Fatalf("couldn't migrate up to 11: %v", err)
+	}
+
+	users := []struct {
+		username string
+		expected string
+	}{
+		{username: "Walt", expected: "Walt"},
+		{username: "walt", expected: "walt_2"},
+		{username: "WALT", expected: "WALT_3"},
+		{username: "heisenberg_walt", expected: "heisenberg_walt"},
+		{username: "Heisenberg_Walt", expected: "Heisenberg_Wa_2"},
+		{username: "jesse", expected: "jesse"},
+	}
+	for i, user := range users {
+		createdAt := time.Date(2008, time.January, 20, 0, 0, i, 0, time.UTC).Format(time.RFC3339)
+		_, err = db.ExecContext(ctx, "INSERT INTO users (id, created_at, updated_at, email, username) VALUES (?, ?, ?, ?, ?)",
+			uuid.NewString(), createdAt, createdAt, fmt.Sprintf("user%d@example.com", i), user.username)
+		if err != nil {
+			t.Fatalf("couldn't insert %s: %v", user.username, err)
+		}
+	}
+
+	_, err = migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("couldn't migrate up: %v", err)
+	}
+
+	for i, user := range users {
+		var username string
+		err = db.QueryRowContext(ctx, "SELECT username FROM users WHERE email = ?", fmt.Sprintf("user%d@example.com", i)).Scan(&username)
+		if err != nil || username != user.expected {
+			t.Errorf("username mismatch --> %s %v != %s <--", username, err, user.expected)
+		}
+	}
+}
+
+func TestNewUnsupportedBackend(t *testing.T) {
+	_, err := New(nil, "mysql")
+	if err == nil {
+		t.Errorf("mysql backend should not be supported")
+	}
+}
@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatalf("couldn't parse trusted proxies: %v", err)
+	}
+
+	cases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		expected     string
+	}{
+		{name: "direct", remoteAddr: "203.0.113.7:5000", expected: "203.0.113.7"},
+		{name: "untrusted peer can't spoof", remoteAddr: "203.0.113.7:5000", forwardedFor: []string{"1.2.3.4"}, expected: "203.0.113.7"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.5:5000", forwardedFor: []string{"198.51.100.9"}, expected: "198.51.100.9"},
+		{name: "client prepends a fake hop", remoteAddr: "10.0.0.5:5000", forwardedFor: []string{"1.2.3.4, 198.51.100.9"}, expected: "198.51.100.9"},
+		{name: "chain of proxies", remoteAddr: "10.0.0.5:5000", forwardedFor: []string{"198.51.100.9, 192.168.1.1", "10.1.2.3"}, expected: "198.51.100.9"},
+		{name: "garbled hop", remoteAddr: "10.0.0.5:5000", forwardedFor: []string{"198.51.100.9, nonsense"}, expected: "10.0.0.5"},
+		{name: "no header from proxy", remoteAddr: "10.0.0.5:5000", expected: "10.0.0.5"},
+		{name: "ipv6", remoteAddr: "[2001:db8::1]:5000", expected: "2001:db8::1"},
+		{name: "ipv4 mapped", remoteAddr: "[::ffff:10.0.0.5]:5000", forwardedFor: []string{"198.51.100.9"}, expected: "198.51.100.9"},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = c.remoteAddr
+		for _, value := range c.forwardedFor {
+			r.Header.Add("X-Forwarded-For", value)
+		}
+
+		ip := ClientIP(r, trusted)
+		if ip != c.expected {
+			t.Errorf("%s: ip mismatch --> %s != %s <--", c.name, ip, c.expected)
+		}
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	for _, value := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0"} {
+		_, err := ParseTrustedProxies([]string{value})
+		if err == nil {
+			t.Errorf("%s: expected an error", value)
+		}
+	}
+}
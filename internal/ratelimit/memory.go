@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// Memory keeps buckets in a map, the limits only hold for a single instance.
+type Memory struct {
+	mu      sync.Mutex
+	buckets map[string]bucket
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: map[string]bucket{}}
+}
+
+func (m *Memory) Take(_ context.Context, key string, policy Policy, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	tokens := float64(policy.Limit)
+	b, ok := m.buckets[key]
+	if ok {
+		tokens = refill(policy, b.tokens, b.updatedAt, now)
+	}
+
+	if tokens < 1 {
+		return newResult(policy, tokens, false), nil
+	}
+
+	tokens--
+	m.buckets[key] = bucket{tokens: tokens, updatedAt: now}
+
+	return newResult(policy, tokens, true), nil
+}
+
+func (m *Memory) Sweep(_ context.Context, before time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, b := range m.buckets {
+		if b.updatedAt.Before(before) {
+			delete(m.buckets, key)
+		}
+	}
+
+	return nil
+}
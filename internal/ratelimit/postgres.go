@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/magicznykacpur/chirpy/internal/database"
+)
+
+// Postgres keeps buckets in the rate_limit_buckets table so every instance
+// behind the load balancer draws from the same ones. A token is taken in a
+// single statement, concurrent requests for one key serialise on its row.
+type Postgres struct {
+	q *database.Queries
+}
+
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{q: database.New(db)}
+}
+
+func (p *Postgres) Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error) {
+	// timestamps are stored without a zone, keep them all in UTC
+	now = now.UTC()
+
+	tokens, err := p.q.TakeRateLimitToken(ctx, database.TakeRateLimitTokenParams{
+		Key:   key,
+		Burst: float64(policy.Limit),
+		Now:   now,
+		Rate:  policy.rate(),
+	})
+	if err == nil {
+		return newResult(policy, tokens, true), nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Result{}, err
+	}
+
+	// the update was skipped because the bucket is empty, read it back to
+	// tell the client when to retry
+	b, err := p.q.GetRateLimitBucket(ctx, key)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return newResult(policy, refill(policy, b.Tokens, b.UpdatedAt, now), false), nil
+}
+
+func (p *Postgres) Sweep(ctx context.Context, before time.Time) error {
+	return p.q.DeleteRateLimitBucketsBefore(ctx, before.UTC())
+}
@@ -0,0 +1,35 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+)
+
+// TestPostgres runs against the database in TEST_DB_URL, which must already be
+// migrated. The bucket table is emptied before each case.
+func TestPostgres(t *testing.T) {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set")
+	}
+
+	runStoreTests(t, func(t *testing.T) Store {
+		db, err := sql.Open("postgres", dbURL)
+		if err != nil {
+			t.Fatalf("couldn't open postgres: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		s := NewPostgres(db)
+		if err := s.Sweep(context.Background(), time.Now().Add(100*365*24*time.Hour)); err != nil {
+			t.Fatalf("couldn't reset postgres: %v", err)
+		}
+
+		return s
+	})
+}
@@ -0,0 +1,75 @@
+// Package ratelimit implements token bucket rate limiting. Buckets live in a
+// Store, in memory for a single instance or in Postgres when several
+// instances have to share them.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Policy lets Limit requests through in a burst and refills the bucket
+// completely over Window.
+type Policy struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// rate is the number of tokens added back per second.
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Window.Seconds()
+}
+
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next request would be let through,
+	// zero when this one was.
+	RetryAfter time.Duration
+}
+
+type Store interface {
+	// Take removes a token from the bucket under key, refilling it first
+	// for the time passed since the last call.
+	Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error)
+	// Sweep drops buckets untouched since before. A bucket idle for a whole
+	// window is full, so dropping it doesn't change any decision.
+	Sweep(ctx context.Context, before time.Time) error
+}
+
+// refill returns the tokens in a bucket that had tokens at updatedAt.
+func refill(policy Policy, tokens float64, updatedAt, now time.Time) float64 {
+	elapsed := now.Sub(updatedAt).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	return math.Min(float64(policy.Limit), tokens+elapsed*policy.rate())
+}
+
+// newResult describes a bucket left with tokens after the decision.
+func newResult(policy Policy, tokens float64, allowed bool) Result {
+	result := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     durationFor(policy, float64(policy.Limit)-tokens),
+	}
+
+	if !allowed {
+		result.RetryAfter = durationFor(policy, 1-tokens)
+	}
+
+	return result
+}
+
+func durationFor(policy Policy, tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+
+	return time.Duration(tokens / policy.rate() * float64(time.Second))
+}
@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func runStoreTests(t *testing.T, newStore func(t *testing.T) Store) {
+	t.Helper()
+
+	ctx := context.Background()
+	policy := Policy{Name: "test", Limit: 3, Window: 3 * time.Second}
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("burst then reject", func(t *testing.T) {
+		s := newStore(t)
+
+		for i := range 3 {
+			result, err := s.Take(ctx, "burst", policy, now)
+			if err != nil {
+				t.Fatalf("couldn't take token: %v", err)
+			}
+			if !result.Allowed {
+				t.Fatalf("request %d should be allowed", i)
+			}
+			if result.Remaining != 2-i {
+				t.Errorf("remaining mismatch --> %d != %d <--", result.Remaining, 2-i)
+			}
+		}
+
+		result, err := s.Take(ctx, "burst", policy, now)
+		if err != nil {
+			t.Fatalf("couldn't take token: %v", err)
+		}
+		if result.Allowed {
+			t.Fatalf("fourth request should be rejected")
+		}
+		if result.RetryAfter != time.Second {
+			t.Errorf("retry after mismatch --> %s != %s <--", result.RetryAfter, time.Second)
+		}
+		if result.Reset != 3*time.Second {
+			t.Errorf("reset mismatch --> %s != %s <--", result.Reset, 3*time.Second)
+		}
+	})
+
+	t.Run("refill", func(t *testing.T) {
+		s := newStore(t)
+
+		for range 3 {
+			if _, err := s.Take(ctx, "refill", policy, now); err != nil {
+				t.Fatalf("couldn't take token: %v", err)
+			}
+		}
+
+		result, err := s.Take(ctx, "refill", policy, now.Add(time.Second))
+		if err != nil {
+			t.Fatalf("couldn't take token: %v", err)
+		}
+		if !result.Allowed {
+			t.Errorf("a token should have been refilled after a second")
+		}
+
+		result, err = s.Take(ctx, "refill", policy, now.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("couldn't take token: %v", err)
+		}
+		if result.Remaining != 2 {
+			t.Errorf("bucket should refill up to the limit --> %d != %d <--", result.Remaining, 2)
+		}
+	})
+
+	t.Run("keys are independent", func(t *testing.T) {
+		s := newStore(t)
+
+		for range 3 {
+			if _, err := s.Take(ctx, "first", policy, now); err != nil {
+				t.Fatalf("couldn't take token: %v", err)
+			}
+		}
+
+		result, err := s.Take(ctx, "second", policy, now)
+		if err != nil {
+			t.Fatalf("couldn't take token: %v", err)
+		}
+		if !result.Allowed {
+			t.Errorf("another key shouldn't share the bucket")
+		}
+	})
+
+	t.Run("sweep", func(t *testing.T) {
+		s := newStore(t)
+
+		for range 3 {
+			if _, err := s.Take(ctx, "sweep", policy, now); err != nil {
+				t.Fatalf("couldn't take token: %v", err)
+			}
+		}
+
+		if err := s.Sweep(ctx, now.Add(time.Second)); err != nil {
+			t.Fatalf("couldn't sweep: %v", err)
+		}
+
+		result, err := s.Take(ctx, "sweep", policy, now)
+		if err != nil {
+			t.Fatalf("couldn't take token: %v", err)
+		}
+		if result.Remaining != 2 {
+			t.Errorf("swept bucket should start full --> %d != %d <--", result.Remaining, 2)
+		}
+	})
+}
+
+func TestMemory(t *testing.T) {
+	runStoreTests(t, func(t *testing.T) Store { return NewMemory() })
+}
@@ -0,0 +1,723 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/internal/database"
+)
+
+// Memory is a concurrency-safe Store kept entirely in process memory. It
+// mirrors the Postgres behaviour handlers rely on, including the apperr
+// errors and deleting users cascading to their chirps, chirp entities,
+// refresh tokens, notifications and moderator rights.
+type Memory struct {
+	mu            sync.RWMutex
+	users         map[uuid.UUID]database.User
+	chirps        map[uuid.UUID]database.Chirp
+	chirpEntities map[uuid.UUID][]database.ChirpEntity
+	refreshTokens map[string]database.RefreshToken
+	notifications map[uuid.UUID]database.Notification
+	moderators    map[uuid.UUID]database.Moderator
+	excludedTags  map[string]database.TrendingExcludedTag
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		users:         map[uuid.UUID]database.User{},
+		chirps:        map[uuid.UUID]database.Chirp{},
+		chirpEntities: map[uuid.UUID][]database.ChirpEntity{},
+		refreshTokens: map[string]database.RefreshToken{},
+		notifications: map[uuid.UUID]database.Notification{},
+		moderators:    map[uuid.UUID]database.Moderator{},
+		excludedTags:  map[string]database.TrendingExcludedTag{},
+	}
+}
+
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+func (m *Memory) Createuser(ctx context.Context, arg database.CreateuserParams) (database.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.emailTaken(arg.Email, uuid.Nil) {
+		return database.User{}, mapError(errUniqueViolation, resourceUser)
+	}
+
+	if m.usernameTaken(arg.Username, uuid.Nil) {
+		return database.User{}, mapError(errors.Join(errUsernameTaken, errUniqueViolation), resourceUser)
+	}
+
+	createdAt := now()
+	user := database.User{
+		ID:             uuid.New(),
+		CreatedAt:      createdAt,
+		UpdatedAt:      createdAt,
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+		IsChirpyRed:    sql.NullBool{Bool: false, Valid: true},
+		Username:       arg.Username,
+	}
+	m.users[user.ID] = user
+
+	return user, nil
+}
+
+func (m *Memory) DeleteUsers(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.users = map[uuid.UUID]database.User{}
+	m.chirps = map[uuid.UUID]database.Chirp{}
+	m.chirpEntities = map[uuid.UUID][]database.ChirpEntity{}
+	m.refreshTokens = map[string]database.RefreshToken{}
+	m.notifications = map[uuid.UUID]database.Notification{}
+	m.moderators = map[uuid.UUID]database.Moderator{}
+	for tag, excluded := range m.excludedTags {
+		excluded.ExcludedBy = uuid.NullUUID{}
+		m.excludedTags[tag] = excluded
+	}
+
+	return nil
+}
+
+func (m *Memory) GetUsers(ctx context.Context) ([]database.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	users := make([]database.User, 0, len(m.users))
+	for _, user := range m.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].CreatedAt.Before(users[j].CreatedAt)
+	})
+
+	return users, nil
+}
+
+func (m *Memory) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, user := range m.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+
+	return database.User{}, mapError(sql.ErrNoRows, resourceUser)
+}
+
+func (m *Memory) GetUserById(ctx context.Context, id uuid.UUID) (database.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		return database.User{}, mapError(sql.ErrNoRows, resourceUser)
+	}
+
+	return user, nil
+}
+
+func (m *Memory) GetUserByUsername(ctx context.Context, username string) (database.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, user := range m.users {
+		if user.Username.Valid && strings.EqualFold(user.Username.String, username) {
+			return user, nil
+		}
+	}
+
+	return database.User{}, mapError(sql.ErrNoRows, resourceUser)
+}
+
+func (m *Memory) UpdateUserEmailAndPassword(ctx context.Context, arg database.UpdateUserEmailAndPasswordParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[arg.ID]
+	if !ok {
+		return nil
+	}
+
+	if m.emailTaken(arg.Email, arg.ID) {
+		return mapError(errUniqueViolation, resourceUser)
+	}
+
+	if m.usernameTaken(arg.Username, arg.ID) {
+		return mapError(errors.Join(errUsernameTaken, errUniqueViolation), resourceUser)
+	}
+
+	user.Email = arg.Email
+	user.HashedPassword = arg.HashedPassword
+	if arg.Username.Valid {
+		user.Username = arg.Username
+	}
+	user.UpdatedAt = now()
+	m.users[user.ID] = user
+
+	return nil
+}
+
+func (m *Memory) UpdateUserProfile(ctx context.Context, arg database.UpdateUserProfileParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[arg.ID]
+	if !ok {
+		return nil
+	}
+
+	if m.usernameTaken(arg.Username, arg.ID) {
+		return mapError(errors.Join(errUsernameTaken, errUniqueViolation), resourceUser)
+	}
+
+	if arg.Username.Valid {
+		user.Username = arg.Username
+	}
+	if arg.DisplayName.Valid {
+		user.DisplayName = arg.DisplayName.String
+	}
+	if arg.Bio.Valid {
+		user.Bio = arg.Bio.String
+	}
+	if arg.AvatarUrl.Valid {
+		user.AvatarUrl = arg.AvatarUrl.String
+	}
+	user.UpdatedAt = now()
+	m.users[user.ID] = user
+
+	return nil
+}
+
+func (m *Memory) UpdateIsChirpyRed(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		return nil
+	}
+
+	user.IsChirpyRed = sql.NullBool{Bool: true, Valid: true}
+	user.UpdatedAt = now()
+	m.users[user.ID] = user
+
+	return nil
+}
+
+func (m *Memory) UpdateUserPassword(ctx context.Context, arg database.UpdateUserPasswordParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[arg.ID]
+	if !ok {
+		return nil
+	}
+
+	user.HashedPassword = arg.HashedPassword
+	user.UpdatedAt = now()
+	m.users[user.ID] = user
+
+	return nil
+}
+
+func (m *Memory) DisableUser(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[id]
+	if !ok || user.DisabledAt.Valid {
+		return nil
+	}
+
+	disabledAt := now()
+	user.DisabledAt = sql.NullTime{Time: disabledAt, Valid: true}
+	user.UpdatedAt = disabledAt
+	m.users[user.ID] = user
+
+	return nil
+}
+
+// emailTaken must be called with m.mu held.
+func (m *Memory) emailTaken(email string, except uuid.UUID) bool {
+	for _, user := range m.users {
+		if user.Email == email && user.ID != except {
+			return true
+		}
+	}
+	return false
+}
+
+// usernameTaken must be called with m.mu held, a missing username is
+// never taken and case doesn't matter.
+func (m *Memory) usernameTaken(username sql.NullString, except uuid.UUID) bool {
+	if !username.Valid {
+		return false
+	}
+
+	for _, user := range m.users {
+		if user.Username.Valid && strings.EqualFold(user.Username.String, username.String) && user.ID != except {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Memory) CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[arg.UserID]; !ok {
+		return database.Chirp{}, mapError(errForeignKeyViolation, resourceChirp)
+	}
+
+	createdAt := now()
+	chirp := database.Chirp{
+		ID:        uuid.New(),
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+		Body:      arg.Body,
+		UserID:    arg.UserID,
+	}
+	m.chirps[chirp.ID] = chirp
+
+	return chirp, nil
+}
+
+func (m *Memory) GetChirp(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	chirp, ok := m.chirps[id]
+	if !ok {
+		return database.Chirp{}, mapError(sql.ErrNoRows, resourceChirp)
+	}
+
+	return chirp, nil
+}
+
+func (m *Memory) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	chirps := []database.Chirp{}
+	for _, chirp := range m.chirps {
+		if chirp.UserID == userID {
+			chirps = append(chirps, chirp)
+		}
+	}
+	sortChirps(chirps)
+
+	return chirps, nil
+}
+
+func (m *Memory) CountChirpsByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int64
+	for _, chirp := range m.chirps {
+		if chirp.UserID == userID {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (m *Memory) GetChirpsByHashtag(ctx context.Context, tag string) ([]database.Chirp, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	chirps := []database.Chirp{}
+	for chirpId, entities := range m.chirpEntities {
+		for _, entity := range entities {
+			if entity.Kind == "hashtag" && entity.Value == tag {
+				chirps = append(chirps, m.chirps[chirpId])
+				break
+			}
+		}
+	}
+	sortChirps(chirps)
+
+	return chirps, nil
+}
+
+func (m *Memory) GetAllChirps(ctx context.Context) ([]database.Chirp, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	chirps := make([]database.Chirp, 0, len(m.chirps))
+	for _, chirp := range m.chirps {
+		chirps = append(chirps, chirp)
+	}
+	sortChirps(chirps)
+
+	return chirps, nil
+}
+
+func (m *Memory) DeleteChirp(ctx context.Context, arg database.DeleteChirpParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	chirp, ok := m.chirps[arg.ID]
+	if ok && chirp.UserID == arg.UserID {
+		delete(m.chirps, arg.ID)
+		delete(m.chirpEntities, arg.ID)
+		for id, notification := range m.notifications {
+			if notification.ChirpID.Valid && notification.ChirpID.UUID == arg.ID {
+				delete(m.notifications, id)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *Memory) CreateChirpEntity(ctx context.Context, arg database.CreateChirpEntityParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, chirpFound := m.chirps[arg.ChirpID]
+	_, userFound := m.users[arg.UserID.UUID]
+	if !chirpFound || (arg.UserID.Valid && !userFound) {
+		return mapError(errForeignKeyViolation, resourceChirp)
+	}
+
+	entities := m.chirpEntities[arg.ChirpID]
+	for _, entity := range entities {
+		if entity.StartOffset == arg.StartOffset {
+			return mapError(errUniqueViolation, resourceChirp)
+		}
+	}
+
+	entities = append(entities, database.ChirpEntity{
+		ChirpID:     arg.ChirpID,
+		Kind:        arg.Kind,
+		StartOffset: arg.StartOffset,
+		EndOffset:   arg.EndOffset,
+		Value:       arg.Value,
+		UserID:      arg.UserID,
+	})
+	sort.Slice(entities, func(i, j int) bool {
+		return entities[i].StartOffset < entities[j].StartOffset
+	})
+	m.chirpEntities[arg.ChirpID] = entities
+
+	return nil
+}
+
+func (m *Memory) GetChirpEntities(ctx context.Context, chirpIDs []uuid.UUID) ([]database.ChirpEntity, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ids := slices.Clone(chirpIDs)
+	slices.SortFunc(ids, func(a, b uuid.UUID) int {
+		return strings.Compare(a.String(), b.String())
+	})
+
+	entities := []database.ChirpEntity{}
+	for _, id := range slices.Compact(ids) {
+		entities = append(entities, m.chirpEntities[id]...)
+	}
+
+	return entities, nil
+}
+
+func (m *Memory) GetHashtagsCreatedBetween(ctx context.Context, arg database.GetHashtagsCreatedBetweenParams) ([]database.GetHashtagsCreatedBetweenRow, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	hashtags := []database.GetHashtagsCreatedBetweenRow{}
+	for id, entities := range m.chirpEntities {
+		createdAt := m.chirps[id].CreatedAt
+		if createdAt.Before(arg.Since) || !createdAt.Before(arg.Until) {
+			continue
+		}
+
+		for _, entity := range entities {
+			if entity.Kind == "hashtag" {
+				hashtags = append(hashtags, database.GetHashtagsCreatedBetweenRow{Value: entity.Value, CreatedAt: createdAt})
+			}
+		}
+	}
+
+	return hashtags, nil
+}
+
+func sortChirps(chirps []database.Chirp) {
+	sort.SliceStable(chirps, func(i, j int) bool {
+		return chirps[i].CreatedAt.Before(chirps[j].CreatedAt)
+	})
+}
+
+func (m *Memory) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[arg.UserID]; !ok {
+		return database.RefreshToken{}, mapError(errForeignKeyViolation, resourceRefreshToken)
+	}
+
+	if _, ok := m.refreshTokens[arg.Token]; ok {
+		return database.RefreshToken{}, mapError(errUniqueViolation, resourceRefreshToken)
+	}
+
+	createdAt := now()
+	refreshToken := database.RefreshToken{
+		Token:     arg.Token,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+		UserID:    arg.UserID,
+		ExpiresAt: arg.ExpiresAt,
+	}
+	m.refreshTokens[refreshToken.Token] = refreshToken
+
+	return refreshToken, nil
+}
+
+func (m *Memory) GetRefreshToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	refreshToken, ok := m.refreshTokens[token]
+	if !ok {
+		return database.RefreshToken{}, mapError(sql.ErrNoRows, resourceRefreshToken)
+	}
+
+	return refreshToken, nil
+}
+
+func (m *Memory) RevokeRefreshToken(ctx context.Context, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	refreshToken, ok := m.refreshTokens[token]
+	if !ok {
+		return nil
+	}
+
+	revokedAt := now()
+	refreshToken.RevokedAt = sql.NullTime{Time: revokedAt, Valid: true}
+	refreshToken.UpdatedAt = revokedAt
+	m.refreshTokens[token] = refreshToken
+
+	return nil
+}
+
+func (m *Memory) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return m.revokeRefreshTokens(func(refreshToken database.RefreshToken) bool {
+		return refreshToken.UserID == userID
+	}), nil
+}
+
+func (m *Memory) RevokeAllRefreshTokens(ctx context.Context) (int64, error) {
+	return m.revokeRefreshTokens(func(database.RefreshToken) bool { return true }), nil
+}
+
+// revokeRefreshTokens revokes the live tokens matching match and returns
+// how many there were.
+func (m *Memory) revokeRefreshTokens(match func(database.RefreshToken) bool) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	revokedAt := now()
+	var revoked int64
+	for token, refreshToken := range m.refreshTokens {
+		if refreshToken.RevokedAt.Valid || !match(refreshToken) {
+			continue
+		}
+
+		refreshToken.RevokedAt = sql.NullTime{Time: revokedAt, Valid: true}
+		refreshToken.UpdatedAt = revokedAt
+		m.refreshTokens[token] = refreshToken
+		revoked++
+	}
+
+	return revoked
+}
+
+func (m *Memory) CreateNotification(ctx context.Context, arg database.CreateNotificationParams) (database.Notification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, userFound := m.users[arg.UserID]
+	_, actorFound := m.users[arg.ActorID.UUID]
+	_, chirpFound := m.chirps[arg.ChirpID.UUID]
+	if !userFound || (arg.ActorID.Valid && !actorFound) || (arg.ChirpID.Valid && !chirpFound) {
+		return database.Notification{}, mapError(errForeignKeyViolation, resourceNotification)
+	}
+
+	notification := database.Notification{
+		ID:        uuid.New(),
+		CreatedAt: now(),
+		UserID:    arg.UserID,
+		Kind:      arg.Kind,
+		ActorID:   arg.ActorID,
+		ChirpID:   arg.ChirpID,
+	}
+	m.notifications[notification.ID] = notification
+
+	return notification, nil
+}
+
+func (m *Memory) ListNotifications(ctx context.Context, arg database.ListNotificationsParams) ([]database.Notification, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	notifications := []database.Notification{}
+	for _, notification := range m.notifications {
+		if notification.UserID == arg.UserID {
+			notifications = append(notifications, notification)
+		}
+	}
+	sort.SliceStable(notifications, func(i, j int) bool {
+		return notifications[i].CreatedAt.After(notifications[j].CreatedAt)
+	})
+
+	if len(notifications) > int(arg.Limit) {
+		notifications = notifications[:arg.Limit]
+	}
+
+	return notifications, nil
+}
+
+func (m *Memory) CountUnreadNotifications(ctx context.Context, userID uuid.UUID) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var count int64
+	for _, notification := range m.notifications {
+		if notification.UserID == userID && !notification.ReadAt.Valid {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (m *Memory) MarkNotificationRead(ctx context.Context, arg database.MarkNotificationReadParams) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	notification, ok := m.notifications[arg.ID]
+	if !ok || notification.UserID != arg.UserID {
+		return 0, nil
+	}
+
+	if !notification.ReadAt.Valid {
+		notification.ReadAt = sql.NullTime{Time: now(), Valid: true}
+		m.notifications[arg.ID] = notification
+	}
+
+	return 1, nil
+}
+
+func (m *Memory) MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	readAt := now()
+	var marked int64
+	for id, notification := range m.notifications {
+		if notification.UserID != userID || notification.ReadAt.Valid {
+			continue
+		}
+
+		notification.ReadAt = sql.NullTime{Time: readAt, Valid: true}
+		m.notifications[id] = notification
+		marked++
+	}
+
+	return marked, nil
+}
+
+func (m *Memory) CreateModerator(ctx context.Context, userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return mapError(errForeignKeyViolation, resourceModerator)
+	}
+
+	if _, ok := m.moderators[userID]; !ok {
+		m.moderators[userID] = database.Moderator{UserID: userID, CreatedAt: now()}
+	}
+
+	return nil
+}
+
+func (m *Memory) DeleteModerator(ctx context.Context, userID uuid.UUID) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.moderators[userID]; !ok {
+		return 0, nil
+	}
+
+	delete(m.moderators, userID)
+	return 1, nil
+}
+
+func (m *Memory) IsModerator(ctx context.Context, userID uuid.UUID) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.moderators[userID]
+	return ok, nil
+}
+
+func (m *Memory) ExcludeTrendingTag(ctx context.Context, arg database.ExcludeTrendingTagParams) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[arg.ExcludedBy.UUID]; arg.ExcludedBy.Valid && !ok {
+		return mapError(errForeignKeyViolation, resourceExcludedTag)
+	}
+
+	if _, ok := m.excludedTags[arg.Tag]; !ok {
+		m.excludedTags[arg.Tag] = database.TrendingExcludedTag{Tag: arg.Tag, CreatedAt: now(), ExcludedBy: arg.ExcludedBy}
+	}
+
+	return nil
+}
+
+func (m *Memory) IncludeTrendingTag(ctx context.Context, tag string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.excludedTags[tag]; !ok {
+		return 0, nil
+	}
+
+	delete(m.excludedTags, tag)
+	return 1, nil
+}
+
+func (m *Memory) GetExcludedTrendingTags(ctx context.Context) ([]database.TrendingExcludedTag, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tags := make([]database.TrendingExcludedTag, 0, len(m.excludedTags))
+	for _, tag := range m.excludedTags {
+		tags = append(tags, tag)
+	}
+	slices.SortFunc(tags, func(a, b database.TrendingExcludedTag) int {
+		return strings.Compare(a.Tag, b.Tag)
+	})
+
+	return tags, nil
+}
@@ -0,0 +1,65 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	BackendPostgres = "postgres"
+	BackendSQLite   = "sqlite"
+)
+
+// Backend returns which database dbURL points at. postgres:// and
+// postgresql:// URLs use Postgres, sqlite:// URLs use SQLite, e.g.
+// sqlite://chirpy.db or sqlite://:memory:.
+func Backend(dbURL string) (string, error) {
+	switch {
+	case strings.HasPrefix(dbURL, "postgres://"), strings.HasPrefix(dbURL, "postgresql://"):
+		return BackendPostgres, nil
+	case strings.HasPrefix(dbURL, "sqlite://"):
+		return BackendSQLite, nil
+	default:
+		return "", fmt.Errorf("unsupported database url scheme: %q", dbURL)
+	}
+}
+
+// Open connects to the database described by dbURL and returns the matching
+// Store, with every query traced, along with the underlying connection pool.
+func Open(dbURL string) (Store, *sql.DB, error) {
+	backend, err := Backend(dbURL)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch backend {
+	case BackendSQLite:
+		db, err := sql.Open("sqlite", sqliteDSN(strings.TrimPrefix(dbURL, "sqlite://")))
+		if err != nil {
+			return nil, nil, err
+		}
+		// SQLite allows a single writer, and every :memory: connection is a
+		// separate database, so keep the pool to one connection.
+		db.SetMaxOpenConns(1)
+		return WithTracing(NewSQLite(db), "sqlite"), db, nil
+	default:
+		db, err := sql.Open("postgres", dbURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		return WithTracing(NewPostgres(db), "postgresql"), db, nil
+	}
+}
+
+func sqliteDSN(path string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+
+	return "file:" + path + separator + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
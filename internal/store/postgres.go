@@ -0,0 +1,216 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/magicznykacpur/chirpy/internal/database"
+)
+
+const (
+	pqForeignKeyViolation = "23503"
+	pqUniqueViolation     = "23505"
+)
+
+// Postgres is the sqlc-backed Store.
+type Postgres struct {
+	q *database.Queries
+}
+
+func NewPostgres(db *sql.DB) *Postgres {
+	return &Postgres{q: database.New(db)}
+}
+
+func (p *Postgres) Createuser(ctx context.Context, arg database.CreateuserParams) (database.User, error) {
+	user, err := p.q.Createuser(ctx, arg)
+	return user, mapPostgresError(err, resourceUser)
+}
+
+func (p *Postgres) DeleteUsers(ctx context.Context) error {
+	return mapPostgresError(p.q.DeleteUsers(ctx), resourceUser)
+}
+
+func (p *Postgres) GetUsers(ctx context.Context) ([]database.User, error) {
+	users, err := p.q.GetUsers(ctx)
+	return users, mapPostgresError(err, resourceUser)
+}
+
+func (p *Postgres) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	user, err := p.q.GetUserByEmail(ctx, email)
+	return user, mapPostgresError(err, resourceUser)
+}
+
+func (p *Postgres) GetUserById(ctx context.Context, id uuid.UUID) (database.User, error) {
+	user, err := p.q.GetUserById(ctx, id)
+	return user, mapPostgresError(err, resourceUser)
+}
+
+func (p *Postgres) GetUserByUsername(ctx context.Context, username string) (database.User, error) {
+	user, err := p.q.GetUserByUsername(ctx, username)
+	return user, mapPostgresError(err, resourceUser)
+}
+
+func (p *Postgres) UpdateUserEmailAndPassword(ctx context.Context, arg database.UpdateUserEmailAndPasswordParams) error {
+	return mapPostgresError(p.q.UpdateUserEmailAndPassword(ctx, arg), resourceUser)
+}
+
+func (p *Postgres) UpdateUserProfile(ctx context.Context, arg database.UpdateUserProfileParams) error {
+	return mapPostgresError(p.q.UpdateUserProfile(ctx, arg), resourceUser)
+}
+
+func (p *Postgres) UpdateIsChirpyRed(ctx context.Context, id uuid.UUID) error {
+	return mapPostgresError(p.q.UpdateIsChirpyRed(ctx, id), resourceUser)
+}
+
+func (p *Postgres) UpdateUserPassword(ctx context.Context, arg database.UpdateUserPasswordParams) error {
+	return mapPostgresError(p.q.UpdateUserPassword(ctx, arg), resourceUser)
+}
+
+func (p *Postgres) DisableUser(ctx context.Context, id uuid.UUID) error {
+	return mapPostgresError(p.q.DisableUser(ctx, id), resourceUser)
+}
+
+func (p *Postgres) CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error) {
+	chirp, err := p.q.CreateChirp(ctx, arg)
+	return chirp, mapPostgresError(err, resourceChirp)
+}
+
+func (p *Postgres) GetChirp(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	chirp, err := p.q.GetChirp(ctx, id)
+	return chirp, mapPostgresError(err, resourceChirp)
+}
+
+func (p *Postgres) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	chirps, err := p.q.GetChirpsByUser(ctx, userID)
+	return chirps, mapPostgresError(err, resourceChirp)
+}
+
+func (p *Postgres) CountChirpsByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	count, err := p.q.CountChirpsByUser(ctx, userID)
+	return count, mapPostgresError(err, resourceChirp)
+}
+
+func (p *Postgres) GetChirpsByHashtag(ctx context.Context, tag string) ([]database.Chirp, error) {
+	chirps, err := p.q.GetChirpsByHashtag(ctx, tag)
+	return chirps, mapPostgresError(err, resourceChirp)
+}
+
+func (p *Postgres) GetAllChirps(ctx context.Context) ([]database.Chirp, error) {
+	chirps, err := p.q.GetAllChirps(ctx)
+	return chirps, mapPostgresError(err, resourceChirp)
+}
+
+func (p *Postgres) DeleteChirp(ctx context.Context, arg database.DeleteChirpParams) error {
+	return mapPostgresError(p.q.DeleteChirp(ctx, arg), resourceChirp)
+}
+
+func (p *Postgres) CreateChirpEntity(ctx context.Context, arg database.CreateChirpEntityParams) error {
+	return mapPostgresError(p.q.CreateChirpEntity(ctx, arg), resourceChirp)
+}
+
+func (p *Postgres) GetChirpEntities(ctx context.Context, chirpIDs []uuid.UUID) ([]database.ChirpEntity, error) {
+	entities, err := p.q.GetChirpEntities(ctx, chirpIDs)
+	return entities, mapPostgresError(err, resourceChirp)
+}
+
+func (p *Postgres) GetHashtagsCreatedBetween(ctx context.Context, arg database.GetHashtagsCreatedBetweenParams) ([]database.GetHashtagsCreatedBetweenRow, error) {
+	hashtags, err := p.q.GetHashtagsCreatedBetween(ctx, arg)
+	return hashtags, mapPostgresError(err, resourceChirp)
+}
+
+func (p *Postgres) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	refreshToken, err := p.q.CreateRefreshToken(ctx, arg)
+	return refreshToken, mapPostgresError(err, resourceRefreshToken)
+}
+
+func (p *Postgres) GetRefreshToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	refreshToken, err := p.q.GetRefreshToken(ctx, token)
+	return refreshToken, mapPostgresError(err, resourceRefreshToken)
+}
+
+func (p *Postgres) RevokeRefreshToken(ctx context.Context, token string) error {
+	return mapPostgresError(p.q.RevokeRefreshToken(ctx, token), resourceRefreshToken)
+}
+
+func (p *Postgres) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	revoked, err := p.q.RevokeUserRefreshTokens(ctx, userID)
+	return revoked, mapPostgresError(err, resourceRefreshToken)
+}
+
+func (p *Postgres) RevokeAllRefreshTokens(ctx context.Context) (int64, error) {
+	revoked, err := p.q.RevokeAllRefreshTokens(ctx)
+	return revoked, mapPostgresError(err, resourceRefreshToken)
+}
+
+func (p *Postgres) CreateNotification(ctx context.Context, arg database.CreateNotificationParams) (database.Notification, error) {
+	notification, err := p.q.CreateNotification(ctx, arg)
+	return notification, mapPostgresError(err, resourceNotification)
+}
+
+func (p *Postgres) ListNotifications(ctx context.Context, arg database.ListNotificationsParams) ([]database.Notification, error) {
+	notifications, err := p.q.ListNotifications(ctx, arg)
+	return notifications, mapPostgresError(err, resourceNotification)
+}
+
+func (p *Postgres) CountUnreadNotifications(ctx context.Context, userID uuid.UUID) (int64, error) {
+	count, err := p.q.CountUnreadNotifications(ctx, userID)
+	return count, mapPostgresError(err, resourceNotification)
+}
+
+func (p *Postgres) MarkNotificationRead(ctx context.Context, arg database.MarkNotificationReadParams) (int64, error) {
+	marked, err := p.q.MarkNotificationRead(ctx, arg)
+	return marked, mapPostgresError(err, resourceNotification)
+}
+
+func (p *Postgres) MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	marked, err := p.q.MarkAllNotificationsRead(ctx, userID)
+	return marked, mapPostgresError(err, resourceNotification)
+}
+
+func (p *Postgres) CreateModerator(ctx context.Context, userID uuid.UUID) error {
+	return mapPostgresError(p.q.CreateModerator(ctx, userID), resourceModerator)
+}
+
+func (p *Postgres) DeleteModerator(ctx context.Context, userID uuid.UUID) (int64, error) {
+	deleted, err := p.q.DeleteModerator(ctx, userID)
+	return deleted, mapPostgresError(err, resourceModerator)
+}
+
+func (p *Postgres) IsModerator(ctx context.Context, userID uuid.UUID) (bool, error) {
+	moderator, err := p.q.IsModerator(ctx, userID)
+	return moderator, mapPostgresError(err, resourceModerator)
+}
+
+func (p *Postgres) ExcludeTrendingTag(ctx context.Context, arg database.ExcludeTrendingTagParams) error {
+	return mapPostgresError(p.q.ExcludeTrendingTag(ctx, arg), resourceExcludedTag)
+}
+
+func (p *Postgres) IncludeTrendingTag(ctx context.Context, tag string) (int64, error) {
+	included, err := p.q.IncludeTrendingTag(ctx, tag)
+	return included, mapPostgresError(err, resourceExcludedTag)
+}
+
+func (p *Postgres) GetExcludedTrendingTags(ctx context.Context) ([]database.TrendingExcludedTag, error) {
+	tags, err := p.q.GetExcludedTrendingTags(ctx)
+	return tags, mapPostgresError(err, resourceExcludedTag)
+}
+
+func mapPostgresError(err error, resource string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case pqUniqueViolation:
+			err = errors.Join(errUniqueViolation, err)
+			if pqErr.Constraint == usernameIndex {
+				err = errors.Join(errUsernameTaken, err)
+			}
+		case pqForeignKeyViolation:
+			err = errors.Join(errForeignKeyViolation, err)
+		}
+	}
+
+	return mapError(err, resource)
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestPostgres runs against the database in TEST_DB_URL, which must already be
+// migrated. Every table but goose's version table is emptied before each case.
+func TestPostgres(t *testing.T) {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set")
+	}
+
+	runContractTests(t, func(t *testing.T) Store {
+		s, db, err := Open(dbURL)
+		if err != nil {
+			t.Fatalf("couldn't open postgres: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		if err := truncateTables(context.Background(), db); err != nil {
+			t.Fatalf("couldn't reset postgres: %v", err)
+		}
+
+		return s
+	})
+}
+
+// truncateTables empties every table of the current schema, so tables that
+// don't cascade from users start empty too.
+func truncateTables(ctx context.Context, db *sql.DB) error {
+	rows, err := db.QueryContext(ctx, `
+		SELECT quote_ident(table_name) FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' AND table_name <> 'goose_db_version'`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return err
+		}
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	_, err = db.ExecContext(ctx, "TRUNCATE "+strings.Join(tables, ", ")+" CASCADE")
+	return err
+}
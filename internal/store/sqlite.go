@@ -0,0 +1,541 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/internal/database"
+	sqlitedb "github.com/magicznykacpur/chirpy/internal/database/sqlite"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLite is the Store backed by the sqlc queries in sql/sqlite. It converts
+// the TEXT ids SQLite stores into the uuid based models the handlers use.
+type SQLite struct {
+	q *sqlitedb.Queries
+}
+
+func NewSQLite(db *sql.DB) *SQLite {
+	return &SQLite{q: sqlitedb.New(db)}
+}
+
+func (s *SQLite) Createuser(ctx context.Context, arg database.CreateuserParams) (database.User, error) {
+	user, err := s.q.Createuser(ctx, sqlitedb.CreateuserParams{
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+		Username:       arg.Username,
+	})
+	if err != nil {
+		return database.User{}, mapSQLiteError(err, resourceUser)
+	}
+
+	return userFromSQLite(user)
+}
+
+func (s *SQLite) DeleteUsers(ctx context.Context) error {
+	return mapSQLiteError(s.q.DeleteUsers(ctx), resourceUser)
+}
+
+func (s *SQLite) GetUsers(ctx context.Context) ([]database.User, error) {
+	users, err := s.q.GetUsers(ctx)
+	if err != nil {
+		return nil, mapSQLiteError(err, resourceUser)
+	}
+
+	result := make([]database.User, 0, len(users))
+	for _, user := range users {
+		converted, err := userFromSQLite(user)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, converted)
+	}
+
+	return result, nil
+}
+
+func (s *SQLite) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	user, err := s.q.GetUserByEmail(ctx, email)
+	if err != nil {
+		return database.User{}, mapSQLiteError(err, resourceUser)
+	}
+
+	return userFromSQLite(user)
+}
+
+func (s *SQLite) GetUserById(ctx context.Context, id uuid.UUID) (database.User, error) {
+	user, err := s.q.GetUserById(ctx, id.String())
+	if err != nil {
+		return database.User{}, mapSQLiteError(err, resourceUser)
+	}
+
+	return userFromSQLite(user)
+}
+
+func (s *SQLite) GetUserByUsername(ctx context.Context, username string) (database.User, error) {
+	user, err := s.q.GetUserByUsername(ctx, username)
+	if err != nil {
+		return database.User{}, mapSQLiteError(err, resourceUser)
+	}
+
+	return userFromSQLite(user)
+}
+
+func (s *SQLite) UpdateUserEmailAndPassword(ctx context.Context, arg database.UpdateUserEmailAndPasswordParams) error {
+	err := s.q.UpdateUserEmailAndPassword(ctx, sqlitedb.UpdateUserEmailAndPasswordParams{
+		Email:          arg.Email,
+		HashedPassword: arg.HashedPassword,
+		Username:       arg.Username,
+		ID:             arg.ID.String(),
+	})
+	return mapSQLiteError(err, resourceUser)
+}
+
+func (s *SQLite) UpdateUserProfile(ctx context.Context, arg database.UpdateUserProfileParams) error {
+	err := s.q.UpdateUserProfile(ctx, sqlitedb.UpdateUserProfileParams{
+		Username:    arg.Username,
+		DisplayName: arg.DisplayName,
+		Bio:         arg.Bio,
+		AvatarUrl:   arg.AvatarUrl,
+		ID:          arg.ID.String(),
+	})
+	return mapSQLiteError(err, resourceUser)
+}
+
+func (s *SQLite) UpdateIsChirpyRed(ctx context.Context, id uuid.UUID) error {
+	return mapSQLiteError(s.q.UpdateIsChirpyRed(ctx, id.String()), resourceUser)
+}
+
+func (s *SQLite) UpdateUserPassword(ctx context.Context, arg database.UpdateUserPasswordParams) error {
+	err := s.q.UpdateUserPassword(ctx, sqlitedb.UpdateUserPasswordParams{
+		HashedPassword: arg.HashedPassword,
+		ID:             arg.ID.String(),
+	})
+	return mapSQLiteError(err, resourceUser)
+}
+
+func (s *SQLite) DisableUser(ctx context.Context, id uuid.UUID) error {
+	return mapSQLiteError(s.q.DisableUser(ctx, id.String()), resourceUser)
+}
+
+func (s *SQLite) CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error) {
+	chirp, err := s.q.CreateChirp(ctx, sqlitedb.CreateChirpParams{
+		Body:   arg.Body,
+		UserID: arg.UserID.String(),
+	})
+	if err != nil {
+		return database.Chirp{}, mapSQLiteError(err, resourceChirp)
+	}
+
+	return chirpFromSQLite(chirp)
+}
+
+func (s *SQLite) GetChirp(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	chirp, err := s.q.GetChirp(ctx, id.String())
+	if err != nil {
+		return database.Chirp{}, mapSQLiteError(err, resourceChirp)
+	}
+
+	return chirpFromSQLite(chirp)
+}
+
+func (s *SQLite) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	chirps, err := s.q.GetChirpsByUser(ctx, userID.String())
+	if err != nil {
+		return nil, mapSQLiteError(err, resourceChirp)
+	}
+
+	return chirpsFromSQLite(chirps)
+}
+
+func (s *SQLite) CountChirpsByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	count, err := s.q.CountChirpsByUser(ctx, userID.String())
+	return count, mapSQLiteError(err, resourceChirp)
+}
+
+func (s *SQLite) GetChirpsByHashtag(ctx context.Context, tag string) ([]database.Chirp, error) {
+	chirps, err := s.q.GetChirpsByHashtag(ctx, tag)
+	if err != nil {
+		return nil, mapSQLiteError(err, resourceChirp)
+	}
+
+	return chirpsFromSQLite(chirps)
+}
+
+func (s *SQLite) GetAllChirps(ctx context.Context) ([]database.Chirp, error) {
+	chirps, err := s.q.GetAllChirps(ctx)
+	if err != nil {
+		return nil, mapSQLiteError(err, resourceChirp)
+	}
+
+	return chirpsFromSQLite(chirps)
+}
+
+func (s *SQLite) DeleteChirp(ctx context.Context, arg database.DeleteChirpParams) error {
+	err := s.q.DeleteChirp(ctx, sqlitedb.DeleteChirpParams{
+		ID:     arg.ID.String(),
+		UserID: arg.UserID.String(),
+	})
+	return mapSQLiteError(err, resourceChirp)
+}
+
+func (s *SQLite) CreateChirpEntity(ctx context.Context, arg database.CreateChirpEntityParams) error {
+	err := s.q.CreateChirpEntity(ctx, sqlitedb.CreateChirpEntityParams{
+		ChirpID:     arg.ChirpID.String(),
+		Kind:        arg.Kind,
+		StartOffset: int64(arg.StartOffset),
+		EndOffset:   int64(arg.EndOffset),
+		Value:       arg.Value,
+		UserID:      nullUUIDToSQLite(arg.UserID),
+	})
+	return mapSQLiteError(err, resourceChirp)
+}
+
+// chirpEntitiesBatch keeps GetChirpEntities below SQLite's limit on bound
+// parameters, listing every chirp can ask for any number of them.
+const chirpEntitiesBatch = 500
+
+func (s *SQLite) GetChirpEntities(ctx context.Context, chirpIDs []uuid.UUID) ([]database.ChirpEntity, error) {
+	result := []database.ChirpEntity{}
+	for batch := range slices.Chunk(chirpIDs, chirpEntitiesBatch) {
+		ids := make([]string, 0, len(batch))
+		for _, id := range batch {
+			ids = append(ids, id.String())
+		}
+
+		entities, err := s.q.GetChirpEntities(ctx, ids)
+		if err != nil {
+			return nil, mapSQLiteError(err, resourceChirp)
+		}
+
+		for _, entity := range entities {
+			converted, err := chirpEntityFromSQLite(entity)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, converted)
+		}
+	}
+
+	return result, nil
+}
+
+// sqliteTimeFormat is how strftime('%Y-%m-%d %H:%M:%f', 'now') stores
+// created_at, the driver would bind a time.Time as its String() instead.
+const sqliteTimeFormat = "2006-01-02 15:04:05.000"
+
+func (s *SQLite) GetHashtagsCreatedBetween(ctx context.Context, arg database.GetHashtagsCreatedBetweenParams) ([]database.GetHashtagsCreatedBetweenRow, error) {
+	hashtags, err := s.q.GetHashtagsCreatedBetween(ctx, sqlitedb.GetHashtagsCreatedBetweenParams{
+		Since: arg.Since.UTC().Format(sqliteTimeFormat),
+		Until: arg.Until.UTC().Format(sqliteTimeFormat),
+	})
+	if err != nil {
+		return nil, mapSQLiteError(err, resourceChirp)
+	}
+
+	result := make([]database.GetHashtagsCreatedBetweenRow, 0, len(hashtags))
+	for _, hashtag := range hashtags {
+		result = append(result, database.GetHashtagsCreatedBetweenRow{Value: hashtag.Value, CreatedAt: hashtag.CreatedAt})
+	}
+
+	return result, nil
+}
+
+func (s *SQLite) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	refreshToken, err := s.q.CreateRefreshToken(ctx, sqlitedb.CreateRefreshTokenParams{
+		Token:     arg.Token,
+		UserID:    arg.UserID.String(),
+		ExpiresAt: arg.ExpiresAt.UTC(),
+	})
+	if err != nil {
+		return database.RefreshToken{}, mapSQLiteError(err, resourceRefreshToken)
+	}
+
+	return refreshTokenFromSQLite(refreshToken)
+}
+
+func (s *SQLite) GetRefreshToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	refreshToken, err := s.q.GetRefreshToken(ctx, token)
+	if err != nil {
+		return database.RefreshToken{}, mapSQLiteError(err, resourceRefreshToken)
+	}
+
+	return refreshTokenFromSQLite(refreshToken)
+}
+
+func (s *SQLite) RevokeRefreshToken(ctx context.Context, token string) error {
+	return mapSQLiteError(s.q.RevokeRefreshToken(ctx, token), resourceRefreshToken)
+}
+
+func (s *SQLite) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	revoked, err := s.q.RevokeUserRefreshTokens(ctx, userID.String())
+	return revoked, mapSQLiteError(err, resourceRefreshToken)
+}
+
+func (s *SQLite) RevokeAllRefreshTokens(ctx context.Context) (int64, error) {
+	revoked, err := s.q.RevokeAllRefreshTokens(ctx)
+	return revoked, mapSQLiteError(err, resourceRefreshToken)
+}
+
+func (s *SQLite) CreateNotification(ctx context.Context, arg database.CreateNotificationParams) (database.Notification, error) {
+	notification, err := s.q.CreateNotification(ctx, sqlitedb.CreateNotificationParams{
+		UserID:  arg.UserID.String(),
+		Kind:    arg.Kind,
+		ActorID: nullUUIDToSQLite(arg.ActorID),
+		ChirpID: nullUUIDToSQLite(arg.ChirpID),
+	})
+	if err != nil {
+		return database.Notification{}, mapSQLiteError(err, resourceNotification)
+	}
+
+	return notificationFromSQLite(notification)
+}
+
+func (s *SQLite) ListNotifications(ctx context.Context, arg database.ListNotificationsParams) ([]database.Notification, error) {
+	notifications, err := s.q.ListNotifications(ctx, sqlitedb.ListNotificationsParams{
+		UserID: arg.UserID.String(),
+		Limit:  int64(arg.Limit),
+	})
+	if err != nil {
+		return nil, mapSQLiteError(err, resourceNotification)
+	}
+
+	result := make([]database.Notification, 0, len(notifications))
+	for _, notification := range notifications {
+		converted, err := notificationFromSQLite(notification)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, converted)
+	}
+
+	return result, nil
+}
+
+func (s *SQLite) CountUnreadNotifications(ctx context.Context, userID uuid.UUID) (int64, error) {
+	count, err := s.q.CountUnreadNotifications(ctx, userID.String())
+	return count, mapSQLiteError(err, resourceNotification)
+}
+
+func (s *SQLite) MarkNotificationRead(ctx context.Context, arg database.MarkNotificationReadParams) (int64, error) {
+	marked, err := s.q.MarkNotificationRead(ctx, sqlitedb.MarkNotificationReadParams{
+		ID:     arg.ID.String(),
+		UserID: arg.UserID.String(),
+	})
+	return marked, mapSQLiteError(err, resourceNotification)
+}
+
+func (s *SQLite) MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	marked, err := s.q.MarkAllNotificationsRead(ctx, userID.String())
+	return marked, mapSQLiteError(err, resourceNotification)
+}
+
+func (s *SQLite) CreateModerator(ctx context.Context, userID uuid.UUID) error {
+	return mapSQLiteError(s.q.CreateModerator(ctx, userID.String()), resourceModerator)
+}
+
+func (s *SQLite) DeleteModerator(ctx context.Context, userID uuid.UUID) (int64, error) {
+	deleted, err := s.q.DeleteModerator(ctx, userID.String())
+	return deleted, mapSQLiteError(err, resourceModerator)
+}
+
+func (s *SQLite) IsModerator(ctx context.Context, userID uuid.UUID) (bool, error) {
+	moderator, err := s.q.IsModerator(ctx, userID.String())
+	return moderator == 1, mapSQLiteError(err, resourceModerator)
+}
+
+func (s *SQLite) ExcludeTrendingTag(ctx context.Context, arg database.ExcludeTrendingTagParams) error {
+	err := s.q.ExcludeTrendingTag(ctx, sqlitedb.ExcludeTrendingTagParams{
+		Tag:        arg.Tag,
+		ExcludedBy: nullUUIDToSQLite(arg.ExcludedBy),
+	})
+	return mapSQLiteError(err, resourceExcludedTag)
+}
+
+func (s *SQLite) IncludeTrendingTag(ctx context.Context, tag string) (int64, error) {
+	included, err := s.q.IncludeTrendingTag(ctx, tag)
+	return included, mapSQLiteError(err, resourceExcludedTag)
+}
+
+func (s *SQLite) GetExcludedTrendingTags(ctx context.Context) ([]database.TrendingExcludedTag, error) {
+	tags, err := s.q.GetExcludedTrendingTags(ctx)
+	if err != nil {
+		return nil, mapSQLiteError(err, resourceExcludedTag)
+	}
+
+	result := make([]database.TrendingExcludedTag, 0, len(tags))
+	for _, tag := range tags {
+		excludedBy, err := nullUUIDFromSQLite(tag.ExcludedBy)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, database.TrendingExcludedTag{Tag: tag.Tag, CreatedAt: tag.CreatedAt, ExcludedBy: excludedBy})
+	}
+
+	return result, nil
+}
+
+func userFromSQLite(user sqlitedb.User) (database.User, error) {
+	id, err := uuid.Parse(user.ID)
+	if err != nil {
+		return database.User{}, err
+	}
+
+	return database.User{
+		ID:             id,
+		CreatedAt:      user.CreatedAt,
+		UpdatedAt:      user.UpdatedAt,
+		Email:          user.Email,
+		HashedPassword: user.HashedPassword,
+		IsChirpyRed:    user.IsChirpyRed,
+		DisabledAt:     user.DisabledAt,
+		Username:       user.Username,
+		DisplayName:    user.DisplayName,
+		Bio:            user.Bio,
+		AvatarUrl:      user.AvatarUrl,
+	}, nil
+}
+
+func chirpFromSQLite(chirp sqlitedb.Chirp) (database.Chirp, error) {
+	id, err := uuid.Parse(chirp.ID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	userId, err := uuid.Parse(chirp.UserID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	return database.Chirp{
+		ID:        id,
+		CreatedAt: chirp.CreatedAt,
+		UpdatedAt: chirp.UpdatedAt,
+		Body:      chirp.Body,
+		UserID:    userId,
+	}, nil
+}
+
+func chirpsFromSQLite(chirps []sqlitedb.Chirp) ([]database.Chirp, error) {
+	result := make([]database.Chirp, 0, len(chirps))
+	for _, chirp := range chirps {
+		converted, err := chirpFromSQLite(chirp)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, converted)
+	}
+
+	return result, nil
+}
+
+func chirpEntityFromSQLite(entity sqlitedb.ChirpEntity) (database.ChirpEntity, error) {
+	chirpId, err := uuid.Parse(entity.ChirpID)
+	if err != nil {
+		return database.ChirpEntity{}, err
+	}
+
+	userId, err := nullUUIDFromSQLite(entity.UserID)
+	if err != nil {
+		return database.ChirpEntity{}, err
+	}
+
+	return database.ChirpEntity{
+		ChirpID:     chirpId,
+		Kind:        entity.Kind,
+		StartOffset: int32(entity.StartOffset),
+		EndOffset:   int32(entity.EndOffset),
+		Value:       entity.Value,
+		UserID:      userId,
+	}, nil
+}
+
+func refreshTokenFromSQLite(refreshToken sqlitedb.RefreshToken) (database.RefreshToken, error) {
+	userId, err := uuid.Parse(refreshToken.UserID)
+	if err != nil {
+		return database.RefreshToken{}, err
+	}
+
+	return database.RefreshToken{
+		Token:     refreshToken.Token,
+		CreatedAt: refreshToken.CreatedAt,
+		UpdatedAt: refreshToken.UpdatedAt,
+		UserID:    userId,
+		ExpiresAt: refreshToken.ExpiresAt,
+		RevokedAt: refreshToken.RevokedAt,
+	}, nil
+}
+
+func notificationFromSQLite(notification sqlitedb.Notification) (database.Notification, error) {
+	id, err := uuid.Parse(notification.ID)
+	if err != nil {
+		return database.Notification{}, err
+	}
+
+	userId, err := uuid.Parse(notification.UserID)
+	if err != nil {
+		return database.Notification{}, err
+	}
+
+	actorId, err := nullUUIDFromSQLite(notification.ActorID)
+	if err != nil {
+		return database.Notification{}, err
+	}
+
+	chirpId, err := nullUUIDFromSQLite(notification.ChirpID)
+	if err != nil {
+		return database.Notification{}, err
+	}
+
+	return database.Notification{
+		ID:        id,
+		CreatedAt: notification.CreatedAt,
+		UserID:    userId,
+		Kind:      notification.Kind,
+		ActorID:   actorId,
+		ChirpID:   chirpId,
+		ReadAt:    notification.ReadAt,
+	}, nil
+}
+
+func nullUUIDToSQLite(id uuid.NullUUID) sql.NullString {
+	if !id.Valid {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: id.UUID.String(), Valid: true}
+}
+
+func nullUUIDFromSQLite(id sql.NullString) (uuid.NullUUID, error) {
+	if !id.Valid {
+		return uuid.NullUUID{}, nil
+	}
+
+	parsed, err := uuid.Parse(id.String)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: parsed, Valid: true}, nil
+}
+
+func mapSQLiteError(err error, resource string) error {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.Code() {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+			err = errors.Join(errUniqueViolation, err)
+			// the username index is on an expression, so SQLite names the index
+			if strings.Contains(sqliteErr.Error(), "index '"+usernameIndex+"'") {
+				err = errors.Join(errUsernameTaken, err)
+			}
+		case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+			err = errors.Join(errForeignKeyViolation, err)
+		}
+	}
+
+	return mapError(err, resource)
+}
@@ -0,0 +1,30 @@
+package store
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/magicznykacpur/chirpy/internal/migrate"
+)
+
+func TestSQLite(t *testing.T) {
+	runContractTests(t, func(t *testing.T) Store {
+		s, db, err := Open("sqlite://" + filepath.Join(t.TempDir(), "chirpy.db"))
+		if err != nil {
+			t.Fatalf("couldn't open sqlite: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		migrator, err := migrate.New(db, BackendSQLite)
+		if err != nil {
+			t.Fatalf("couldn't create migrator: %v", err)
+		}
+
+		if _, err := migrator.Up(context.Background()); err != nil {
+			t.Fatalf("couldn't migrate sqlite: %v", err)
+		}
+
+		return s
+	})
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/internal/apperr"
+	"github.com/magicznykacpur/chirpy/internal/database"
+)
+
+// Every Store returns apperr errors: missing rows match apperr.ErrNotFound,
+// duplicate emails and usernames match apperr.ErrConflict and rows
+// referencing a missing user match apperr.ErrNotFound.
+
+type UserRepository interface {
+	Createuser(ctx context.Context, arg database.CreateuserParams) (database.User, error)
+	DeleteUsers(ctx context.Context) error
+	GetUsers(ctx context.Context) ([]database.User, error)
+	GetUserByEmail(ctx context.Context, email string) (database.User, error)
+	GetUserById(ctx context.Context, id uuid.UUID) (database.User, error)
+	// GetUserByUsername ignores case, usernames are unique regardless of it
+	GetUserByUsername(ctx context.Context, username string) (database.User, error)
+	UpdateUserEmailAndPassword(ctx context.Context, arg database.UpdateUserEmailAndPasswordParams) error
+	// UpdateUserProfile keeps the fields left null
+	UpdateUserProfile(ctx context.Context, arg database.UpdateUserProfileParams) error
+	UpdateIsChirpyRed(ctx context.Context, id uuid.UUID) error
+	UpdateUserPassword(ctx context.Context, arg database.UpdateUserPasswordParams) error
+	DisableUser(ctx context.Context, id uuid.UUID) error
+}
+
+type ChirpRepository interface {
+	CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error)
+	GetChirp(ctx context.Context, id uuid.UUID) (database.Chirp, error)
+	GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error)
+	CountChirpsByUser(ctx context.Context, userID uuid.UUID) (int64, error)
+	// GetChirpsByHashtag takes the tag lowercased and without the #
+	GetChirpsByHashtag(ctx context.Context, tag string) ([]database.Chirp, error)
+	GetAllChirps(ctx context.Context) ([]database.Chirp, error)
+	DeleteChirp(ctx context.Context, arg database.DeleteChirpParams) error
+	CreateChirpEntity(ctx context.Context, arg database.CreateChirpEntityParams) error
+	// GetChirpEntities returns the entities of every chirp in chirpIDs,
+	// ordered by chirp and start offset
+	GetChirpEntities(ctx context.Context, chirpIDs []uuid.UUID) ([]database.ChirpEntity, error)
+	// GetHashtagsCreatedBetween returns the hashtags of the chirps created
+	// from Since up to but not including Until, in no particular order
+	GetHashtagsCreatedBetween(ctx context.Context, arg database.GetHashtagsCreatedBetweenParams) ([]database.GetHashtagsCreatedBetweenRow, error)
+}
+
+type RefreshTokenRepository interface {
+	CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error)
+	GetRefreshToken(ctx context.Context, token string) (database.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, token string) error
+	// RevokeUserRefreshTokens and RevokeAllRefreshTokens return how many
+	// live tokens they revoked
+	RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) (int64, error)
+	RevokeAllRefreshTokens(ctx context.Context) (int64, error)
+}
+
+type NotificationRepository interface {
+	CreateNotification(ctx context.Context, arg database.CreateNotificationParams) (database.Notification, error)
+	// ListNotifications returns the newest notifications first
+	ListNotifications(ctx context.Context, arg database.ListNotificationsParams) ([]database.Notification, error)
+	CountUnreadNotifications(ctx context.Context, userID uuid.UUID) (int64, error)
+	// MarkNotificationRead returns how many of the user's notifications
+	// matched, marking a read notification again keeps its read_at
+	MarkNotificationRead(ctx context.Context, arg database.MarkNotificationReadParams) (int64, error)
+	// MarkAllNotificationsRead returns how many unread notifications it
+	// marked
+	MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+type ModerationRepository interface {
+	// CreateModerator does nothing when the user already is one
+	CreateModerator(ctx context.Context, userID uuid.UUID) error
+	// DeleteModerator returns how many moderators it removed
+	DeleteModerator(ctx context.Context, userID uuid.UUID) (int64, error)
+	IsModerator(ctx context.Context, userID uuid.UUID) (bool, error)
+	// ExcludeTrendingTag keeps the first exclusion of a tag
+	ExcludeTrendingTag(ctx context.Context, arg database.ExcludeTrendingTagParams) error
+	// IncludeTrendingTag returns how many exclusions it removed
+	IncludeTrendingTag(ctx context.Context, tag string) (int64, error)
+	// GetExcludedTrendingTags returns the excluded tags in alphabetical
+	// order
+	GetExcludedTrendingTags(ctx context.Context) ([]database.TrendingExcludedTag, error)
+}
+
+// Store is everything the handlers need from persistence.
+type Store interface {
+	UserRepository
+	ChirpRepository
+	RefreshTokenRepository
+	NotificationRepository
+	ModerationRepository
+}
+
+const (
+	resourceUser         = "user"
+	resourceChirp        = "chirp"
+	resourceRefreshToken = "refresh token"
+	resourceNotification = "notification"
+	resourceModerator    = "moderator"
+	resourceExcludedTag  = "excluded tag"
+)
+
+// Backends join these to driver errors so mapError can classify them without
+// knowing which driver produced them.
+var (
+	errUniqueViolation     = errors.New("unique constraint violated")
+	errForeignKeyViolation = errors.New("foreign key constraint violated")
+	// errUsernameTaken is joined next to errUniqueViolation when the
+	// violated constraint is usernameIndex
+	errUsernameTaken = errors.New("username taken")
+)
+
+// usernameIndex is the unique index on lower(users.username).
+const usernameIndex = "users_username"
+
+// mapError translates a database error into an apperr error. resource names
+// the kind of row that was read or written.
+func mapError(err error, resource string) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return apperr.NotFound(resource, err)
+	case errors.Is(err, errUsernameTaken):
+		return apperr.Conflict("username already taken", err)
+	case errors.Is(err, errUniqueViolation) && resource == resourceUser:
+		return apperr.Conflict("email already taken", err)
+	case errors.Is(err, errUniqueViolation):
+		return apperr.Conflict(resource+" already exists", err)
+	case errors.Is(err, errForeignKeyViolation):
+		return apperr.NotFound(resourceUser, err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,759 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/internal/apperr"
+	"github.com/magicznykacpur/chirpy/internal/database"
+)
+
+// runContractTests exercises the behaviour every Store implementation must
+// share. newStore has to return an empty store.
+func runContractTests(t *testing.T, newStore func(t *testing.T) Store) {
+	cases := []struct {
+		name string
+		test func(t *testing.T, s Store)
+	}{
+		{name: "users", test: testUsers},
+		{name: "duplicate email", test: testDuplicateEmail},
+		{name: "usernames", test: testUsernames},
+		{name: "profiles", test: testProfiles},
+		{name: "missing rows", test: testMissingRows},
+		{name: "chirps", test: testChirps},
+		{name: "chirp without user", test: testChirpWithoutUser},
+		{name: "chirp entities", test: testChirpEntities},
+		{name: "refresh tokens", test: testRefreshTokens},
+		{name: "admin user updates", test: testAdminUserUpdates},
+		{name: "revoke refresh tokens in bulk", test: testRevokeRefreshTokensInBulk},
+		{name: "notifications", test: testNotifications},
+		{name: "moderation", test: testModeration},
+		{name: "delete users cascades", test: testDeleteUsersCascades},
+		{name: "concurrent writes", test: testConcurrentWrites},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.test(t, newStore(t))
+		})
+	}
+}
+
+func TestMemory(t *testing.T) {
+	runContractTests(t, func(t *testing.T) Store {
+		return NewMemory()
+	})
+}
+
+func mustCreateUser(t *testing.T, s Store, email string) database.User {
+	t.Helper()
+
+	user, err := s.Createuser(context.Background(), database.CreateuserParams{Email: email, HashedPassword: "hash"})
+	if err != nil {
+		t.Fatalf("couldn't create user: %v", err)
+	}
+
+	return user
+}
+
+func mustCreateChirp(t *testing.T, s Store, userId uuid.UUID, body string) database.Chirp {
+	t.Helper()
+
+	chirp, err := s.CreateChirp(context.Background(), database.CreateChirpParams{Body: body, UserID: userId})
+	if err != nil {
+		t.Fatalf("couldn't create chirp: %v", err)
+	}
+
+	// created_at is the only ordering key, keep consecutive chirps apart
+	time.Sleep(2 * time.Millisecond)
+
+	return chirp
+}
+
+func testUsers(t *testing.T, s Store) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "walt@example.com")
+
+	if user.ID == uuid.Nil || user.CreatedAt.IsZero() || user.IsChirpyRed.Bool {
+		t.Errorf("created user malformed --> %+v <--", user)
+	}
+
+	byEmail, err := s.GetUserByEmail(ctx, "walt@example.com")
+	if err != nil || byEmail.ID != user.ID {
+		t.Errorf("get user by email mismatch --> %v %v <--", byEmail.ID, err)
+	}
+
+	err = s.UpdateUserEmailAndPassword(ctx, database.UpdateUserEmailAndPasswordParams{
+		Email:          "heisenberg@example.com",
+		HashedPassword: "newHash",
+		ID:             user.ID,
+	})
+	if err != nil {
+		t.Fatalf("couldn't update user: %v", err)
+	}
+
+	err = s.UpdateIsChirpyRed(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("couldn't upgrade user: %v", err)
+	}
+
+	byId, err := s.GetUserById(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("couldn't get user by id: %v", err)
+	}
+
+	if byId.Email != "heisenberg@example.com" || byId.HashedPassword != "newHash" || !byId.IsChirpyRed.Bool {
+		t.Errorf("updated user mismatch --> %+v <--", byId)
+	}
+
+	mustCreateUser(t, s, "jesse@example.com")
+	users, err := s.GetUsers(ctx)
+	if err != nil || len(users) != 2 {
+		t.Errorf("get users mismatch --> %d %v <--", len(users), err)
+	}
+}
+
+func testDuplicateEmail(t *testing.T, s Store) {
+	ctx := context.Background()
+	mustCreateUser(t, s, "walt@example.com")
+	jesse := mustCreateUser(t, s, "jesse@example.com")
+
+	_, err := s.Createuser(ctx, database.CreateuserParams{Email: "walt@example.com", HashedPassword: "hash"})
+	if !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("duplicate create should be a conflict, got: %v", err)
+	}
+
+	err = s.UpdateUserEmailAndPassword(ctx, database.UpdateUserEmailAndPasswordParams{
+		Email:          "walt@example.com",
+		HashedPassword: "hash",
+		ID:             jesse.ID,
+	})
+	if !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("duplicate update should be a conflict, got: %v", err)
+	}
+}
+
+func testUsernames(t *testing.T, s Store) {
+	ctx := context.Background()
+	walt, err := s.Createuser(ctx, database.CreateuserParams{
+		Email:          "walt@example.com",
+		HashedPassword: "hash",
+		Username:       sql.NullString{String: "heisenberg", Valid: true},
+	})
+	if err != nil || walt.Username.String != "heisenberg" {
+		t.Fatalf("couldn't create user with a username --> %+v %v <--", walt, err)
+	}
+	jesse := mustCreateUser(t, s, "jesse@example.com")
+
+	byUsername, err := s.GetUserByUsername(ctx, "HeisenBerg")
+	if err != nil || byUsername.ID != walt.ID {
+		t.Errorf("get user by username mismatch --> %v %v <--", byUsername.ID, err)
+	}
+
+	_, err = s.Createuser(ctx, database.CreateuserParams{
+		Email:          "saul@example.com",
+		HashedPassword: "hash",
+		Username:       sql.NullString{String: "Heisenberg", Valid: true},
+	})
+	if !errors.Is(err, apperr.ErrConflict) || !strings.Contains(err.Error(), "username") {
+		t.Errorf("username differing in case should be a username conflict, got: %v", err)
+	}
+
+	update := database.UpdateUserEmailAndPasswordParams{
+		Email:          "jesse@example.com",
+		HashedPassword: "hash",
+		Username:       sql.NullString{String: "heisenberg", Valid: true},
+		ID:             jesse.ID,
+	}
+	err = s.UpdateUserEmailAndPassword(ctx, update)
+	if !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("taking a username on update should be a conflict, got: %v", err)
+	}
+
+	update.Username.String = "cap_n_cook"
+	err = s.UpdateUserEmailAndPassword(ctx, update)
+	if err != nil {
+		t.Fatalf("couldn't set username: %v", err)
+	}
+
+	// updates without a username keep it
+	update.Username = sql.NullString{}
+	err = s.UpdateUserEmailAndPassword(ctx, update)
+	updated, _ := s.GetUserById(ctx, jesse.ID)
+	if err != nil || updated.Username.String != "cap_n_cook" {
+		t.Errorf("username should be kept --> %q %v <--", updated.Username.String, err)
+	}
+
+	if _, err := s.GetUserByUsername(ctx, "nobody"); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("get user by missing username should return apperr.ErrNotFound, got: %v", err)
+	}
+}
+
+func testProfiles(t *testing.T, s Store) {
+	ctx := context.Background()
+	walt := mustCreateUser(t, s, "walt@example.com")
+	jesse := mustCreateUser(t, s, "jesse@example.com")
+	mustCreateChirp(t, s, walt.ID, "Say my name")
+	mustCreateChirp(t, s, walt.ID, "I am the danger")
+
+	err := s.UpdateUserProfile(ctx, database.UpdateUserProfileParams{
+		Username:    sql.NullString{String: "Heisenberg", Valid: true},
+		DisplayName: sql.NullString{String: "Walter White", Valid: true},
+		Bio:         sql.NullString{String: "chemistry teacher", Valid: true},
+		AvatarUrl:   sql.NullString{String: "https://example.com/walt.png", Valid: true},
+		ID:          walt.ID,
+	})
+	if err != nil {
+		t.Fatalf("couldn't update profile: %v", err)
+	}
+
+	// null fields are kept, empty ones cleared
+	err = s.UpdateUserProfile(ctx, database.UpdateUserProfileParams{
+		Bio: sql.NullString{String: "", Valid: true},
+		ID:  walt.ID,
+	})
+	if err != nil {
+		t.Fatalf("couldn't update profile: %v", err)
+	}
+
+	updated, err := s.GetUserByUsername(ctx, "heisenberg")
+	if err != nil || updated.ID != walt.ID || updated.Username.String != "Heisenberg" || updated.DisplayName != "Walter White" ||
+		updated.Bio != "" || updated.AvatarUrl != "https://example.com/walt.png" {
+		t.Errorf("profile mismatch --> %+v %v <--", updated, err)
+	}
+
+	err = s.UpdateUserProfile(ctx, database.UpdateUserProfileParams{
+		Username: sql.NullString{String: "HEISENBERG", Valid: true},
+		ID:       jesse.ID,
+	})
+	if !errors.Is(err, apperr.ErrConflict) || !strings.Contains(err.Error(), "username") {
+		t.Errorf("taking a username in another case should be a username conflict, got: %v", err)
+	}
+
+	count, err := s.CountChirpsByUser(ctx, walt.ID)
+	if err != nil || count != 2 {
+		t.Errorf("walt's chirp count mismatch --> %d %v != 2 <--", count, err)
+	}
+	count, err = s.CountChirpsByUser(ctx, jesse.ID)
+	if err != nil || count != 0 {
+		t.Errorf("jesse's chirp count mismatch --> %d %v != 0 <--", count, err)
+	}
+}
+
+func testMissingRows(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	if _, err := s.GetUserById(ctx, uuid.New()); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("get user by id should return apperr.ErrNotFound, got: %v", err)
+	}
+
+	if _, err := s.GetUserByEmail(ctx, "nobody@example.com"); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("get user by email should return apperr.ErrNotFound, got: %v", err)
+	}
+
+	if _, err := s.GetChirp(ctx, uuid.New()); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("get chirp should return apperr.ErrNotFound, got: %v", err)
+	}
+
+	if _, err := s.GetRefreshToken(ctx, "missing"); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("get refresh token should return apperr.ErrNotFound, got: %v", err)
+	}
+}
+
+func testChirps(t *testing.T, s Store) {
+	ctx := context.Background()
+	walt := mustCreateUser(t, s, "walt@example.com")
+	jesse := mustCreateUser(t, s, "jesse@example.com")
+
+	first := mustCreateChirp(t, s, walt.ID, "first")
+	second := mustCreateChirp(t, s, jesse.ID, "second")
+	third := mustCreateChirp(t, s, walt.ID, "third")
+
+	chirp, err := s.GetChirp(ctx, second.ID)
+	if err != nil || chirp.Body != "second" || chirp.UserID != jesse.ID {
+		t.Errorf("get chirp mismatch --> %+v %v <--", chirp, err)
+	}
+
+	all, err := s.GetAllChirps(ctx)
+	if err != nil {
+		t.Fatalf("couldn't get chirps: %v", err)
+	}
+	assertChirpIds(t, all, first.ID, second.ID, third.ID)
+
+	byUser, err := s.GetChirpsByUser(ctx, walt.ID)
+	if err != nil {
+		t.Fatalf("couldn't get chirps by user: %v", err)
+	}
+	assertChirpIds(t, byUser, first.ID, third.ID)
+
+	// deleting someone else's chirp is a no-op
+	err = s.DeleteChirp(ctx, database.DeleteChirpParams{ID: first.ID, UserID: jesse.ID})
+	if err != nil {
+		t.Fatalf("couldn't delete chirp: %v", err)
+	}
+
+	err = s.DeleteChirp(ctx, database.DeleteChirpParams{ID: third.ID, UserID: walt.ID})
+	if err != nil {
+		t.Fatalf("couldn't delete chirp: %v", err)
+	}
+
+	byUser, err = s.GetChirpsByUser(ctx, walt.ID)
+	if err != nil {
+		t.Fatalf("couldn't get chirps by user: %v", err)
+	}
+	assertChirpIds(t, byUser, first.ID)
+}
+
+func testChirpWithoutUser(t *testing.T, s Store) {
+	_, err := s.CreateChirp(context.Background(), database.CreateChirpParams{Body: "orphan", UserID: uuid.New()})
+	if !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("chirp without user should be not found, got: %v", err)
+	}
+}
+
+func testChirpEntities(t *testing.T, s Store) {
+	ctx := context.Background()
+	walt := mustCreateUser(t, s, "walt@example.com")
+	jesse := mustCreateUser(t, s, "jesse@example.com")
+	first := mustCreateChirp(t, s, walt.ID, "@jesse #science")
+	second := mustCreateChirp(t, s, walt.ID, "#science rocks")
+	mustCreateChirp(t, s, walt.ID, "#chemistry")
+
+	entities := []database.CreateChirpEntityParams{
+		{ChirpID: first.ID, Kind: "hashtag", StartOffset: 7, EndOffset: 15, Value: "science"},
+		{ChirpID: first.ID, Kind: "mention", StartOffset: 0, EndOffset: 6, Value: "jesse", UserID: uuid.NullUUID{UUID: jesse.ID, Valid: true}},
+		{ChirpID: second.ID, Kind: "hashtag", StartOffset: 0, EndOffset: 8, Value: "science"},
+	}
+	for _, entity := range entities {
+		err := s.CreateChirpEntity(ctx, entity)
+		if err != nil {
+			t.Fatalf("couldn't create chirp entity: %v", err)
+		}
+	}
+
+	err := s.CreateChirpEntity(ctx, entities[0])
+	if !errors.Is(err, apperr.ErrConflict) {
+		t.Errorf("two entities at one offset should be a conflict, got: %v", err)
+	}
+
+	err = s.CreateChirpEntity(ctx, database.CreateChirpEntityParams{ChirpID: uuid.New(), Kind: "hashtag", Value: "science"})
+	if !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("entity of a missing chirp should fail, got: %v", err)
+	}
+
+	got, err := s.GetChirpEntities(ctx, []uuid.UUID{second.ID, first.ID, uuid.New()})
+	if err != nil || len(got) != 3 {
+		t.Fatalf("get chirp entities mismatch --> %+v %v <--", got, err)
+	}
+	for _, entity := range got {
+		if entity.ChirpID == first.ID && entity.Kind == "mention" && entity.UserID.UUID != jesse.ID {
+			t.Errorf("mention should keep its user --> %+v <--", entity)
+		}
+	}
+	firstEntities := slices.DeleteFunc(slices.Clone(got), func(entity database.ChirpEntity) bool { return entity.ChirpID != first.ID })
+	if len(firstEntities) != 2 || firstEntities[0].StartOffset != 0 || firstEntities[1].StartOffset != 7 {
+		t.Errorf("entities should be ordered by offset --> %+v <--", firstEntities)
+	}
+
+	chirps, err := s.GetChirpsByHashtag(ctx, "science")
+	if err != nil {
+		t.Fatalf("couldn't get chirps by hashtag: %v", err)
+	}
+	assertChirpIds(t, chirps, first.ID, second.ID)
+
+	// bounds in another zone still compare by instant
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	hashtags, err := s.GetHashtagsCreatedBetween(ctx, database.GetHashtagsCreatedBetweenParams{
+		Since: first.CreatedAt.In(zone),
+		Until: time.Now().Add(time.Minute).In(zone),
+	})
+	if err != nil || len(hashtags) != 2 || hashtags[0].Value != "science" || hashtags[1].Value != "science" {
+		t.Fatalf("hashtags created between mismatch --> %+v %v <--", hashtags, err)
+	}
+
+	hashtags, err = s.GetHashtagsCreatedBetween(ctx, database.GetHashtagsCreatedBetweenParams{
+		Since: first.CreatedAt.Add(-time.Hour),
+		Until: first.CreatedAt,
+	})
+	if err != nil || len(hashtags) != 0 {
+		t.Errorf("hashtags before the first chirp mismatch --> %+v %v <--", hashtags, err)
+	}
+
+	err = s.DeleteChirp(ctx, database.DeleteChirpParams{ID: first.ID, UserID: walt.ID})
+	if err != nil {
+		t.Fatalf("couldn't delete chirp: %v", err)
+	}
+
+	got, _ = s.GetChirpEntities(ctx, []uuid.UUID{first.ID})
+	if len(got) != 0 {
+		t.Errorf("entities should be deleted with their chirp --> %+v <--", got)
+	}
+}
+
+func testModeration(t *testing.T, s Store) {
+	ctx := context.Background()
+	walt := mustCreateUser(t, s, "walt@example.com")
+	jesse := mustCreateUser(t, s, "jesse@example.com")
+
+	for range 2 {
+		err := s.CreateModerator(ctx, walt.ID)
+		if err != nil {
+			t.Fatalf("couldn't create moderator: %v", err)
+		}
+	}
+
+	err := s.CreateModerator(ctx, uuid.New())
+	if !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("moderator without user should fail, got: %v", err)
+	}
+
+	isModerator, err := s.IsModerator(ctx, walt.ID)
+	if err != nil || !isModerator {
+		t.Errorf("walt should be a moderator --> %v %v <--", isModerator, err)
+	}
+	isModerator, err = s.IsModerator(ctx, jesse.ID)
+	if err != nil || isModerator {
+		t.Errorf("jesse shouldn't be a moderator --> %v %v <--", isModerator, err)
+	}
+
+	excludedBy := uuid.NullUUID{UUID: walt.ID, Valid: true}
+	for _, tag := range []string{"spam", "ads", "spam"} {
+		err = s.ExcludeTrendingTag(ctx, database.ExcludeTrendingTagParams{Tag: tag, ExcludedBy: excludedBy})
+		if err != nil {
+			t.Fatalf("couldn't exclude %s: %v", tag, err)
+		}
+	}
+
+	err = s.ExcludeTrendingTag(ctx, database.ExcludeTrendingTagParams{Tag: "x", ExcludedBy: uuid.NullUUID{UUID: uuid.New(), Valid: true}})
+	if !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("exclusion by a missing user should fail, got: %v", err)
+	}
+
+	excluded, err := s.GetExcludedTrendingTags(ctx)
+	if err != nil || len(excluded) != 2 || excluded[0].Tag != "ads" || excluded[1].Tag != "spam" || excluded[1].ExcludedBy != excludedBy {
+		t.Fatalf("excluded tags mismatch --> %+v %v <--", excluded, err)
+	}
+
+	included, err := s.IncludeTrendingTag(ctx, "ads")
+	if err != nil || included != 1 {
+		t.Errorf("include mismatch --> %d %v != 1 <--", included, err)
+	}
+	included, err = s.IncludeTrendingTag(ctx, "ads")
+	if err != nil || included != 0 {
+		t.Errorf("include again mismatch --> %d %v != 0 <--", included, err)
+	}
+
+	deleted, err := s.DeleteModerator(ctx, walt.ID)
+	if err != nil || deleted != 1 {
+		t.Errorf("delete moderator mismatch --> %d %v != 1 <--", deleted, err)
+	}
+	isModerator, _ = s.IsModerator(ctx, walt.ID)
+	if isModerator {
+		t.Errorf("walt should no longer be a moderator")
+	}
+
+	// exclusions outlive the moderator who made them
+	err = s.DeleteUsers(ctx)
+	if err != nil {
+		t.Fatalf("couldn't delete users: %v", err)
+	}
+	excluded, err = s.GetExcludedTrendingTags(ctx)
+	if err != nil || len(excluded) != 1 || excluded[0].ExcludedBy.Valid {
+		t.Errorf("excluded tags after deleting users mismatch --> %+v %v <--", excluded, err)
+	}
+
+	// nothing else clears exclusions, leave none behind for the next run
+	_, err = s.IncludeTrendingTag(ctx, "spam")
+	if err != nil {
+		t.Errorf("couldn't include spam: %v", err)
+	}
+}
+
+func testRefreshTokens(t *testing.T, s Store) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "walt@example.com")
+	expiresAt := time.Now().Add(time.Hour)
+
+	created, err := s.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		Token:     "token",
+		UserID:    user.ID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		t.Fatalf("couldn't create refresh token: %v", err)
+	}
+
+	if created.RevokedAt.Valid || created.UserID != user.ID {
+		t.Errorf("created refresh token malformed --> %+v <--", created)
+	}
+
+	if diff := created.ExpiresAt.Sub(expiresAt); diff > time.Millisecond || diff < -time.Millisecond {
+		t.Errorf("expires at mismatch --> %v != %v <--", created.ExpiresAt, expiresAt)
+	}
+
+	err = s.RevokeRefreshToken(ctx, "token")
+	if err != nil {
+		t.Fatalf("couldn't revoke refresh token: %v", err)
+	}
+
+	revoked, err := s.GetRefreshToken(ctx, "token")
+	if err != nil {
+		t.Fatalf("couldn't get refresh token: %v", err)
+	}
+
+	if !revoked.RevokedAt.Valid {
+		t.Errorf("refresh token should be revoked")
+	}
+}
+
+func testAdminUserUpdates(t *testing.T, s Store) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "walt@example.com")
+
+	if user.DisabledAt.Valid {
+		t.Errorf("new user shouldn't be disabled --> %+v <--", user)
+	}
+
+	err := s.UpdateUserPassword(ctx, database.UpdateUserPasswordParams{HashedPassword: "newHash", ID: user.ID})
+	if err != nil {
+		t.Fatalf("couldn't update password: %v", err)
+	}
+
+	err = s.DisableUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("couldn't disable user: %v", err)
+	}
+
+	disabled, err := s.GetUserByEmail(ctx, "walt@example.com")
+	if err != nil {
+		t.Fatalf("couldn't get user: %v", err)
+	}
+
+	if disabled.HashedPassword != "newHash" || !disabled.DisabledAt.Valid {
+		t.Errorf("updated user mismatch --> %+v <--", disabled)
+	}
+
+	// disabling twice keeps the first timestamp
+	err = s.DisableUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("couldn't disable user again: %v", err)
+	}
+
+	again, err := s.GetUserById(ctx, user.ID)
+	if err != nil || !again.DisabledAt.Time.Equal(disabled.DisabledAt.Time) {
+		t.Errorf("disabled at mismatch --> %v != %v %v <--", again.DisabledAt, disabled.DisabledAt, err)
+	}
+}
+
+func testRevokeRefreshTokensInBulk(t *testing.T, s Store) {
+	ctx := context.Background()
+	walt := mustCreateUser(t, s, "walt@example.com")
+	jesse := mustCreateUser(t, s, "jesse@example.com")
+
+	tokens := map[string]uuid.UUID{"walt-1": walt.ID, "walt-2": walt.ID, "jesse-1": jesse.ID, "jesse-2": jesse.ID}
+	for token, userId := range tokens {
+		_, err := s.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+			Token:     token,
+			UserID:    userId,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("couldn't create refresh token: %v", err)
+		}
+	}
+
+	err := s.RevokeRefreshToken(ctx, "jesse-2")
+	if err != nil {
+		t.Fatalf("couldn't revoke refresh token: %v", err)
+	}
+
+	revoked, err := s.RevokeUserRefreshTokens(ctx, walt.ID)
+	if err != nil || revoked != 2 {
+		t.Errorf("revoke user tokens mismatch --> %d != 2 %v <--", revoked, err)
+	}
+
+	jesseToken, err := s.GetRefreshToken(ctx, "jesse-1")
+	if err != nil || jesseToken.RevokedAt.Valid {
+		t.Errorf("other user's token shouldn't be revoked --> %+v %v <--", jesseToken, err)
+	}
+
+	// only jesse-1 is still live
+	revoked, err = s.RevokeAllRefreshTokens(ctx)
+	if err != nil || revoked != 1 {
+		t.Errorf("revoke all tokens mismatch --> %d != 1 %v <--", revoked, err)
+	}
+
+	for token := range tokens {
+		refreshToken, err := s.GetRefreshToken(ctx, token)
+		if err != nil || !refreshToken.RevokedAt.Valid {
+			t.Errorf("%s: token should be revoked --> %+v %v <--", token, refreshToken, err)
+		}
+	}
+}
+
+func mustCreateNotification(t *testing.T, s Store, arg database.CreateNotificationParams) database.Notification {
+	t.Helper()
+
+	notification, err := s.CreateNotification(context.Background(), arg)
+	if err != nil {
+		t.Fatalf("couldn't create notification: %v", err)
+	}
+
+	// created_at orders the list, keep consecutive notifications apart
+	time.Sleep(2 * time.Millisecond)
+
+	return notification
+}
+
+func testNotifications(t *testing.T, s Store) {
+	ctx := context.Background()
+	walt := mustCreateUser(t, s, "walt@example.com")
+	jesse := mustCreateUser(t, s, "jesse@example.com")
+	chirp := mustCreateChirp(t, s, walt.ID, "say my name")
+
+	upgraded := mustCreateNotification(t, s, database.CreateNotificationParams{UserID: walt.ID, Kind: "chirpy_red"})
+	mentioned := mustCreateNotification(t, s, database.CreateNotificationParams{
+		UserID:  walt.ID,
+		Kind:    "mention",
+		ActorID: uuid.NullUUID{UUID: jesse.ID, Valid: true},
+		ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+	})
+	mustCreateNotification(t, s, database.CreateNotificationParams{UserID: jesse.ID, Kind: "chirpy_red"})
+
+	if mentioned.ActorID.UUID != jesse.ID || mentioned.ChirpID.UUID != chirp.ID || mentioned.ReadAt.Valid || upgraded.ActorID.Valid {
+		t.Errorf("created notification malformed --> %+v %+v <--", mentioned, upgraded)
+	}
+
+	notifications, err := s.ListNotifications(ctx, database.ListNotificationsParams{UserID: walt.ID, Limit: 10})
+	if err != nil || len(notifications) != 2 || notifications[0].ID != mentioned.ID || notifications[1].ID != upgraded.ID {
+		t.Errorf("notifications should be listed newest first --> %+v %v <--", notifications, err)
+	}
+
+	notifications, err = s.ListNotifications(ctx, database.ListNotificationsParams{UserID: walt.ID, Limit: 1})
+	if err != nil || len(notifications) != 1 {
+		t.Errorf("limited notifications mismatch --> %d != 1 %v <--", len(notifications), err)
+	}
+
+	_, err = s.CreateNotification(ctx, database.CreateNotificationParams{
+		UserID:  walt.ID,
+		Kind:    "mention",
+		ActorID: uuid.NullUUID{UUID: uuid.New(), Valid: true},
+	})
+	if !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("notification from a missing actor should fail, got: %v", err)
+	}
+
+	marked, err := s.MarkNotificationRead(ctx, database.MarkNotificationReadParams{ID: upgraded.ID, UserID: jesse.ID})
+	if err != nil || marked != 0 {
+		t.Errorf("other users shouldn't mark notifications read --> %d %v <--", marked, err)
+	}
+
+	for range 2 {
+		marked, err = s.MarkNotificationRead(ctx, database.MarkNotificationReadParams{ID: upgraded.ID, UserID: walt.ID})
+		if err != nil || marked != 1 {
+			t.Errorf("mark read mismatch --> %d != 1 %v <--", marked, err)
+		}
+	}
+
+	unread, err := s.CountUnreadNotifications(ctx, walt.ID)
+	if err != nil || unread != 1 {
+		t.Errorf("unread mismatch --> %d != 1 %v <--", unread, err)
+	}
+
+	marked, err = s.MarkAllNotificationsRead(ctx, walt.ID)
+	if err != nil || marked != 1 {
+		t.Errorf("mark all read mismatch --> %d != 1 %v <--", marked, err)
+	}
+
+	unread, _ = s.CountUnreadNotifications(ctx, walt.ID)
+	jesseUnread, _ := s.CountUnreadNotifications(ctx, jesse.ID)
+	if unread != 0 || jesseUnread != 1 {
+		t.Errorf("unread after mark all mismatch --> %d %d <--", unread, jesseUnread)
+	}
+
+	// the mention goes with its chirp
+	err = s.DeleteChirp(ctx, database.DeleteChirpParams{ID: chirp.ID, UserID: walt.ID})
+	if err != nil {
+		t.Fatalf("couldn't delete chirp: %v", err)
+	}
+
+	notifications, _ = s.ListNotifications(ctx, database.ListNotificationsParams{UserID: walt.ID, Limit: 10})
+	if len(notifications) != 1 || notifications[0].ID != upgraded.ID {
+		t.Errorf("notifications after deleting the chirp mismatch --> %+v <--", notifications)
+	}
+}
+
+func testDeleteUsersCascades(t *testing.T, s Store) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "walt@example.com")
+	chirp := mustCreateChirp(t, s, user.ID, "say my name")
+
+	_, err := s.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		Token:     "token",
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("couldn't create refresh token: %v", err)
+	}
+
+	err = s.DeleteUsers(ctx)
+	if err != nil {
+		t.Fatalf("couldn't delete users: %v", err)
+	}
+
+	if _, err := s.GetChirp(ctx, chirp.ID); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("chirp should be deleted with its user, got: %v", err)
+	}
+
+	if _, err := s.GetRefreshToken(ctx, "token"); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("refresh token should be deleted with its user, got: %v", err)
+	}
+}
+
+func testConcurrentWrites(t *testing.T, s Store) {
+	ctx := context.Background()
+	user := mustCreateUser(t, s, "walt@example.com")
+
+	const writers = 20
+
+	var wg sync.WaitGroup
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.CreateChirp(ctx, database.CreateChirpParams{Body: "chirp", UserID: user.ID})
+			if err != nil {
+				t.Errorf("couldn't create chirp: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	chirps, err := s.GetChirpsByUser(ctx, user.ID)
+	if err != nil || len(chirps) != writers {
+		t.Errorf("concurrent chirps mismatch --> %d != %d %v <--", len(chirps), writers, err)
+	}
+}
+
+func assertChirpIds(t *testing.T, chirps []database.Chirp, ids ...uuid.UUID) {
+	t.Helper()
+
+	if len(chirps) != len(ids) {
+		t.Errorf("chirps length mismatch --> %d != %d <--", len(chirps), len(ids))
+		return
+	}
+
+	for i, id := range ids {
+		if chirps[i].ID != id {
+			t.Errorf("chirp %d mismatch --> %s != %s <--", i, chirps[i].ID, id)
+		}
+	}
+}
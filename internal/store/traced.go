@@ -0,0 +1,309 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/internal/apperr"
+	"github.com/magicznykacpur/chirpy/internal/database"
+	"github.com/magicznykacpur/chirpy/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Traced runs every call of the wrapped Store in a client span named after
+// the sqlc query it executes.
+type Traced struct {
+	next    Store
+	backend string
+}
+
+// WithTracing wraps s, backend is reported as the span's db.system.
+func WithTracing(s Store, backend string) *Traced {
+	return &Traced{next: s, backend: backend}
+}
+
+func (t *Traced) start(ctx context.Context, query string) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(tracing.TracerName).Start(ctx, query,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemKey.String(t.backend),
+			semconv.DBOperationName(query),
+		),
+	)
+	tracing.SetUser(ctx, span)
+
+	return ctx, span
+}
+
+// finish ends span, marking it failed unless err is nil or a missing row,
+// which handlers treat as a normal outcome.
+func finish(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, apperr.ErrNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (t *Traced) Createuser(ctx context.Context, arg database.CreateuserParams) (database.User, error) {
+	ctx, span := t.start(ctx, "Createuser")
+	user, err := t.next.Createuser(ctx, arg)
+	finish(span, err)
+	return user, err
+}
+
+func (t *Traced) DeleteUsers(ctx context.Context) error {
+	ctx, span := t.start(ctx, "DeleteUsers")
+	err := t.next.DeleteUsers(ctx)
+	finish(span, err)
+	return err
+}
+
+func (t *Traced) GetUsers(ctx context.Context) ([]database.User, error) {
+	ctx, span := t.start(ctx, "GetUsers")
+	users, err := t.next.GetUsers(ctx)
+	finish(span, err)
+	return users, err
+}
+
+func (t *Traced) GetUserByEmail(ctx context.Context, email string) (database.User, error) {
+	ctx, span := t.start(ctx, "GetUserByEmail")
+	user, err := t.next.GetUserByEmail(ctx, email)
+	finish(span, err)
+	return user, err
+}
+
+func (t *Traced) GetUserById(ctx context.Context, id uuid.UUID) (database.User, error) {
+	ctx, span := t.start(ctx, "GetUserById")
+	user, err := t.next.GetUserById(ctx, id)
+	finish(span, err)
+	return user, err
+}
+
+func (t *Traced) GetUserByUsername(ctx context.Context, username string) (database.User, error) {
+	ctx, span := t.start(ctx, "GetUserByUsername")
+	user, err := t.next.GetUserByUsername(ctx, username)
+	finish(span, err)
+	return user, err
+}
+
+func (t *Traced) UpdateUserEmailAndPassword(ctx context.Context, arg database.UpdateUserEmailAndPasswordParams) error {
+	ctx, span := t.start(ctx, "UpdateUserEmailAndPassword")
+	err := t.next.UpdateUserEmailAndPassword(ctx, arg)
+	finish(span, err)
+	return err
+}
+
+func (t *Traced) UpdateUserProfile(ctx context.Context, arg database.UpdateUserProfileParams) error {
+	ctx, span := t.start(ctx, "UpdateUserProfile")
+	err := t.next.UpdateUserProfile(ctx, arg)
+	finish(span, err)
+	return err
+}
+
+func (t *Traced) UpdateIsChirpyRed(ctx context.Context, id uuid.UUID) error {
+	ctx, span := t.start(ctx, "UpdateIsChirpyRed")
+	err := t.next.UpdateIsChirpyRed(ctx, id)
+	finish(span, err)
+	return err
+}
+
+func (t *Traced) UpdateUserPassword(ctx context.Context, arg database.UpdateUserPasswordParams) error {
+	ctx, span := t.start(ctx, "UpdateUserPassword")
+	err := t.next.UpdateUserPassword(ctx, arg)
+	finish(span, err)
+	return err
+}
+
+func (t *Traced) DisableUser(ctx context.Context, id uuid.UUID) error {
+	ctx, span := t.start(ctx, "DisableUser")
+	err := t.next.DisableUser(ctx, id)
+	finish(span, err)
+	return err
+}
+
+func (t *Traced) CreateChirp(ctx context.Context, arg database.CreateChirpParams) (database.Chirp, error) {
+	ctx, span := t.start(ctx, "CreateChirp")
+	chirp, err := t.next.CreateChirp(ctx, arg)
+	finish(span, err)
+	return chirp, err
+}
+
+func (t *Traced) GetChirp(ctx context.Context, id uuid.UUID) (database.Chirp, error) {
+	ctx, span := t.start(ctx, "GetChirp")
+	chirp, err := t.next.GetChirp(ctx, id)
+	finish(span, err)
+	return chirp, err
+}
+
+func (t *Traced) GetChirpsByUser(ctx context.Context, userID uuid.UUID) ([]database.Chirp, error) {
+	ctx, span := t.start(ctx, "GetChirpsByUser")
+	chirps, err := t.next.GetChirpsByUser(ctx, userID)
+	finish(span, err)
+	return chirps, err
+}
+
+func (t *Traced) CountChirpsByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	ctx, span := t.start(ctx, "CountChirpsByUser")
+	count, err := t.next.CountChirpsByUser(ctx, userID)
+	finish(span, err)
+	return count, err
+}
+
+func (t *Traced) GetChirpsByHashtag(ctx context.Context, tag string) ([]database.Chirp, error) {
+	ctx, span := t.start(ctx, "GetChirpsByHashtag")
+	chirps, err := t.next.GetChirpsByHashtag(ctx, tag)
+	finish(span, err)
+	return chirps, err
+}
+
+func (t *Traced) GetAllChirps(ctx context.Context) ([]database.Chirp, error) {
+	ctx, span := t.start(ctx, "GetAllChirps")
+	chirps, err := t.next.GetAllChirps(ctx)
+	finish(span, err)
+	return chirps, err
+}
+
+func (t *Traced) DeleteChirp(ctx context.Context, arg database.DeleteChirpParams) error {
+	ctx, span := t.start(ctx, "DeleteChirp")
+	err := t.next.DeleteChirp(ctx, arg)
+	finish(span, err)
+	return err
+}
+
+func (t *Traced) CreateChirpEntity(ctx context.Context, arg database.CreateChirpEntityParams) error {
+	ctx, span := t.start(ctx, "CreateChirpEntity")
+	err := t.next.CreateChirpEntity(ctx, arg)
+	finish(span, err)
+	return err
+}
+
+func (t *Traced) GetChirpEntities(ctx context.Context, chirpIDs []uuid.UUID) ([]database.ChirpEntity, error) {
+	ctx, span := t.start(ctx, "GetChirpEntities")
+	entities, err := t.next.GetChirpEntities(ctx, chirpIDs)
+	finish(span, err)
+	return entities, err
+}
+
+func (t *Traced) GetHashtagsCreatedBetween(ctx context.Context, arg database.GetHashtagsCreatedBetweenParams) ([]database.GetHashtagsCreatedBetweenRow, error) {
+	ctx, span := t.start(ctx, "GetHashtagsCreatedBetween")
+	hashtags, err := t.next.GetHashtagsCreatedBetween(ctx, arg)
+	finish(span, err)
+	return hashtags, err
+}
+
+func (t *Traced) CreateRefreshToken(ctx context.Context, arg database.CreateRefreshTokenParams) (database.RefreshToken, error) {
+	ctx, span := t.start(ctx, "CreateRefreshToken")
+	refreshToken, err := t.next.CreateRefreshToken(ctx, arg)
+	finish(span, err)
+	return refreshToken, err
+}
+
+func (t *Traced) GetRefreshToken(ctx context.Context, token string) (database.RefreshToken, error) {
+	ctx, span := t.start(ctx, "GetRefreshToken")
+	refreshToken, err := t.next.GetRefreshToken(ctx, token)
+	finish(span, err)
+	return refreshToken, err
+}
+
+func (t *Traced) RevokeRefreshToken(ctx context.Context, token string) error {
+	ctx, span := t.start(ctx, "RevokeRefreshToken")
+	err := t.next.RevokeRefreshToken(ctx, token)
+	finish(span, err)
+	return err
+}
+
+func (t *Traced) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) (int64, error) {
+	ctx, span := t.start(ctx, "RevokeUserRefreshTokens")
+	revoked, err := t.next.RevokeUserRefreshTokens(ctx, userID)
+	finish(span, err)
+	return revoked, err
+}
+
+func (t *Traced) RevokeAllRefreshTokens(ctx context.Context) (int64, error) {
+	ctx, span := t.start(ctx, "RevokeAllRefreshTokens")
+	revoked, err := t.next.RevokeAllRefreshTokens(ctx)
+	finish(span, err)
+	return revoked, err
+}
+
+func (t *Traced) CreateNotification(ctx context.Context, arg database.CreateNotificationParams) (database.Notification, error) {
+	ctx, span := t.start(ctx, "CreateNotification")
+	notification, err := t.next.CreateNotification(ctx, arg)
+	finish(span, err)
+	return notification, err
+}
+
+func (t *Traced) ListNotifications(ctx context.Context, arg database.ListNotificationsParams) ([]database.Notification, error) {
+	ctx, span := t.start(ctx, "ListNotifications")
+	notifications, err := t.next.ListNotifications(ctx, arg)
+	finish(span, err)
+	return notifications, err
+}
+
+func (t *Traced) CountUnreadNotifications(ctx context.Context, userID uuid.UUID) (int64, error) {
+	ctx, span := t.start(ctx, "CountUnreadNotifications")
+	count, err := t.next.CountUnreadNotifications(ctx, userID)
+	finish(span, err)
+	return count, err
+}
+
+func (t *Traced) MarkNotificationRead(ctx context.Context, arg database.MarkNotificationReadParams) (int64, error) {
+	ctx, span := t.start(ctx, "MarkNotificationRead")
+	marked, err := t.next.MarkNotificationRead(ctx, arg)
+	finish(span, err)
+	return marked, err
+}
+
+func (t *Traced) MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	ctx, span := t.start(ctx, "MarkAllNotificationsRead")
+	marked, err := t.next.MarkAllNotificationsRead(ctx, userID)
+	finish(span, err)
+	return marked, err
+}
+
+func (t *Traced) CreateModerator(ctx context.Context, userID uuid.UUID) error {
+	ctx, span := t.start(ctx, "CreateModerator")
+	err := t.next.CreateModerator(ctx, userID)
+	finish(span, err)
+	return err
+}
+
+func (t *Traced) DeleteModerator(ctx context.Context, userID uuid.UUID) (int64, error) {
+	ctx, span := t.start(ctx, "DeleteModerator")
+	deleted, err := t.next.DeleteModerator(ctx, userID)
+	finish(span, err)
+	return deleted, err
+}
+
+func (t *Traced) IsModerator(ctx context.Context, userID uuid.UUID) (bool, error) {
+	ctx, span := t.start(ctx, "IsModerator")
+	moderator, err := t.next.IsModerator(ctx, userID)
+	finish(span, err)
+	return moderator, err
+}
+
+func (t *Traced) ExcludeTrendingTag(ctx context.Context, arg database.ExcludeTrendingTagParams) error {
+	ctx, span := t.start(ctx, "ExcludeTrendingTag")
+	err := t.next.ExcludeTrendingTag(ctx, arg)
+	finish(span, err)
+	return err
+}
+
+func (t *Traced) IncludeTrendingTag(ctx context.Context, tag string) (int64, error) {
+	ctx, span := t.start(ctx, "IncludeTrendingTag")
+	included, err := t.next.IncludeTrendingTag(ctx, tag)
+	finish(span, err)
+	return included, err
+}
+
+func (t *Traced) GetExcludedTrendingTags(ctx context.Context) ([]database.TrendingExcludedTag, error) {
+	ctx, span := t.start(ctx, "GetExcludedTrendingTags")
+	tags, err := t.next.GetExcludedTrendingTags(ctx)
+	finish(span, err)
+	return tags, err
+}
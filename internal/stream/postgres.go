@@ -0,0 +1,92 @@
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/magicznykacpur/chirpy/internal/health"
+)
+
+// notifyChannel is the Postgres channel the instances share.
+const notifyChannel = "chirpy_chirp_events"
+
+// maxNotifyPayload is the largest payload Postgres accepts in a NOTIFY.
+const maxNotifyPayload = 8000
+
+// PostgresPublisher publishes events with NOTIFY, every instance running a
+// Listen receives them, including the publishing one.
+type PostgresPublisher struct {
+	db *sql.DB
+}
+
+func NewPostgresPublisher(db *sql.DB) *PostgresPublisher {
+	return &PostgresPublisher{db: db}
+}
+
+func (p *PostgresPublisher) Publish(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if len(payload) >= maxNotifyPayload {
+		return fmt.Errorf("event %s is %d bytes, NOTIFY takes less than %d", event.ID, len(payload), maxNotifyPayload)
+	}
+
+	_, err = p.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", notifyChannel, string(payload))
+	return err
+}
+
+// Listen feeds the events published by any instance into broker until ctx
+// is done. It reconnects on its own, events notified while the connection
+// is down are lost and the worker reports failing until it is back.
+func Listen(ctx context.Context, dbUrl string, broker *Broker, worker *health.Worker) error {
+	listener := pq.NewListener(dbUrl, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		switch event {
+		case pq.ListenerEventDisconnected, pq.ListenerEventConnectionAttemptFailed:
+			slog.Error("lost the chirp event listener connection", "error", err)
+			worker.Report(fmt.Errorf("listener disconnected: %w", err))
+		case pq.ListenerEventReconnected:
+			slog.Warn("reconnected the chirp event listener, events sent meanwhile are lost")
+			worker.Report(nil)
+		}
+	})
+	defer listener.Close()
+
+	err := listener.Listen(notifyChannel)
+	if err != nil {
+		return fmt.Errorf("couldn't listen on %s: %w", notifyChannel, err)
+	}
+
+	// the connection can die without a notification to notice it by
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			go listener.Ping()
+		case notification := <-listener.Notify:
+			// nil after a reconnect
+			if notification == nil {
+				continue
+			}
+
+			var event Event
+			err := json.Unmarshal([]byte(notification.Extra), &event)
+			if err != nil {
+				slog.Error("couldn't decode chirp event", "error", err)
+				continue
+			}
+
+			broker.Publish(ctx, event)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/internal/health"
+)
+
+// TestPostgres runs against the database in TEST_DB_URL, two listeners
+// stand in for two instances.
+func TestPostgres(t *testing.T) {
+	dbURL := os.Getenv("TEST_DB_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DB_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatalf("couldn't open postgres: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	var subscriptions []*Subscription
+	for range 2 {
+		broker := NewBroker(8, 8)
+		subscription, _ := broker.Subscribe(uuid.Nil, "")
+		subscriptions = append(subscriptions, subscription)
+		go Listen(ctx, dbURL, broker, health.New(time.Second).Worker("listener"))
+	}
+
+	// give the listeners time to connect
+	time.Sleep(500 * time.Millisecond)
+
+	publisher := NewPostgresPublisher(db)
+	event := newTestEvent(t, uuid.New())
+	err = publisher.Publish(ctx, event)
+	if err != nil {
+		t.Fatalf("couldn't publish: %v", err)
+	}
+
+	for i, subscription := range subscriptions {
+		select {
+		case got := <-subscription.Events():
+			if got.ID != event.ID || string(got.Data) != string(event.Data) {
+				t.Errorf("listener %d event mismatch --> %+v != %+v <--", i, got, event)
+			}
+		case <-time.After(5 * time.Second):
+			t.Errorf("listener %d got no event", i)
+		}
+	}
+
+	event.Data = []byte(`"` + strings.Repeat("a", maxNotifyPayload) + `"`)
+	err = publisher.Publish(ctx, event)
+	if err == nil {
+		t.Error("oversized event should fail")
+	}
+}
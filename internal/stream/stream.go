@@ -0,0 +1,230 @@
+// Package stream fans chirp events out to the clients following them, and
+// notification events out to the user they are for.
+//
+// A Broker keeps the subscribers of one instance and the last events it
+// delivered, so clients that reconnect can resume where they left off.
+// Handlers publish through a Publisher: the Broker itself when there is one
+// instance, or Postgres NOTIFY so every instance's Broker gets the event.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Event types.
+const (
+	ChirpCreated        = "chirp.created"
+	ChirpDeleted        = "chirp.deleted"
+	NotificationCreated = "notification.created"
+)
+
+type Event struct {
+	// ID orders events by publish time, clients send the last one they saw
+	// back as Last-Event-ID.
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// ChirpID and UserID are the chirp the event is about and its author.
+	// Private events are for UserID only, ChirpID is uuid.Nil when they
+	// aren't about a chirp.
+	ChirpID uuid.UUID       `json:"chirp_id"`
+	UserID  uuid.UUID       `json:"user_id"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Private reports whether only the user in UserID may receive event.
+func (e Event) Private() bool {
+	return e.Type == NotificationCreated
+}
+
+// NewEvent returns an event with a fresh id, data is marshalled to JSON.
+func NewEvent(eventType string, chirpID, userID uuid.UUID, data any) (Event, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return Event{}, err
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{ID: id.String(), Type: eventType, ChirpID: chirpID, UserID: userID, Data: raw}, nil
+}
+
+// Publisher hands an event to the brokers of every instance.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Broker delivers events to the subscribers of this instance. Delivery never
+// blocks: a subscriber whose buffer is full is dropped and has to resume.
+type Broker struct {
+	bufferSize  int
+	historySize int
+
+	mu          sync.Mutex
+	history     []Event
+	subscribers map[*Subscription]struct{}
+	closed      bool
+}
+
+// NewBroker returns a broker that buffers bufferSize events per subscriber
+// and keeps the last historySize events for resuming.
+func NewBroker(bufferSize, historySize int) *Broker {
+	return &Broker{
+		bufferSize:  bufferSize,
+		historySize: historySize,
+		subscribers: map[*Subscription]struct{}{},
+	}
+}
+
+// Publish delivers event to the subscribers of this instance only.
+func (b *Broker) Publish(_ context.Context, event Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+
+	if b.historySize > 0 {
+		if len(b.history) == b.historySize {
+			b.history = b.history[1:]
+		}
+		// append copies the retained events to a new array once the
+		// dropped ones fill the old one
+		b.history = append(b.history, event)
+	}
+
+	for subscription := range b.subscribers {
+		if !subscription.matches(event) {
+			continue
+		}
+
+		select {
+		case subscription.events <- event:
+		default:
+			subscription.lagged = true
+			b.remove(subscription)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe follows the chirp events of authorID, or everyone's when it is
+// uuid.Nil. With a lastEventID the retained events after it are returned in
+// Replay first, resumed is false when that event is no longer retained and
+// events may have been missed.
+func (b *Broker) Subscribe(authorID uuid.UUID, lastEventID string) (subscription *Subscription, resumed bool) {
+	return b.SubscribeAs(uuid.Nil, authorID, lastEventID)
+}
+
+// SubscribeAs is Subscribe for the logged in userID, who also gets the
+// private events addressed to them.
+func (b *Broker) SubscribeAs(userID, authorID uuid.UUID, lastEventID string) (subscription *Subscription, resumed bool) {
+	subscription = &Subscription{
+		broker:   b,
+		userID:   userID,
+		authorID: authorID,
+		events:   make(chan Event, b.bufferSize),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	resumed = lastEventID == ""
+	if !resumed {
+		i := slices.IndexFunc(b.history, func(e Event) bool { return e.ID == lastEventID })
+		resumed = i >= 0
+		if resumed {
+			for _, event := range b.history[i+1:] {
+				if subscription.matches(event) {
+					subscription.Replay = append(subscription.Replay, event)
+				}
+			}
+		}
+	}
+
+	if b.closed {
+		close(subscription.events)
+		return subscription, resumed
+	}
+
+	b.subscribers[subscription] = struct{}{}
+	return subscription, resumed
+}
+
+// Close ends every subscription, later ones end right away.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for subscription := range b.subscribers {
+		b.remove(subscription)
+	}
+}
+
+// Subscribers returns how many subscriptions are open.
+func (b *Broker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.subscribers)
+}
+
+// remove must be called with mu held.
+func (b *Broker) remove(subscription *Subscription) {
+	if _, ok := b.subscribers[subscription]; !ok {
+		return
+	}
+
+	delete(b.subscribers, subscription)
+	close(subscription.events)
+}
+
+type Subscription struct {
+	// Replay holds the events missed since the Last-Event-ID, they come
+	// before anything on Events.
+	Replay []Event
+
+	broker   *Broker
+	userID   uuid.UUID
+	authorID uuid.UUID
+	events   chan Event
+	lagged   bool
+}
+
+// Events is closed when the subscription ends.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Lagged reports whether the subscription was dropped for falling behind.
+func (s *Subscription) Lagged() bool {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	return s.lagged
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	s.broker.remove(s)
+}
+
+func (s *Subscription) matches(event Event) bool {
+	if event.Private() {
+		return s.userID != uuid.Nil && s.userID == event.UserID
+	}
+	return s.authorID == uuid.Nil || s.authorID == event.UserID
+}
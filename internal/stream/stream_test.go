@@ -0,0 +1,172 @@
+package stream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func newTestEvent(t *testing.T, userID uuid.UUID) Event {
+	t.Helper()
+
+	event, err := NewEvent(ChirpCreated, uuid.New(), userID, map[string]string{"body": "hello"})
+	if err != nil {
+		t.Fatalf("couldn't create event: %v", err)
+	}
+	return event
+}
+
+func receive(t *testing.T, subscription *Subscription) []Event {
+	t.Helper()
+
+	var events []Event
+	for {
+		select {
+		case event, ok := <-subscription.Events():
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestBrokerFiltersByAuthor(t *testing.T) {
+	broker := NewBroker(8, 8)
+	walt, jesse := uuid.New(), uuid.New()
+
+	everyone, _ := broker.Subscribe(uuid.Nil, "")
+	onlyWalt, _ := broker.Subscribe(walt, "")
+
+	broker.Publish(context.Background(), newTestEvent(t, walt))
+	broker.Publish(context.Background(), newTestEvent(t, jesse))
+
+	if got := len(receive(t, everyone)); got != 2 {
+		t.Errorf("everyone events mismatch --> %d != 2 <--", got)
+	}
+	if got := receive(t, onlyWalt); len(got) != 1 || got[0].UserID != walt {
+		t.Errorf("author events mismatch --> %+v <--", got)
+	}
+}
+
+func TestBrokerPrivateEvents(t *testing.T) {
+	broker := NewBroker(8, 8)
+	walt, jesse := uuid.New(), uuid.New()
+
+	anonymous, _ := broker.Subscribe(uuid.Nil, "")
+	waltOnly, _ := broker.Subscribe(walt, "")
+	asWalt, _ := broker.SubscribeAs(walt, uuid.Nil, "")
+	asJesse, _ := broker.SubscribeAs(jesse, uuid.Nil, "")
+
+	notification, err := NewEvent(NotificationCreated, uuid.Nil, walt, map[string]string{"kind": "mention"})
+	if err != nil {
+		t.Fatalf("couldn't create event: %v", err)
+	}
+	broker.Publish(context.Background(), notification)
+	broker.Publish(context.Background(), newTestEvent(t, jesse))
+
+	if got := receive(t, anonymous); len(got) != 1 || got[0].Private() {
+		t.Errorf("anonymous events mismatch --> %+v <--", got)
+	}
+	if got := receive(t, waltOnly); len(got) != 0 {
+		t.Errorf("author subscription shouldn't get private events --> %+v <--", got)
+	}
+	if got := receive(t, asWalt); len(got) != 2 || got[0].ID != notification.ID {
+		t.Errorf("recipient events mismatch --> %+v <--", got)
+	}
+	if got := receive(t, asJesse); len(got) != 1 || got[0].Private() {
+		t.Errorf("other user events mismatch --> %+v <--", got)
+	}
+}
+
+func TestBrokerResume(t *testing.T) {
+	broker := NewBroker(8, 3)
+	walt, jesse := uuid.New(), uuid.New()
+
+	var events []Event
+	for _, userID := range []uuid.UUID{walt, jesse, walt, walt} {
+		event := newTestEvent(t, userID)
+		events = append(events, event)
+		broker.Publish(context.Background(), event)
+	}
+
+	cases := []struct {
+		name        string
+		authorID    uuid.UUID
+		lastEventID string
+		replay      []Event
+		resumed     bool
+	}{
+		{name: "fresh", lastEventID: "", resumed: true},
+		{name: "latest", lastEventID: events[3].ID, resumed: true},
+		{name: "retained", lastEventID: events[1].ID, replay: events[2:], resumed: true},
+		{name: "retained by author", authorID: jesse, lastEventID: events[1].ID, resumed: true},
+		{name: "dropped from history", lastEventID: events[0].ID, resumed: false},
+		{name: "unknown", lastEventID: "nope", resumed: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			subscription, resumed := broker.Subscribe(c.authorID, c.lastEventID)
+			defer subscription.Close()
+
+			if resumed != c.resumed {
+				t.Errorf("resumed mismatch --> %v != %v <--", resumed, c.resumed)
+			}
+			if len(subscription.Replay) != len(c.replay) {
+				t.Fatalf("replay mismatch --> %d != %d <--", len(subscription.Replay), len(c.replay))
+			}
+			for i := range c.replay {
+				if subscription.Replay[i].ID != c.replay[i].ID {
+					t.Errorf("replay %d mismatch --> %s != %s <--", i, subscription.Replay[i].ID, c.replay[i].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	broker := NewBroker(2, 0)
+	slow, _ := broker.Subscribe(uuid.Nil, "")
+	fast, _ := broker.Subscribe(uuid.Nil, "")
+
+	for range 3 {
+		broker.Publish(context.Background(), newTestEvent(t, uuid.New()))
+		receive(t, fast)
+	}
+
+	if !slow.Lagged() {
+		t.Error("slow subscriber should have lagged")
+	}
+	if fast.Lagged() {
+		t.Error("fast subscriber shouldn't have lagged")
+	}
+	if got := len(receive(t, slow)); got != 2 {
+		t.Errorf("slow subscriber should keep its buffered events --> %d != 2 <--", got)
+	}
+	if _, ok := <-slow.Events(); ok {
+		t.Error("slow subscriber channel should be closed")
+	}
+	if got := broker.Subscribers(); got != 1 {
+		t.Errorf("subscribers mismatch --> %d != 1 <--", got)
+	}
+}
+
+func TestBrokerClose(t *testing.T) {
+	broker := NewBroker(2, 2)
+	before, _ := broker.Subscribe(uuid.Nil, "")
+
+	broker.Close()
+	after, _ := broker.Subscribe(uuid.Nil, "")
+
+	for _, subscription := range []*Subscription{before, after} {
+		if _, ok := <-subscription.Events(); ok {
+			t.Error("subscription should end when the broker closes")
+		}
+		// closing twice is fine
+		subscription.Close()
+	}
+}
@@ -0,0 +1,122 @@
+// Package tracing sets up OpenTelemetry and the spans chirpy records. Traces
+// are propagated with W3C traceparent headers.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/internal/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const TracerName = "github.com/magicznykacpur/chirpy"
+
+// UserIdKey is the span attribute holding the authenticated user.
+const UserIdKey = "chirpy.user_id"
+
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Setup installs a global tracer provider exporting with exporter, one of
+// the Exporter* constants. The OTLP exporter is configured with the standard
+// OTEL_EXPORTER_OTLP_* environment variables. The returned function flushes
+// and stops the provider.
+func Setup(ctx context.Context, exporter string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+
+	switch exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		spanExporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unsupported trace exporter: %q", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create %s exporter: %v", exporter, err)
+	}
+
+	provider := NewProvider(sdktrace.NewBatchSpanProcessor(spanExporter))
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// SetupInMemory installs a global tracer provider that keeps finished spans
+// in the returned exporter, for tests to inspect.
+func SetupInMemory() (*tracetest.InMemoryExporter, func(context.Context) error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewProvider(sdktrace.NewSimpleSpanProcessor(exporter))
+	otel.SetTracerProvider(provider)
+
+	return exporter, provider.Shutdown
+}
+
+// NewProvider returns a tracer provider for the chirpy service sending spans
+// to processor.
+func NewProvider(processor sdktrace.SpanProcessor) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName("chirpy"))),
+	)
+}
+
+// StartServerSpan starts the span of an incoming request, continuing the
+// trace from its traceparent header if there is one.
+func StartServerSpan(r *http.Request) (context.Context, trace.Span) {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	return otel.Tracer(TracerName).Start(ctx, r.Method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(r.Method),
+			semconv.URLPath(r.URL.Path),
+		),
+	)
+}
+
+// EndServerSpan names span after the matched route pattern, records the
+// response status and the authenticated user, and ends it.
+func EndServerSpan(ctx context.Context, span trace.Span, route string, status int) {
+	if route != "" {
+		span.SetName(route)
+		span.SetAttributes(semconv.HTTPRoute(route))
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+
+	SetUser(ctx, span)
+	span.End()
+}
+
+// SetUser tags span with the authenticated user of ctx, if there is one.
+func SetUser(ctx context.Context, span trace.Span) {
+	if userId := logging.UserId(ctx); userId != uuid.Nil {
+		span.SetAttributes(attribute.String(UserIdKey, userId.String()))
+	}
+}
@@ -0,0 +1,205 @@
+// Package trending ranks hashtags by how fast they are rising. Hashtags are
+// counted into one minute buckets as they come in and ranking only walks the
+// buckets, so it costs the same however many chirps there are.
+package trending
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const bucketSize = time.Minute
+
+// Window is a period tags are ranked over.
+type Window struct {
+	Name   string
+	Length time.Duration
+}
+
+// Windows are ranked from the shortest to the longest.
+var Windows = []Window{
+	{Name: "1h", Length: time.Hour},
+	{Name: "24h", Length: 24 * time.Hour},
+}
+
+// Retention is how long hashtags are kept, the longest window and the one
+// before it that it is compared against.
+var Retention = 2 * Windows[len(Windows)-1].Length
+
+// Tag is a ranked hashtag, Count is how many times it was used in the
+// window.
+type Tag struct {
+	Tag   string
+	Count int
+	Score float64
+}
+
+type Ranking struct {
+	Window string
+	Tags   []Tag
+}
+
+// Snapshot is the outcome of the last Rank, RankedAt is zero until the
+// first one.
+type Snapshot struct {
+	RankedAt time.Time
+	Rankings []Ranking
+}
+
+// Tracker counts hashtags and keeps the latest ranking, it is safe for
+// concurrent use.
+type Tracker struct {
+	limit int
+
+	mu sync.Mutex
+	// buckets maps the start of a minute, in unix seconds, to the number of
+	// times each tag was used in it
+	buckets  map[int64]map[string]int
+	excluded map[string]bool
+	snapshot Snapshot
+}
+
+// NewTracker returns a Tracker ranking the top limit tags of each window.
+func NewTracker(limit int) *Tracker {
+	rankings := []Ranking{}
+	for _, window := range Windows {
+		rankings = append(rankings, Ranking{Window: window.Name, Tags: []Tag{}})
+	}
+
+	return &Tracker{
+		limit:    limit,
+		buckets:  map[int64]map[string]int{},
+		excluded: map[string]bool{},
+		snapshot: Snapshot{Rankings: rankings},
+	}
+}
+
+// Add counts one use of tag at at, it shows up in the next Rank.
+func (t *Tracker) Add(tag string, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	start := at.Truncate(bucketSize).Unix()
+	if t.buckets[start] == nil {
+		t.buckets[start] = map[string]int{}
+	}
+	t.buckets[start][tag]++
+}
+
+// Exclude replaces the tags left out of rankings, from the next Rank on.
+func (t *Tracker) Exclude(tags []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.excluded = map[string]bool{}
+	for _, tag := range tags {
+		t.excluded[tag] = true
+	}
+}
+
+// Snapshot returns the latest ranking.
+func (t *Tracker) Snapshot() Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.snapshot
+}
+
+// Rank drops the buckets older than Retention, ranks every window ending at
+// now and returns the new snapshot.
+func (t *Tracker) Rank(now time.Time) Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for start := range t.buckets {
+		if now.Sub(time.Unix(start, 0)) > Retention {
+			delete(t.buckets, start)
+		}
+	}
+
+	rankings := make([]Ranking, 0, len(Windows))
+	for _, window := range Windows {
+		rankings = append(rankings, Ranking{Window: window.Name, Tags: t.rank(window, now)})
+	}
+
+	t.snapshot = Snapshot{RankedAt: now, Rankings: rankings}
+	return t.snapshot
+}
+
+type usage struct {
+	count             int
+	current, previous float64
+}
+
+func (t *Tracker) rank(window Window, now time.Time) []Tag {
+	// uses count less the older they get, halving every quarter window
+	halfLife := window.Length / 4
+
+	usages := map[string]*usage{}
+	for start, counts := range t.buckets {
+		age := now.Sub(time.Unix(start, 0))
+		if age < 0 || age >= 2*window.Length {
+			continue
+		}
+
+		current := age < window.Length
+		if !current {
+			age -= window.Length
+		}
+		weight := math.Exp2(-float64(age) / float64(halfLife))
+
+		for tag, count := range counts {
+			if t.excluded[tag] {
+				continue
+			}
+
+			u := usages[tag]
+			if u == nil {
+				u = &usage{}
+				usages[tag] = u
+			}
+
+			if current {
+				u.count += count
+				u.current += float64(count) * weight
+			} else {
+				u.previous += float64(count) * weight
+			}
+		}
+	}
+
+	tags := []Tag{}
+	for tag, u := range usages {
+		if u.count == 0 {
+			continue
+		}
+		tags = append(tags, Tag{Tag: tag, Count: u.count, Score: Score(u.current, u.previous)})
+	}
+
+	slices.SortFunc(tags, func(a, b Tag) int {
+		switch {
+		case a.Score != b.Score:
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		case a.Count != b.Count:
+			return b.Count - a.Count
+		default:
+			return strings.Compare(a.Tag, b.Tag)
+		}
+	})
+
+	return tags[:min(len(tags), t.limit)]
+}
+
+// Score favours rising tags over always popular ones: it is the decayed
+// use count of the window times how much it grew over the window before.
+// A tag used at a steady pace scores about its count, one that just took
+// off about its count squared.
+func Score(current, previous float64) float64 {
+	return current * (current + 1) / (previous + 1)
+}
@@ -0,0 +1,91 @@
+package trending
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRank(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	tracker := NewTracker(3)
+
+	// #breakingbad is always popular, #heisenberg only just took off
+	for minutes := range 120 {
+		tracker.Add("breakingbad", now.Add(-time.Duration(minutes)*time.Minute))
+	}
+	for range 20 {
+		tracker.Add("heisenberg", now.Add(-5*time.Minute))
+	}
+	tracker.Add("science", now.Add(-30*time.Minute))
+	tracker.Add("spam", now.Add(-time.Minute))
+	tracker.Add("yesterday", now.Add(-20*time.Hour))
+	tracker.Add("forgotten", now.Add(-Retention-time.Hour))
+	tracker.Exclude([]string{"spam"})
+
+	snapshot := tracker.Rank(now)
+	if !snapshot.RankedAt.Equal(now) || len(snapshot.Rankings) != len(Windows) {
+		t.Fatalf("snapshot mismatch --> %+v <--", snapshot)
+	}
+
+	hour := snapshot.Rankings[0]
+	expected := []string{"heisenberg", "breakingbad", "science"}
+	if hour.Window != "1h" || len(hour.Tags) != len(expected) {
+		t.Fatalf("1h ranking mismatch --> %+v <--", hour)
+	}
+	for i, tag := range hour.Tags {
+		if tag.Tag != expected[i] {
+			t.Errorf("1h tag %d mismatch --> %s != %s <--", i, tag.Tag, expected[i])
+		}
+	}
+	if hour.Tags[0].Count != 20 || hour.Tags[1].Count != 60 {
+		t.Errorf("1h counts mismatch --> %+v <--", hour.Tags)
+	}
+
+	day := snapshot.Rankings[1]
+	if day.Window != "24h" || len(day.Tags) != 3 {
+		t.Fatalf("24h ranking mismatch --> %+v <--", day)
+	}
+	for _, tag := range day.Tags {
+		if tag.Tag == "spam" || tag.Tag == "forgotten" {
+			t.Errorf("24h ranking shouldn't have %s --> %+v <--", tag.Tag, day.Tags)
+		}
+	}
+
+	if len(tracker.buckets) != 121 {
+		t.Errorf("buckets older than the retention should be dropped --> %d != 121 <--", len(tracker.buckets))
+	}
+
+	if tracker.Snapshot().RankedAt != snapshot.RankedAt {
+		t.Errorf("snapshot should keep the last ranking")
+	}
+}
+
+func TestScore(t *testing.T) {
+	cases := []struct {
+		name           string
+		rising, steady [2]float64
+		risingMustWin  bool
+	}{
+		{name: "new tag beats a steady one twice its size", rising: [2]float64{10, 0}, steady: [2]float64{20, 20}, risingMustWin: true},
+		{name: "a much bigger steady tag still wins", rising: [2]float64{2, 0}, steady: [2]float64{50, 50}},
+	}
+
+	for _, c := range cases {
+		rising := Score(c.rising[0], c.rising[1])
+		steady := Score(c.steady[0], c.steady[1])
+		if (rising > steady) != c.risingMustWin {
+			t.Errorf("%s: score mismatch --> %v vs %v <--", c.name, rising, steady)
+		}
+	}
+
+	if Score(0, 5) != 0 {
+		t.Errorf("unused tags should score 0 --> %v <--", Score(0, 5))
+	}
+}
+
+func TestNewTracker(t *testing.T) {
+	snapshot := NewTracker(10).Snapshot()
+	if !snapshot.RankedAt.IsZero() || len(snapshot.Rankings) != len(Windows) || snapshot.Rankings[0].Tags == nil {
+		t.Errorf("empty snapshot mismatch --> %+v <--", snapshot)
+	}
+}
@@ -0,0 +1,215 @@
+// Package validate checks request values against declared rules and
+// reports every violation at once.
+//
+//	err := validate.Check(
+//		validate.Field("email", rq.Email, validate.Required(), validate.Email()),
+//		validate.Field("password", rq.Password, validate.Required(), validate.Password(rq.Email)),
+//	)
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/internal/apperr"
+	"github.com/magicznykacpur/chirpy/internal/cleaner"
+	"github.com/magicznykacpur/chirpy/internal/entities"
+)
+
+// Rule returns a message describing how value breaks it, or "" when value
+// passes.
+type Rule func(value string) string
+
+// Spec ties a request field to its rules.
+type Spec struct {
+	name  string
+	value string
+	rules []Rule
+}
+
+// Field declares the rules a request field has to pass, they run in order
+// and the first one that fails is reported.
+func Field(name, value string, rules ...Rule) Spec {
+	return Spec{name: name, value: value, rules: rules}
+}
+
+// Check runs every field's rules and returns an apperr validation error
+// listing all the fields that failed, or nil.
+func Check(fields ...Spec) error {
+	var violations []apperr.FieldError
+	for _, f := range fields {
+		for _, rule := range f.rules {
+			message := rule(f.value)
+			if message != "" {
+				violations = append(violations, apperr.FieldError{Field: f.name, Message: message})
+				break
+			}
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return apperr.InvalidFields(violations)
+}
+
+func Required() Rule {
+	return func(value string) string {
+		if strings.TrimSpace(value) == "" {
+			return "cannot be empty"
+		}
+		return ""
+	}
+}
+
+// MaxLength limits value to max user perceived characters as counted by
+// cleaner.Length, so an accented letter or an emoji made of several code
+// points counts once.
+func MaxLength(max int) Rule {
+	return func(value string) string {
+		if cleaner.Length(value) > max {
+			return fmt.Sprintf("too long, max %d characters", max)
+		}
+		return ""
+	}
+}
+
+// UUID accepts the textual form of a UUID.
+func UUID() Rule {
+	return func(value string) string {
+		_, err := uuid.Parse(value)
+		if err != nil {
+			return "must be a uuid"
+		}
+		return ""
+	}
+}
+
+// reservedUsernames could pass for the service or its staff, or clash with
+// paths next to /api/users/{handle}. They are lower case, usernames are
+// compared ignoring case.
+var reservedUsernames = map[string]bool{
+	"about":      true,
+	"admin":      true,
+	"api":        true,
+	"chirpy":     true,
+	"chirpy_red": true,
+	"everyone":   true,
+	"help":       true,
+	"here":       true,
+	"login":      true,
+	"me":         true,
+	"moderator":  true,
+	"null":       true,
+	"root":       true,
+	"settings":   true,
+	"signup":     true,
+	"support":    true,
+	"system":     true,
+	"undefined":  true,
+}
+
+// Username accepts names that can be @mentioned in a chirp and aren't
+// reserved.
+func Username() Rule {
+	return func(value string) string {
+		if !entities.ValidUsername(value) {
+			return fmt.Sprintf("must be 1 to %d letters, digits or underscores", entities.MaxUsernameLength)
+		}
+		if reservedUsernames[strings.ToLower(value)] {
+			return "is reserved"
+		}
+		return ""
+	}
+}
+
+// maxURLLength keeps links short enough for every browser.
+const maxURLLength = 2048
+
+// URL accepts absolute http and https links.
+func URL() Rule {
+	return func(value string) string {
+		if len(value) > maxURLLength {
+			return fmt.Sprintf("too long, max %d characters", maxURLLength)
+		}
+
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "must be an http or https url"
+		}
+		return ""
+	}
+}
+
+// maxEmailLength is the longest address that fits in an SMTP path.
+const maxEmailLength = 254
+
+// Email accepts a bare RFC 5322 addr-spec like walt@example.com, display
+// names and angle brackets are rejected.
+func Email() Rule {
+	return func(value string) string {
+		if len(value) > maxEmailLength {
+			return fmt.Sprintf("too long, max %d characters", maxEmailLength)
+		}
+
+		address, err := mail.ParseAddress(value)
+		// net/mail also parses "Name <addr>" and unquotes the local part,
+		// so only compare the shape
+		if err != nil || address.Name != "" || strings.ContainsAny(value, "<>") {
+			return "must be an email address like name@example.com"
+		}
+
+		_, domain, _ := strings.Cut(address.Address, "@")
+		if !strings.Contains(domain, ".") {
+			return "must be an email address like name@example.com"
+		}
+
+		return ""
+	}
+}
+
+// Password limits, bcrypt ignores everything past 72 bytes so longer
+// passwords would give a false sense of security.
+const (
+	minPasswordLength = 8
+	maxPasswordBytes  = 72
+)
+
+// Password enforces the password policy: 8 to 72 bytes long, mixing letters
+// with digits or symbols and not containing the name part of email.
+func Password(email string) Rule {
+	return func(value string) string {
+		if utf8.RuneCountInString(value) < minPasswordLength {
+			return fmt.Sprintf("too short, min %d characters", minPasswordLength)
+		}
+
+		if len(value) > maxPasswordBytes {
+			return fmt.Sprintf("too long, max %d bytes", maxPasswordBytes)
+		}
+
+		var letters, others bool
+		for _, r := range value {
+			if unicode.IsLetter(r) {
+				letters = true
+			} else if !unicode.IsSpace(r) {
+				others = true
+			}
+		}
+		if !letters || !others {
+			return "must mix letters with digits or symbols"
+		}
+
+		name, _, _ := strings.Cut(email, "@")
+		if len(name) >= 3 && strings.Contains(strings.ToLower(value), strings.ToLower(name)) {
+			return "cannot contain your email"
+		}
+
+		return ""
+	}
+}
@@ -0,0 +1,90 @@
+package validate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/magicznykacpur/chirpy/internal/apperr"
+)
+
+func TestRules(t *testing.T) {
+	cases := []struct {
+		name     string
+		rule     Rule
+		value    string
+		expected string
+	}{
+		{name: "required", rule: Required(), value: "walt", expected: ""},
+		{name: "required empty", rule: Required(), value: "", expected: "cannot be empty"},
+		{name: "required blank", rule: Required(), value: "  \t", expected: "cannot be empty"},
+		{name: "max length", rule: MaxLength(3), value: "ééé", expected: ""},
+		{name: "max length combining marks", rule: MaxLength(3), value: "e\u0301e\u0301e\u0301", expected: ""},
+		{name: "max length emoji sequence", rule: MaxLength(1), value: "\U0001f469\u200d\U0001f4bb", expected: ""},
+		{name: "max length over", rule: MaxLength(3), value: "éééé", expected: "too long, max 3 characters"},
+		{name: "uuid", rule: UUID(), value: "3311741c-680c-4546-99f3-fc9efac2036c", expected: ""},
+		{name: "uuid malformed", rule: UUID(), value: "user-1", expected: "must be a uuid"},
+		{name: "username", rule: Username(), value: "walter_white", expected: ""},
+		{name: "username symbols", rule: Username(), value: "walt.white", expected: "must be 1 to 15 letters, digits or underscores"},
+		{name: "username too long", rule: Username(), value: strings.Repeat("w", 16), expected: "must be 1 to 15 letters, digits or underscores"},
+		{name: "username reserved", rule: Username(), value: "Admin", expected: "is reserved"},
+		{name: "url", rule: URL(), value: "https://example.com/walt.png", expected: ""},
+		{name: "url relative", rule: URL(), value: "/walt.png", expected: "must be an http or https url"},
+		{name: "url scheme", rule: URL(), value: "javascript:alert(1)", expected: "must be an http or https url"},
+		{name: "url too long", rule: URL(), value: "https://example.com/" + strings.Repeat("a", 2048), expected: "too long, max 2048 characters"},
+		{name: "email", rule: Email(), value: "walt@example.com", expected: ""},
+		{name: "email quoted local part", rule: Email(), value: `"walter white"@example.com`, expected: ""},
+		{name: "email missing at", rule: Email(), value: "walt.example.com", expected: "must be an email address like name@example.com"},
+		{name: "email display name", rule: Email(), value: "Walt <walt@example.com>", expected: "must be an email address like name@example.com"},
+		{name: "email no domain dot", rule: Email(), value: "walt@localhost", expected: "must be an email address like name@example.com"},
+		{name: "email too long", rule: Email(), value: strings.Repeat("a", 250) + "@example.com", expected: "too long, max 254 characters"},
+		{name: "password", rule: Password("walt@example.com"), value: "blue-crystal", expected: ""},
+		{name: "password short", rule: Password("walt@example.com"), value: "abc12", expected: "too short, min 8 characters"},
+		{name: "password letters only", rule: Password("walt@example.com"), value: "heisenberg", expected: "must mix letters with digits or symbols"},
+		{name: "password digits only", rule: Password("walt@example.com"), value: "1234567890", expected: "must mix letters with digits or symbols"},
+		{name: "password contains email", rule: Password("walt@example.com"), value: "Walt12345", expected: "cannot contain your email"},
+		{name: "password over bcrypt limit", rule: Password("walt@example.com"), value: strings.Repeat("a1", 37), expected: "too long, max 72 bytes"},
+	}
+
+	for _, c := range cases {
+		message := c.rule(c.value)
+		if message != c.expected {
+			t.Errorf("%s: message mismatch --> %q != %q <--", c.name, message, c.expected)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	err := Check(
+		Field("email", "walt@example.com", Required(), Email()),
+		Field("body", "hello", Required(), MaxLength(140)),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = Check(
+		Field("email", "", Required(), Email()),
+		Field("password", "short", Required(), Password("")),
+		Field("body", "hello", Required()),
+	)
+	if !errors.Is(err, apperr.ErrValidation) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+
+	var validationErr *apperr.ValidationError
+	errors.As(err, &validationErr)
+
+	expected := []apperr.FieldError{
+		{Field: "email", Message: "cannot be empty"},
+		{Field: "password", Message: "too short, min 8 characters"},
+	}
+	if len(validationErr.Fields) != len(expected) {
+		t.Fatalf("fields mismatch --> %+v != %+v <--", validationErr.Fields, expected)
+	}
+	for i := range expected {
+		if validationErr.Fields[i] != expected[i] {
+			t.Errorf("field mismatch --> %+v != %+v <--", validationErr.Fields[i], expected[i])
+		}
+	}
+}
@@ -1,71 +1,344 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"net/netip"
 	"os"
+	"os/signal"
 	"sync/atomic"
+	"syscall"
+	"time"
 
-	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
-	"github.com/magicznykacpur/chirpy/internal/database"
+	"github.com/magicznykacpur/chirpy/internal/config"
+	"github.com/magicznykacpur/chirpy/internal/health"
+	"github.com/magicznykacpur/chirpy/internal/logging"
+	"github.com/magicznykacpur/chirpy/internal/metrics"
+	"github.com/magicznykacpur/chirpy/internal/ratelimit"
+	"github.com/magicznykacpur/chirpy/internal/store"
+	"github.com/magicznykacpur/chirpy/internal/stream"
+	"github.com/magicznykacpur/chirpy/internal/tracing"
+	"github.com/magicznykacpur/chirpy/internal/trending"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
-	db             *database.Queries
-	jwtSecret      string
-	polkaKey       string
+	filepathRoot   string
+	db             store.Store
+	metrics        *metrics.Metrics
+	health         *health.Checker
+	rateLimits     ratelimit.Store
+	// rateLimitPolicies maps a policy name to its limits, trustedProxies
+	// are the addresses allowed to set X-Forwarded-For
+	rateLimitPolicies map[string]ratelimit.Policy
+	trustedProxies    []netip.Prefix
+	jwtSecret         string
+	polkaKey          string
+	platform          string
+	// accessTokenTTL and refreshTokenTTL are the lifetimes of the tokens
+	// handed out on login and refresh
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	chirpMaxLength  int
+	// broker feeds the chirp streams of this instance, events is where
+	// handlers publish, the broker itself or the postgres fan-out
+	broker             *stream.Broker
+	events             stream.Publisher
+	streamHeartbeat    time.Duration
+	streamWriteTimeout time.Duration
+	// trending holds the hashtag rankings the trending job keeps up to date
+	trending *trending.Tracker
 }
 
-func main() {
-	godotenv.Load()
+// errUsage is returned for bad command lines after the usage was printed.
+var errUsage = errors.New("usage")
+
+// usageError prints text to stderr for a bad command line and returns
+// errUsage, so main exits with 2 without logging it.
+func usageError(text string) error {
+	fmt.Fprintln(os.Stderr, text)
+	return errUsage
+}
+
+const usage = `usage: chirpy [command] [arguments]
+
+commands:
+  serve                              start the API server, the default
+  migrate up|down|status|redo        apply or inspect database migrations
+  seed [-users N] [-chirps N]        fill a dev database with fake users and chirps
+  user create [-red] EMAIL           create a user, the password is read from stdin
+  user promote EMAIL                 give a user Chirpy Red
+  user moderator [-remove] EMAIL     let a user exclude trending tags, or stop them
+  user disable EMAIL                 block logins and revoke the user's refresh tokens
+  user reset-password EMAIL          set a new password read from stdin
+  tokens revoke-all [-user EMAIL]    revoke every live refresh token, or one user's
+  help                               show this message
 
-	const filepathRoot = "."
-	const port = "8080"
+every command reads the same configuration as the server
+`
 
-	dbUrl := os.Getenv("DB_URL")
-	db, err := sql.Open("postgres", dbUrl)
+func main() {
+	slog.SetDefault(slog.New(logging.NewHandler(slog.NewJSONHandler(os.Stdout, nil))))
+
+	err := run(os.Args[1:])
+	if errors.Is(err, errUsage) {
+		os.Exit(2)
+	}
 	if err != nil {
-		fmt.Printf("couldn't open database: %v\n", err)
+		slog.Error("chirpy stopped", "error", err)
 		os.Exit(1)
 	}
+}
+
+// run dispatches to the subcommand named by args[0], without one the server
+// starts as it always did.
+func run(args []string) error {
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	if command == "help" || command == "-h" || command == "--help" {
+		fmt.Print(usage)
+		return nil
+	}
+
+	conf, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("couldn't load config: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if command == "serve" {
+		if len(args) > 0 {
+			fmt.Fprint(os.Stderr, usage)
+			return errUsage
+		}
+		return runServe(ctx, conf)
+	}
+
+	// the admin commands only need the database, the rest of the config
+	// is checked when the server starts
+	var runCommand func(context.Context, store.Store, *sql.DB) error
+	switch command {
+	case "migrate":
+		runCommand = func(ctx context.Context, _ store.Store, sqlDB *sql.DB) error {
+			err := runMigrate(ctx, sqlDB, conf.DBURL, args)
+			if err != nil {
+				return fmt.Errorf("couldn't migrate: %w", err)
+			}
+			return nil
+		}
+	case "seed":
+		runCommand = func(ctx context.Context, db store.Store, _ *sql.DB) error {
+			return runSeed(ctx, db, conf, args, os.Stdout)
+		}
+	case "user":
+		runCommand = func(ctx context.Context, db store.Store, _ *sql.DB) error {
+			return runUser(ctx, db, args, os.Stdin, os.Stdout)
+		}
+	case "tokens":
+		runCommand = func(ctx context.Context, db store.Store, _ *sql.DB) error {
+			return runTokens(ctx, db, args, os.Stdout)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		return errUsage
+	}
+
+	db, sqlDB, err := openDatabase(ctx, conf.DBURL)
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	return runCommand(ctx, db, sqlDB)
+}
+
+// openDatabase opens the store for dbUrl and checks the database answers.
+func openDatabase(ctx context.Context, dbUrl string) (store.Store, *sql.DB, error) {
+	db, sqlDB, err := store.Open(dbUrl)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't open database: %w", err)
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err = sqlDB.PingContext(pingCtx)
+	if err != nil {
+		sqlDB.Close()
+		return nil, nil, fmt.Errorf("database unreachable: %w", err)
+	}
+
+	return db, sqlDB, nil
+}
+
+// runServe starts the API server and blocks until ctx is done and the
+// shutdown sequence finished.
+func runServe(ctx context.Context, conf config.Config) error {
+	err := conf.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	shutdown := shutdownSequence{}
+
+	shutdownTracing, err := tracing.Setup(ctx, conf.TracesExporter)
+	if err != nil {
+		return fmt.Errorf("couldn't set up tracing: %w", err)
+	}
+
+	dbUrl := conf.DBURL
+	db, sqlDB, err := openDatabase(ctx, dbUrl)
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	if conf.MigrateOnBoot {
+		err := migrateOnBoot(ctx, sqlDB, dbUrl)
+		if err != nil {
+			return fmt.Errorf("couldn't migrate database: %w", err)
+		}
+	}
 
 	apiCfg := apiConfig{
-		fileserverHits: atomic.Int32{},
-		db:             database.New(db),
-		jwtSecret:      os.Getenv("JWT_SECRET"),
-		polkaKey:       os.Getenv("POLKA_KEY"),
+		fileserverHits:  atomic.Int32{},
+		filepathRoot:    conf.FilepathRoot,
+		db:              db,
+		jwtSecret:       conf.JWTSecret,
+		polkaKey:        conf.PolkaKey,
+		platform:        conf.Platform,
+		accessTokenTTL:  conf.Tokens.AccessTTL,
+		refreshTokenTTL: conf.Tokens.RefreshTTL,
+		chirpMaxLength:  conf.Limits.ChirpLength,
+
+		broker:             stream.NewBroker(conf.Stream.Buffer, conf.Stream.History),
+		streamHeartbeat:    conf.Stream.Heartbeat,
+		streamWriteTimeout: conf.Server.WriteTimeout,
+	}
+	apiCfg.metrics = metrics.New(sqlDB, apiCfg.fileserverHitsValue)
+
+	migrator, err := newMigrator(sqlDB, dbUrl)
+	if err != nil {
+		return err
+	}
+
+	apiCfg.health = health.New(readinessTimeout)
+	apiCfg.health.Add("database", databaseCheck(sqlDB.PingContext))
+	apiCfg.health.Add("migrations", migrationsCheck(migrator))
+
+	apiCfg.trustedProxies, err = ratelimit.ParseTrustedProxies(conf.RateLimit.TrustedProxies)
+	if err != nil {
+		return err
+	}
+
+	apiCfg.rateLimits, err = newRateLimitStore(conf.RateLimit.Store, sqlDB, dbUrl)
+	if err != nil {
+		return err
+	}
+	apiCfg.rateLimitPolicies = rateLimitPolicies(conf.RateLimit.Policies)
+
+	var stopListener func(context.Context) error
+	apiCfg.events, stopListener, err = newStreamPublisher(conf.Stream.Fanout, sqlDB, dbUrl, apiCfg.broker, apiCfg.health)
+	if err != nil {
+		return err
 	}
 
-	mux := http.ServeMux{}
+	sweeperWorker := apiCfg.health.Worker("rate limit sweeper")
+	stopSweeper := startWorker(func(ctx context.Context) {
+		sweepRateLimits(ctx, apiCfg.rateLimits, apiCfg.rateLimitPolicies, time.Minute, sweeperWorker)
+	})
+
+	apiCfg.trending = trending.NewTracker(trendingLimit)
+	trendingJob, trendingWorker := newTrendingJob(db, apiCfg.trending, time.Now()), apiCfg.health.Worker("trending")
+	stopTrending := startWorker(func(ctx context.Context) {
+		runTrending(ctx, trendingJob, trendingInterval, trendingWorker)
+	})
+
+	server := newServer(apiCfg.routes(), ":"+conf.Port, conf.Server)
+	// streams never finish on their own, end them so Shutdown doesn't wait
+	// for them until the drain deadline
+	server.RegisterOnShutdown(apiCfg.broker.Close)
+
+	// components stop in the order they are added: report not ready so load
+	// balancers stop routing here, stop taking requests and drain the
+	// in-flight ones, stop the background workers, which keep feeding the
+	// draining streams until then, flush traces, the database pool closes
+	// last
+	shutdown.add("readiness", func(ctx context.Context) error {
+		apiCfg.health.Drain()
+		return sleep(ctx, conf.Server.DrainDelay)
+	})
+	shutdown.add("http server", func(ctx context.Context) error {
+		err := server.Shutdown(ctx)
+		if err != nil {
+			// drain deadline passed, drop the remaining connections
+			server.Close()
+		}
+		return err
+	})
+	if stopListener != nil {
+		shutdown.add("chirp event listener", stopListener)
+	}
+	shutdown.add("trending", stopTrending)
+	shutdown.add("rate limit sweeper", stopSweeper)
+	shutdown.add("tracing", shutdownTracing)
+	shutdown.add("database", func(context.Context) error { return sqlDB.Close() })
+
+	return serve(ctx, server, conf.Server.ShutdownTimeout, &shutdown)
+}
+
+func (cfg *apiConfig) routes() http.Handler {
+	mux := http.NewServeMux()
+
+	fileServerHandler := http.StripPrefix("/app", http.FileServer(http.Dir(cfg.filepathRoot)))
+	mux.Handle("/app/", cfg.middlewareServerHitsInc(fileServerHandler))
 
-	fileServerHandler := http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot)))
-	mux.Handle("/app/", apiCfg.middlewareServerHitsInc(fileServerHandler))
+	mux.HandleFunc("POST /admin/reset", cfg.handlerReset)
+	mux.HandleFunc("GET /admin/metrics", cfg.handlerMetrics)
+	mux.HandleFunc("GET /admin/users", cfg.handlerGetUsers)
 
-	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
-	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
-	mux.HandleFunc("GET /admin/users", apiCfg.handlerGetUsers)
+	mux.Handle("GET /metrics", cfg.metrics.Handler())
 
-	mux.HandleFunc("GET /api/healthz", handlerHealth)
+	mux.HandleFunc("GET /api/livez", handlerLiveness)
+	mux.HandleFunc("GET /api/healthz", handlerLiveness)
+	mux.HandleFunc("GET /api/readyz", cfg.handlerReadiness)
+	mux.HandleFunc("GET /api/openapi.json", handlerOpenAPI)
 
-	mux.HandleFunc("POST /api/chirps", apiCfg.handlerCreateChirp)
-	mux.HandleFunc("GET /api/chirps", apiCfg.handlerGetAllChirps)
-	mux.HandleFunc("GET /api/chirps/{id}", apiCfg.handlerGetChirpById)
-	mux.HandleFunc("DELETE /api/chirps/{id}", apiCfg.handlerDeleteChirp)
+	mux.Handle("POST /api/chirps", cfg.middlewareRateLimit(config.RateLimitCreateChirp, cfg.handlerCreateChirp))
+	mux.HandleFunc("GET /api/chirps", cfg.handlerGetAllChirps)
+	mux.HandleFunc("GET /api/chirps/stream", cfg.handlerChirpStream)
+	mux.HandleFunc("GET /api/ws", cfg.handlerWebSocket)
+	mux.HandleFunc("GET /api/chirps/{id}", cfg.handlerGetChirpById)
+	mux.HandleFunc("DELETE /api/chirps/{id}", cfg.handlerDeleteChirp)
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", cfg.handlerGetChirpsByHashtag)
+	mux.HandleFunc("GET /api/trending", cfg.handlerGetTrending)
+	mux.HandleFunc("GET /api/trending/excluded", cfg.handlerGetExcludedTags)
+	mux.HandleFunc("PUT /api/trending/excluded/{tag}", cfg.handlerExcludeTag)
+	mux.HandleFunc("DELETE /api/trending/excluded/{tag}", cfg.handlerIncludeTag)
 
-	mux.HandleFunc("POST /api/users", apiCfg.handlerCreateUser)
-	mux.HandleFunc("POST /api/login", apiCfg.handlerLoginUser)
-	mux.HandleFunc("PUT /api/users", apiCfg.handlerUpdateEmailAndPassword)
+	mux.Handle("POST /api/users", cfg.middlewareRateLimit(config.RateLimitCreateUser, cfg.handlerCreateUser))
+	mux.Handle("POST /api/login", cfg.middlewareRateLimit(config.RateLimitLogin, cfg.handlerLoginUser))
+	mux.HandleFunc("PUT /api/users", cfg.handlerUpdateEmailAndPassword)
+	mux.HandleFunc("PATCH /api/users", cfg.handlerUpdateProfile)
+	mux.HandleFunc("GET /api/users/{handle}", cfg.handlerGetProfile)
 
-	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
-	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
+	mux.HandleFunc("POST /api/refresh", cfg.handlerRefresh)
+	mux.HandleFunc("POST /api/revoke", cfg.handlerRevoke)
 
-	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerUpgradeWebhook)
+	mux.HandleFunc("GET /api/notifications", cfg.handlerGetNotifications)
+	mux.HandleFunc("POST /api/notifications/{id}/read", cfg.handlerMarkNotificationRead)
+	mux.HandleFunc("POST /api/notifications/read-all", cfg.handlerMarkAllNotificationsRead)
 
-	server := http.Server{Handler: &mux, Addr: ":" + port}
+	mux.HandleFunc("POST /api/polka/webhooks", cfg.handlerUpgradeWebhook)
 
-	fmt.Printf("starting server on %v\n", server.Addr)
-	server.ListenAndServe()
+	return middlewareRequestId(middlewareTracing(middlewareLogging(cfg.middlewareMetrics(mux))))
 }
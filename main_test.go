@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/internal/auth"
+	"github.com/magicznykacpur/chirpy/internal/database"
+	"github.com/magicznykacpur/chirpy/internal/health"
+	"github.com/magicznykacpur/chirpy/internal/logging"
+	"github.com/magicznykacpur/chirpy/internal/metrics"
+	"github.com/magicznykacpur/chirpy/internal/ratelimit"
+	"github.com/magicznykacpur/chirpy/internal/store"
+	"github.com/magicznykacpur/chirpy/internal/stream"
+	"github.com/magicznykacpur/chirpy/internal/trending"
+)
+
+const (
+	testJWTSecret = "very-secret-secret"
+	testPolkaKey  = "polka-test-key"
+	testPassword  = "myPassword1"
+)
+
+func newTestConfig(t *testing.T) *apiConfig {
+	t.Helper()
+
+	cfg := &apiConfig{
+		filepathRoot:    ".",
+		db:              store.WithTracing(store.NewMemory(), "memory"),
+		jwtSecret:       testJWTSecret,
+		polkaKey:        testPolkaKey,
+		accessTokenTTL:  time.Hour,
+		refreshTokenTTL: 60 * 24 * time.Hour,
+		chirpMaxLength:  140,
+
+		broker:             stream.NewBroker(8, 16),
+		streamHeartbeat:    time.Minute,
+		streamWriteTimeout: time.Second,
+	}
+	cfg.events = cfg.broker
+	cfg.metrics = metrics.New(nil, cfg.fileserverHitsValue)
+	cfg.health = health.New(time.Second)
+	cfg.rateLimits = ratelimit.NewMemory()
+	cfg.trending = trending.NewTracker(trendingLimit)
+
+	return cfg
+}
+
+func doRequest(t *testing.T, cfg *apiConfig, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	for key, values := range header {
+		r.Header.Del(key)
+		for _, value := range values {
+			r.Header.Add(key, value)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	cfg.routes().ServeHTTP(w, r)
+
+	return w
+}
+
+func createTestUser(t *testing.T, cfg *apiConfig, email string) database.User {
+	t.Helper()
+
+	hashedPassword, err := auth.HashPassword(testPassword)
+	if err != nil {
+		t.Fatalf("couldn't hash password: %v", err)
+	}
+
+	user, err := cfg.db.Createuser(context.Background(),
+		database.CreateuserParams{Email: email, HashedPassword: hashedPassword},
+	)
+	if err != nil {
+		t.Fatalf("couldn't create user: %v", err)
+	}
+
+	return user
+}
+
+func createTestChirp(t *testing.T, cfg *apiConfig, userId uuid.UUID, body string) database.Chirp {
+	t.Helper()
+
+	chirp, err := cfg.db.CreateChirp(context.Background(),
+		database.CreateChirpParams{Body: body, UserID: userId},
+	)
+	if err != nil {
+		t.Fatalf("couldn't create chirp: %v", err)
+	}
+
+	return chirp
+}
+
+func bearerHeader(t *testing.T, userId uuid.UUID) http.Header {
+	t.Helper()
+
+	token, err := auth.MakeJWT(userId, testJWTSecret, time.Hour)
+	if err != nil {
+		t.Fatalf("couldn't make jwt: %v", err)
+	}
+
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+func newTestLogHandler(w io.Writer) slog.Handler {
+	return logging.NewHandler(slog.NewJSONHandler(w, nil))
+}
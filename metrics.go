@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 )
 
 func (cfg *apiConfig) middlewareServerHitsInc(next http.Handler) http.Handler {
@@ -14,16 +15,48 @@ func (cfg *apiConfig) middlewareServerHitsInc(next http.Handler) http.Handler {
 	})
 }
 
+func (cfg *apiConfig) fileserverHitsValue() float64 {
+	return float64(cfg.fileserverHits.Load())
+}
+
+// middlewareMetrics records the duration of every request by route pattern
+// and status.
+func (cfg *apiConfig) middlewareMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		cfg.metrics.ObserveRequest(route, metricsMethod(r.Method), recorder.status, time.Since(start))
+	})
+}
+
+// metricsMethod keeps the method label bounded, routes without a method in
+// their pattern accept any token a client makes up.
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "other"
+	}
+}
+
 func (cfg *apiConfig) handlerMetrics(w http.ResponseWriter, r *http.Request) {
 	adminHTML, err := os.ReadFile("admin.html")
-
 	if err != nil {
-		fmt.Println("couldn't read file admin.html")
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(http.StatusText(http.StatusInternalServerError)))
-	} else {
-		serverHits := strings.ReplaceAll(string(adminHTML), "%d", fmt.Sprintf("%d", cfg.fileserverHits.Load()))
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(serverHits))
+		respondWithError(w, r, fmt.Errorf("couldn't read admin.html: %w", err))
+		return
 	}
+
+	serverHits := strings.ReplaceAll(string(adminHTML), "%d", fmt.Sprintf("%d", cfg.fileserverHits.Load()))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(serverHits))
 }
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestFileserverHitsAreCounted(t *testing.T) {
+	cfg := newTestConfig(t)
+
+	cases := []struct {
+		target   string
+		expected int
+	}{
+		{target: "/app/", expected: http.StatusOK},
+		{target: "/app/assets/logo.png", expected: http.StatusOK},
+		{target: "/app/missing.html", expected: http.StatusNotFound},
+	}
+
+	for _, c := range cases {
+		w := doRequest(t, cfg, http.MethodGet, c.target, "", nil)
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.target, w.Code, c.expected)
+		}
+	}
+
+	w := doRequest(t, cfg, http.MethodGet, "/admin/metrics", "", nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("status mismatch --> %d != %d <--", w.Code, http.StatusOK)
+	}
+
+	if !strings.Contains(w.Body.String(), "visited 3 times") {
+		t.Errorf("metrics should report 3 visits, got: %s", w.Body.String())
+	}
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	cfg := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com")
+
+	doRequest(t, cfg, http.MethodGet, "/app/", "", nil)
+	doRequest(t, cfg, "HEISENBERG", "/app/", "", nil)
+	doRequest(t, cfg, "BLUEMAGIC", "/nowhere", "", nil)
+	doRequest(t, cfg, http.MethodPost, "/api/chirps", `{"body": "hello chirpy"}`, bearerHeader(t, user.ID))
+	doRequest(t, cfg, http.MethodPost, "/api/login", `{"email": "walt@example.com", "password": "`+testPassword+`"}`, nil)
+	doRequest(t, cfg, http.MethodPost, "/api/login", `{"email": "walt@example.com", "password": "wrong"}`, nil)
+	doRequest(t, cfg, http.MethodPost, "/api/polka/webhooks", `{"event": "user.payment_failed"}`, http.Header{"Authorization": {"ApiKey " + testPolkaKey}})
+
+	w := doRequest(t, cfg, http.MethodGet, "/metrics", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status mismatch --> %d != %d <--", w.Code, http.StatusOK)
+	}
+
+	expected := []string{
+		`chirpy_fileserver_hits 2`,
+		`chirpy_chirps_created_total 1`,
+		`chirpy_logins_total{result="succeeded"} 1`,
+		`chirpy_logins_total{result="failed"} 1`,
+		`chirpy_polka_webhooks_total{outcome="ignored"} 1`,
+		`chirpy_http_request_duration_seconds_count{method="POST",route="POST /api/chirps",status="201"} 1`,
+		`chirpy_http_request_duration_seconds_count{method="POST",route="POST /api/login",status="401"} 1`,
+		`chirpy_http_request_duration_seconds_count{method="other",route="/app/",status="200"} 1`,
+		`chirpy_http_request_duration_seconds_count{method="other",route="unmatched",status="404"} 1`,
+		`go_goroutines`,
+	}
+
+	for _, series := range expected {
+		if !strings.Contains(w.Body.String(), series) {
+			t.Errorf("metrics should contain %s", series)
+		}
+	}
+
+	for _, method := range []string{"HEISENBERG", "BLUEMAGIC"} {
+		if strings.Contains(w.Body.String(), method) {
+			t.Errorf("made up method %s shouldn't become a label value", method)
+		}
+	}
+}
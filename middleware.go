@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/internal/logging"
+	"github.com/magicznykacpur/chirpy/internal/tracing"
+)
+
+const requestIdHeader = "X-Request-ID"
+
+// incoming request ids are echoed into logs and responses, so only accept
+// short, printable ones
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// middlewareRequestId propagates the X-Request-ID of the request, or
+// generates one, and makes it available to logs and error responses.
+func middlewareRequestId(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(requestIdHeader)
+		if !validRequestId.MatchString(requestId) {
+			requestId = uuid.NewString()
+		}
+
+		w.Header().Set(requestIdHeader, requestId)
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestId(r.Context(), requestId)))
+	})
+}
+
+// middlewareLogging writes one log line per request once it has been served.
+func middlewareLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+
+		level := slog.LevelInfo
+		if recorder.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		slog.Log(r.Context(), level, "request served",
+			"method", r.Method,
+			"route", route,
+			"path", r.URL.Path,
+			"status", recorder.status,
+			"latency", time.Since(start),
+			"bytes", recorder.bytes,
+		)
+	})
+}
+
+// responseRecorder remembers the status and size of a response.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if !rr.wroteHeader {
+		rr.status = status
+		rr.wroteHeader = true
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.wroteHeader = true
+	n, err := rr.ResponseWriter.Write(b)
+	rr.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// flush streamed responses.
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
+// Hijack hands the connection over to websocket handlers, the response is
+// recorded as 101 Switching Protocols.
+func (rr *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(rr.ResponseWriter).Hijack()
+	if err == nil && !rr.wroteHeader {
+		rr.status = http.StatusSwitchingProtocols
+		rr.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// middlewareTracing records a span for every request. It has to wrap the mux
+// so the span can be named after the matched route pattern.
+func middlewareTracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracing.StartServerSpan(r)
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		r = r.WithContext(ctx)
+		next.ServeHTTP(recorder, r)
+
+		tracing.EndServerSpan(ctx, span, r.Pattern, recorder.status)
+	})
+}
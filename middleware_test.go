@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestMiddlewareRequestId(t *testing.T) {
+	cfg := newTestConfig(t)
+
+	cases := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "propagated", incoming: "edge-1234", keep: true},
+		{name: "generated", incoming: "", keep: false},
+		{name: "invalid replaced", incoming: "bad id\nwith newline", keep: false},
+		{name: "too long replaced", incoming: strings.Repeat("a", 129), keep: false},
+	}
+
+	for _, c := range cases {
+		header := http.Header{}
+		if c.incoming != "" {
+			header.Set(requestIdHeader, c.incoming)
+		}
+
+		w := doRequest(t, cfg, http.MethodGet, "/api/chirps/not-a-uuid", "", header)
+
+		requestId := w.Header().Get(requestIdHeader)
+		if c.keep && requestId != c.incoming {
+			t.Errorf("%s: request id mismatch --> %s != %s <--", c.name, requestId, c.incoming)
+		}
+
+		if !c.keep {
+			if _, err := uuid.Parse(requestId); err != nil {
+				t.Errorf("%s: generated request id should be a uuid, got %q", c.name, requestId)
+			}
+		}
+
+		var problem problemRes
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("%s: couldn't unmarshal problem: %v", c.name, err)
+		}
+
+		if problem.RequestId != requestId {
+			t.Errorf("%s: problem request id mismatch --> %s != %s <--", c.name, problem.RequestId, requestId)
+		}
+	}
+}
+
+func TestMiddlewareLogging(t *testing.T) {
+	var buffer bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(newTestLogHandler(&buffer)))
+	defer slog.SetDefault(previous)
+
+	cfg := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com")
+
+	w := doRequest(t, cfg, http.MethodPost, "/api/chirps", `{"body": "hello chirpy"}`, bearerHeader(t, user.ID))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status mismatch --> %d != %d <--", w.Code, http.StatusCreated)
+	}
+
+	var line map[string]any
+	for _, raw := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		var candidate map[string]any
+		if err := json.Unmarshal([]byte(raw), &candidate); err != nil {
+			t.Fatalf("couldn't unmarshal log line: %v", err)
+		}
+		if candidate["msg"] == "request served" {
+			line = candidate
+		}
+	}
+
+	if line == nil {
+		t.Fatalf("request wasn't logged: %s", buffer.String())
+	}
+
+	expected := map[string]any{
+		"method":     "POST",
+		"route":      "POST /api/chirps",
+		"status":     float64(http.StatusCreated),
+		"bytes":      float64(w.Body.Len()),
+		"user_id":    user.ID.String(),
+		"request_id": w.Header().Get(requestIdHeader),
+	}
+
+	for key, value := range expected {
+		if line[key] != value {
+			t.Errorf("%s mismatch --> %v != %v <--", key, line[key], value)
+		}
+	}
+
+	if _, ok := line["latency"]; !ok {
+		t.Errorf("latency should be logged")
+	}
+}
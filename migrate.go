@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/magicznykacpur/chirpy/internal/migrate"
+	"github.com/magicznykacpur/chirpy/internal/store"
+)
+
+const migrateUsage = "usage: chirpy migrate up|down|status|redo"
+
+// runMigrate handles `chirpy migrate <command>`.
+func runMigrate(ctx context.Context, db *sql.DB, dbUrl string, args []string) error {
+	if len(args) != 1 {
+		return usageError(migrateUsage)
+	}
+
+	migrator, err := newMigrator(db, dbUrl)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		results, err := migrator.Up(ctx)
+		for _, result := range results {
+			fmt.Println(result)
+		}
+		if err == nil && len(results) == 0 {
+			fmt.Println("no migrations to apply")
+		}
+		return err
+	case "down":
+		result, err := migrator.Down(ctx)
+		if result != nil {
+			fmt.Println(result)
+		}
+		return err
+	case "redo":
+		results, err := migrator.Redo(ctx)
+		for _, result := range results {
+			fmt.Println(result)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if !status.AppliedAt.IsZero() {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%-25s %s\n", appliedAt, status.Source.Path)
+		}
+		return nil
+	default:
+		return usageError(migrateUsage)
+	}
+}
+
+// migrateOnBoot applies pending migrations before the server starts. On
+// Postgres the migrator holds an advisory lock, so replicas booting at once
+// wait for each other instead of racing.
+func migrateOnBoot(ctx context.Context, db *sql.DB, dbUrl string) error {
+	migrator, err := newMigrator(db, dbUrl)
+	if err != nil {
+		return err
+	}
+
+	results, err := migrator.Up(ctx)
+	for _, result := range results {
+		slog.Info("migrated", "migration", result.Source.Path, "duration", result.Duration)
+	}
+
+	return err
+}
+
+func newMigrator(db *sql.DB, dbUrl string) (*migrate.Migrator, error) {
+	backend, err := store.Backend(dbUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.New(db, backend)
+}
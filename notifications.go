@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/internal/apperr"
+	"github.com/magicznykacpur/chirpy/internal/auth"
+	"github.com/magicznykacpur/chirpy/internal/database"
+	"github.com/magicznykacpur/chirpy/internal/logging"
+	"github.com/magicznykacpur/chirpy/internal/stream"
+)
+
+// Notification kinds.
+const (
+	notificationMention   = "mention"
+	notificationChirpyRed = "chirpy_red"
+)
+
+// notificationsLimit is how many of the newest notifications are grouped
+// into a listing.
+const notificationsLimit = 100
+
+type notificationGroupRes struct {
+	Kind            string    `json:"kind"`
+	ChirpId         string    `json:"chirp_id,omitempty"`
+	Summary         string    `json:"summary"`
+	Count           int       `json:"count"`
+	Unread          int       `json:"unread"`
+	ActorIds        []string  `json:"actor_ids"`
+	NotificationIds []string  `json:"notification_ids"`
+	LatestAt        time.Time `json:"latest_at"`
+}
+
+type notificationsRes struct {
+	UnreadCount int64                  `json:"unread_count"`
+	Groups      []notificationGroupRes `json:"groups"`
+}
+
+// notificationRes is one notification, as sent to the websocket of the user
+// it is for.
+type notificationRes struct {
+	Id        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	ActorId   string    `json:"actor_id,omitempty"`
+	ChirpId   string    `json:"chirp_id,omitempty"`
+	Summary   string    `json:"summary"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func newNotificationRes(notification database.Notification) notificationRes {
+	res := notificationRes{
+		Id:        notification.ID.String(),
+		Kind:      notification.Kind,
+		Summary:   notificationSummary(notification.Kind, 1),
+		CreatedAt: notification.CreatedAt,
+	}
+	if notification.ActorID.Valid {
+		res.ActorId = notification.ActorID.UUID.String()
+	}
+	if notification.ChirpID.Valid {
+		res.ChirpId = notification.ChirpID.UUID.String()
+	}
+	return res
+}
+
+type unreadCountRes struct {
+	UnreadCount int64 `json:"unread_count"`
+}
+
+// notify stores a notification for arg.UserID and publishes it to their
+// websockets. Failing only costs the notification, so it is logged instead
+// of failing the request.
+func (cfg *apiConfig) notify(ctx context.Context, arg database.CreateNotificationParams) {
+	notification, err := cfg.db.CreateNotification(ctx, arg)
+	if err != nil {
+		slog.ErrorContext(ctx, "couldn't create notification", "kind", arg.Kind, "error", err)
+		return
+	}
+
+	event, err := stream.NewEvent(stream.NotificationCreated, notification.ChirpID.UUID, notification.UserID, newNotificationRes(notification))
+	if err == nil {
+		err = cfg.events.Publish(ctx, event)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "couldn't publish notification event", "kind", arg.Kind, "error", err)
+	}
+}
+
+// groupNotifications groups notifications, newest first, by kind and the
+// chirp they are about. The groups keep the order of their newest member.
+func groupNotifications(notifications []database.Notification) []notificationGroupRes {
+	groups := []notificationGroupRes{}
+	index := map[string]int{}
+
+	for _, notification := range notifications {
+		key := notification.Kind
+		if notification.ChirpID.Valid {
+			key += ":" + notification.ChirpID.UUID.String()
+		}
+
+		i, ok := index[key]
+		if !ok {
+			group := notificationGroupRes{
+				Kind:            notification.Kind,
+				ActorIds:        []string{},
+				NotificationIds: []string{},
+				LatestAt:        notification.CreatedAt,
+			}
+			if notification.ChirpID.Valid {
+				group.ChirpId = notification.ChirpID.UUID.String()
+			}
+
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, group)
+		}
+
+		group := &groups[i]
+		group.Count++
+		group.NotificationIds = append(group.NotificationIds, notification.ID.String())
+		if !notification.ReadAt.Valid {
+			group.Unread++
+		}
+		if notification.ActorID.Valid && !slices.Contains(group.ActorIds, notification.ActorID.UUID.String()) {
+			group.ActorIds = append(group.ActorIds, notification.ActorID.UUID.String())
+		}
+	}
+
+	for i := range groups {
+		groups[i].Summary = notificationSummary(groups[i].Kind, len(groups[i].ActorIds))
+	}
+
+	return groups
+}
+
+// notificationSummary describes a group, like "3 people mentioned you".
+func notificationSummary(kind string, actors int) string {
+	who := "someone"
+	if actors > 1 {
+		who = fmt.Sprintf("%d people", actors)
+	}
+
+	switch kind {
+	case notificationMention:
+		return who + " mentioned you"
+	case notificationChirpyRed:
+		return "you are now a Chirpy Red member"
+	default:
+		return kind
+	}
+}
+
+func (cfg *apiConfig) handlerGetNotifications(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, apperr.Unauthorized("couldn't get bearer token", err))
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, r, apperr.Unauthorized("token invalid", err))
+		return
+	}
+
+	logging.SetUserId(r.Context(), userId)
+
+	notifications, err := cfg.db.ListNotifications(r.Context(), database.ListNotificationsParams{
+		UserID: userId,
+		Limit:  notificationsLimit,
+	})
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't retrieve notifications: %w", err))
+		return
+	}
+
+	unread, err := cfg.db.CountUnreadNotifications(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't count notifications: %w", err))
+		return
+	}
+
+	responseBytes, err := json.Marshal(notificationsRes{
+		UnreadCount: unread,
+		Groups:      groupNotifications(notifications),
+	})
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't marshal response: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(responseBytes)
+}
+
+func (cfg *apiConfig) handlerMarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, apperr.Unauthorized("couldn't get bearer token", err))
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, r, apperr.Unauthorized("token invalid", err))
+		return
+	}
+
+	logging.SetUserId(r.Context(), userId)
+
+	notificationId, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondWithError(w, r, apperr.InvalidField("id", "couldn't parse id"))
+		return
+	}
+
+	marked, err := cfg.db.MarkNotificationRead(r.Context(), database.MarkNotificationReadParams{
+		ID:     notificationId,
+		UserID: userId,
+	})
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't mark notification read: %w", err))
+		return
+	}
+
+	// other users' notifications are as missing as unknown ones
+	if marked == 0 {
+		respondWithError(w, r, apperr.NotFound("notification", nil))
+		return
+	}
+
+	cfg.respondWithUnreadCount(w, r, userId)
+}
+
+func (cfg *apiConfig) handlerMarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, apperr.Unauthorized("couldn't get bearer token", err))
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, r, apperr.Unauthorized("token invalid", err))
+		return
+	}
+
+	logging.SetUserId(r.Context(), userId)
+
+	_, err = cfg.db.MarkAllNotificationsRead(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't mark notifications read: %w", err))
+		return
+	}
+
+	cfg.respondWithUnreadCount(w, r, userId)
+}
+
+// respondWithUnreadCount answers the mark read requests with what is left
+// unread, notifications may have arrived since the client listed them.
+func (cfg *apiConfig) respondWithUnreadCount(w http.ResponseWriter, r *http.Request, userId uuid.UUID) {
+	unread, err := cfg.db.CountUnreadNotifications(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't count notifications: %w", err))
+		return
+	}
+
+	responseBytes, err := json.Marshal(unreadCountRes{UnreadCount: unread})
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't marshal response: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(responseBytes)
+}
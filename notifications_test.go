@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/internal/database"
+)
+
+func TestGroupNotifications(t *testing.T) {
+	walt, jesse, saul := uuid.New(), uuid.New(), uuid.New()
+	chirp, otherChirp := uuid.New(), uuid.New()
+	start := time.Now()
+
+	notification := func(kind string, actor, chirp uuid.UUID, read bool) database.Notification {
+		start = start.Add(-time.Minute)
+		return database.Notification{
+			ID:        uuid.New(),
+			CreatedAt: start,
+			Kind:      kind,
+			ActorID:   uuid.NullUUID{UUID: actor, Valid: actor != uuid.Nil},
+			ChirpID:   uuid.NullUUID{UUID: chirp, Valid: chirp != uuid.Nil},
+			ReadAt:    sql.NullTime{Time: start, Valid: read},
+		}
+	}
+
+	// newest first, like ListNotifications
+	groups := groupNotifications([]database.Notification{
+		notification(notificationMention, jesse, chirp, false),
+		notification(notificationChirpyRed, uuid.Nil, uuid.Nil, false),
+		notification(notificationMention, saul, chirp, true),
+		notification(notificationMention, jesse, otherChirp, true),
+		notification(notificationMention, jesse, chirp, true),
+		notification(notificationMention, walt, otherChirp, true),
+	})
+
+	expected := []struct {
+		kind    string
+		chirpId uuid.UUID
+		count   int
+		unread  int
+		summary string
+	}{
+		{kind: notificationMention, chirpId: chirp, count: 3, unread: 1, summary: "2 people mentioned you"},
+		{kind: notificationChirpyRed, count: 1, unread: 1, summary: "you are now a Chirpy Red member"},
+		{kind: notificationMention, chirpId: otherChirp, count: 2, unread: 0, summary: "2 people mentioned you"},
+	}
+
+	if len(groups) != len(expected) {
+		t.Fatalf("groups length mismatch --> %d != %d <--", len(groups), len(expected))
+	}
+
+	for i, e := range expected {
+		group := groups[i]
+		chirpId := ""
+		if e.chirpId != uuid.Nil {
+			chirpId = e.chirpId.String()
+		}
+
+		if group.Kind != e.kind || group.ChirpId != chirpId || group.Count != e.count || group.Unread != e.unread || group.Summary != e.summary {
+			t.Errorf("group %d mismatch --> %+v != %+v <--", i, group, e)
+		}
+		if len(group.NotificationIds) != group.Count {
+			t.Errorf("group %d notification ids mismatch --> %d != %d <--", i, len(group.NotificationIds), group.Count)
+		}
+	}
+}
+
+func TestHandlerNotifications(t *testing.T) {
+	cfg := newTestConfig(t)
+	walt := createTestUser(t, cfg, "walt@example.com")
+	jesse := createTestUser(t, cfg, "jesse@example.com")
+	saul := createTestUser(t, cfg, "saul@example.com")
+	chirp := createTestChirp(t, cfg, walt.ID, "Say my name")
+
+	for _, actor := range []uuid.UUID{jesse.ID, saul.ID} {
+		cfg.notify(context.Background(), database.CreateNotificationParams{
+			UserID:  walt.ID,
+			Kind:    notificationMention,
+			ActorID: uuid.NullUUID{UUID: actor, Valid: true},
+			ChirpID: uuid.NullUUID{UUID: chirp.ID, Valid: true},
+		})
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	// a retried upgrade webhook doesn't notify twice
+	upgrade := `{"event": "user.upgraded", "data": {"user_id": "` + walt.ID.String() + `"}}`
+	for range 2 {
+		doRequest(t, cfg, http.MethodPost, "/api/polka/webhooks", upgrade, http.Header{"Authorization": {"ApiKey " + testPolkaKey}})
+	}
+
+	w := doRequest(t, cfg, http.MethodGet, "/api/notifications", "", bearerHeader(t, walt.ID))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status mismatch --> %d != %d <--", w.Code, http.StatusOK)
+	}
+
+	var listed notificationsRes
+	json.NewDecoder(w.Body).Decode(&listed)
+
+	if listed.UnreadCount != 3 || len(listed.Groups) != 2 {
+		t.Fatalf("notifications mismatch --> %+v <--", listed)
+	}
+	if listed.Groups[0].Kind != notificationChirpyRed || listed.Groups[1].Summary != "2 people mentioned you" {
+		t.Errorf("groups mismatch --> %+v <--", listed.Groups)
+	}
+
+	mentionId := listed.Groups[1].NotificationIds[0]
+
+	cases := []struct {
+		name     string
+		target   string
+		userId   uuid.UUID
+		expected int
+		unread   int64
+	}{
+		{name: "someone else's notification", target: "/api/notifications/" + mentionId + "/read", userId: jesse.ID, expected: http.StatusNotFound},
+		{name: "malformed id", target: "/api/notifications/walt/read", userId: walt.ID, expected: http.StatusBadRequest},
+		{name: "mark read", target: "/api/notifications/" + mentionId + "/read", userId: walt.ID, expected: http.StatusOK, unread: 2},
+		{name: "mark read again", target: "/api/notifications/" + mentionId + "/read", userId: walt.ID, expected: http.StatusOK, unread: 2},
+		{name: "mark all read", target: "/api/notifications/read-all", userId: walt.ID, expected: http.StatusOK, unread: 0},
+	}
+
+	for _, c := range cases {
+		w := doRequest(t, cfg, http.MethodPost, c.target, "", bearerHeader(t, c.userId))
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+			continue
+		}
+
+		if w.Code != http.StatusOK {
+			continue
+		}
+
+		var res unreadCountRes
+		json.NewDecoder(w.Body).Decode(&res)
+		if res.UnreadCount != c.unread {
+			t.Errorf("%s: unread mismatch --> %d != %d <--", c.name, res.UnreadCount, c.unread)
+		}
+	}
+
+	w = doRequest(t, cfg, http.MethodGet, "/api/notifications", "", nil)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous status mismatch --> %d != %d <--", w.Code, http.StatusUnauthorized)
+	}
+
+	w = doRequest(t, cfg, http.MethodGet, "/api/notifications", "", bearerHeader(t, jesse.ID))
+	if !strings.Contains(w.Body.String(), `"groups":[]`) {
+		t.Errorf("other users shouldn't see the notifications --> %s <--", w.Body.String())
+	}
+}
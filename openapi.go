@@ -0,0 +1,15 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/magicznykacpur/chirpy/api"
+)
+
+// handlerOpenAPI serves the OpenAPI document for client generators and api
+// explorers.
+func handlerOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(api.OpenAPI)
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/api"
+	"github.com/magicznykacpur/chirpy/internal/config"
+)
+
+var registerHTMLDecoder sync.Once
+
+func loadSpec(t *testing.T) (*openapi3.T, routers.Router) {
+	t.Helper()
+
+	// kin-openapi only decodes json and plain text bodies out of the box
+	registerHTMLDecoder.Do(func() {
+		openapi3filter.RegisterBodyDecoder("text/html", func(body io.Reader, _ http.Header, _ *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (any, error) {
+			data, err := io.ReadAll(body)
+			return string(data), err
+		})
+	})
+
+	doc, err := openapi3.NewLoader().LoadFromData(api.OpenAPI)
+	if err != nil {
+		t.Fatalf("couldn't load spec: %v", err)
+	}
+
+	err = doc.Validate(context.Background())
+	if err != nil {
+		t.Fatalf("spec is invalid: %v", err)
+	}
+
+	// match requests by path alone, test requests go to example.com
+	doc.Servers = nil
+
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		t.Fatalf("couldn't build spec router: %v", err)
+	}
+
+	return doc, router
+}
+
+// doContractRequest is doRequest checking the exchange against the spec:
+// the response always, the request only when the handler accepted it, since
+// error cases send invalid requests on purpose.
+func doContractRequest(t *testing.T, cfg *apiConfig, router routers.Router, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	for key, values := range header {
+		r.Header.Del(key)
+		for _, value := range values {
+			r.Header.Add(key, value)
+		}
+	}
+
+	route, pathParams, err := router.FindRoute(r)
+	if err != nil {
+		t.Fatalf("%s %s: not in spec: %v", method, target, err)
+	}
+
+	w := httptest.NewRecorder()
+	cfg.routes().ServeHTTP(w, r)
+
+	// the handler drained the body, give the validator a fresh copy
+	r.Body = io.NopCloser(strings.NewReader(body))
+
+	options := &openapi3filter.Options{
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+		IncludeResponseStatus: true,
+		MultiError:            true,
+	}
+	requestInput := &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: pathParams,
+		Route:      route,
+		Options:    options,
+	}
+
+	if w.Code < http.StatusBadRequest {
+		err = openapi3filter.ValidateRequest(context.Background(), requestInput)
+		if err != nil {
+			t.Errorf("%s %s: request breaks the spec: %v", method, target, err)
+		}
+	}
+
+	err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: requestInput,
+		Status:                 w.Code,
+		Header:                 w.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+		Options:                options,
+	})
+	if err != nil {
+		t.Errorf("%s %s: %d response breaks the spec: %v\n%s", method, target, w.Code, err, w.Body.String())
+	}
+
+	return w
+}
+
+func TestContract(t *testing.T) {
+	_, router := loadSpec(t)
+	cfg := newTestConfig(t)
+
+	user := createTestUser(t, cfg, "walt@example.com")
+	other := createTestUser(t, cfg, "jesse@example.com")
+	chirp := createTestChirp(t, cfg, user.ID, "I am the one who knocks")
+	otherChirp := createTestChirp(t, cfg, other.ID, "Yeah, science!")
+	refreshToken := createTestRefreshToken(t, cfg, user.ID, time.Hour)
+	if err := cfg.db.CreateModerator(context.Background(), other.ID); err != nil {
+		t.Fatalf("couldn't create moderator: %v", err)
+	}
+	polkaHeader := http.Header{"Authorization": {"ApiKey " + testPolkaKey}}
+
+	cases := []struct {
+		name     string
+		method   string
+		target   string
+		body     string
+		header   http.Header
+		expected int
+	}{
+		{name: "list chirps", method: http.MethodGet, target: "/api/chirps", expected: http.StatusOK},
+		{name: "list chirps by author", method: http.MethodGet, target: "/api/chirps?author_id=" + user.ID.String() + "&sort=desc", expected: http.StatusOK},
+		{name: "list chirps bad author", method: http.MethodGet, target: "/api/chirps?author_id=walt", expected: http.StatusBadRequest},
+		{name: "stream chirps bad author", method: http.MethodGet, target: "/api/chirps/stream?author_id=walt", expected: http.StatusBadRequest},
+		{name: "websocket anonymously", method: http.MethodGet, target: "/api/ws", expected: http.StatusUnauthorized},
+		{name: "get chirp", method: http.MethodGet, target: "/api/chirps/" + chirp.ID.String(), expected: http.StatusOK},
+		{name: "get missing chirp", method: http.MethodGet, target: "/api/chirps/" + uuid.NewString(), expected: http.StatusNotFound},
+		{name: "get chirp bad id", method: http.MethodGet, target: "/api/chirps/walt", expected: http.StatusBadRequest},
+		{name: "create chirp", method: http.MethodPost, target: "/api/chirps", body: `{"body": "Say my name"}`, header: bearerHeader(t, user.ID), expected: http.StatusCreated},
+		{name: "create chirp with entities", method: http.MethodPost, target: "/api/chirps", body: `{"body": "@gus_fring #LosPollos https://example.com"}`, header: bearerHeader(t, user.ID), expected: http.StatusCreated},
+		{name: "list hashtag chirps", method: http.MethodGet, target: "/api/hashtags/LosPollos/chirps?sort=desc", expected: http.StatusOK},
+		{name: "list hashtag chirps bad tag", method: http.MethodGet, target: "/api/hashtags/2008/chirps", expected: http.StatusBadRequest},
+		{name: "trending", method: http.MethodGet, target: "/api/trending", expected: http.StatusOK},
+		{name: "list excluded tags as a user", method: http.MethodGet, target: "/api/trending/excluded", header: bearerHeader(t, user.ID), expected: http.StatusForbidden},
+		{name: "exclude tag", method: http.MethodPut, target: "/api/trending/excluded/spam", header: bearerHeader(t, other.ID), expected: http.StatusNoContent},
+		{name: "list excluded tags", method: http.MethodGet, target: "/api/trending/excluded", header: bearerHeader(t, other.ID), expected: http.StatusOK},
+		{name: "include tag", method: http.MethodDelete, target: "/api/trending/excluded/spam", header: bearerHeader(t, other.ID), expected: http.StatusNoContent},
+		{name: "include tag not excluded", method: http.MethodDelete, target: "/api/trending/excluded/spam", header: bearerHeader(t, other.ID), expected: http.StatusNotFound},
+		{name: "create chirp anonymously", method: http.MethodPost, target: "/api/chirps", body: `{"body": "Say my name"}`, expected: http.StatusUnauthorized},
+		{name: "create chirp too long", method: http.MethodPost, target: "/api/chirps", body: `{"body": "` + strings.Repeat("a", 141) + `"}`, header: bearerHeader(t, user.ID), expected: http.StatusBadRequest},
+		{name: "create chirp unknown field", method: http.MethodPost, target: "/api/chirps", body: `{"body": "hi", "user_id": "x"}`, header: bearerHeader(t, user.ID), expected: http.StatusBadRequest},
+		{name: "create chirp as text", method: http.MethodPost, target: "/api/chirps", body: "hi", header: http.Header{"Authorization": bearerHeader(t, user.ID)["Authorization"], "Content-Type": {"text/plain"}}, expected: http.StatusUnsupportedMediaType},
+		{name: "create chirp too large", method: http.MethodPost, target: "/api/chirps", body: `{"body": "` + strings.Repeat("a", maxChirpBodyBytes) + `"}`, header: bearerHeader(t, user.ID), expected: http.StatusRequestEntityTooLarge},
+		{name: "delete someone else's chirp", method: http.MethodDelete, target: "/api/chirps/" + otherChirp.ID.String(), header: bearerHeader(t, user.ID), expected: http.StatusForbidden},
+		{name: "delete chirp anonymously", method: http.MethodDelete, target: "/api/chirps/" + chirp.ID.String(), expected: http.StatusUnauthorized},
+		{name: "delete chirp", method: http.MethodDelete, target: "/api/chirps/" + chirp.ID.String(), header: bearerHeader(t, user.ID), expected: http.StatusNoContent},
+		{name: "delete missing chirp", method: http.MethodDelete, target: "/api/chirps/" + chirp.ID.String(), header: bearerHeader(t, user.ID), expected: http.StatusNotFound},
+		{name: "create user", method: http.MethodPost, target: "/api/users", body: `{"email": "saul@example.com", "password": "betterCall5"}`, expected: http.StatusCreated},
+		{name: "create user taken email", method: http.MethodPost, target: "/api/users", body: `{"email": "saul@example.com", "password": "betterCall5"}`, expected: http.StatusConflict},
+		{name: "create user with username", method: http.MethodPost, target: "/api/users", body: `{"email": "gus@example.com", "password": "betterCall5", "username": "gus_fring"}`, expected: http.StatusCreated},
+		{name: "create user taken username", method: http.MethodPost, target: "/api/users", body: `{"email": "gustavo@example.com", "password": "betterCall5", "username": "gus_fring"}`, expected: http.StatusConflict},
+		{name: "create user invalid username", method: http.MethodPost, target: "/api/users", body: `{"email": "gustavo@example.com", "password": "betterCall5", "username": "gus fring"}`, expected: http.StatusBadRequest},
+		{name: "create user invalid", method: http.MethodPost, target: "/api/users", body: `{"email": "saul", "password": "saul"}`, expected: http.StatusBadRequest},
+		{name: "update user", method: http.MethodPut, target: "/api/users", body: `{"email": "heisenberg@example.com", "password": "` + testPassword + `"}`, header: bearerHeader(t, user.ID), expected: http.StatusOK},
+		{name: "update user taken email", method: http.MethodPut, target: "/api/users", body: `{"email": "jesse@example.com", "password": "` + testPassword + `"}`, header: bearerHeader(t, user.ID), expected: http.StatusConflict},
+		{name: "update user anonymously", method: http.MethodPut, target: "/api/users", body: `{"email": "x@example.com", "password": "` + testPassword + `"}`, expected: http.StatusUnauthorized},
+		{name: "update profile", method: http.MethodPatch, target: "/api/users", body: `{"display_name": "Walter White", "bio": "chemistry teacher"}`, header: bearerHeader(t, user.ID), expected: http.StatusOK},
+		{name: "update profile taken username", method: http.MethodPatch, target: "/api/users", body: `{"username": "GUS_FRING"}`, header: bearerHeader(t, user.ID), expected: http.StatusConflict},
+		{name: "update profile reserved username", method: http.MethodPatch, target: "/api/users", body: `{"username": "admin"}`, header: bearerHeader(t, user.ID), expected: http.StatusBadRequest},
+		{name: "update profile anonymously", method: http.MethodPatch, target: "/api/users", body: `{"bio": "hi"}`, expected: http.StatusUnauthorized},
+		{name: "get profile", method: http.MethodGet, target: "/api/users/Gus_Fring", expected: http.StatusOK},
+		{name: "get missing profile", method: http.MethodGet, target: "/api/users/saul", expected: http.StatusNotFound},
+		{name: "login", method: http.MethodPost, target: "/api/login", body: `{"email": "heisenberg@example.com", "password": "` + testPassword + `"}`, expected: http.StatusOK},
+		{name: "login wrong password", method: http.MethodPost, target: "/api/login", body: `{"email": "heisenberg@example.com", "password": "wrong"}`, expected: http.StatusUnauthorized},
+		{name: "login missing password", method: http.MethodPost, target: "/api/login", body: `{"email": "heisenberg@example.com"}`, expected: http.StatusBadRequest},
+		{name: "refresh", method: http.MethodPost, target: "/api/refresh", header: http.Header{"Authorization": {"Bearer " + refreshToken}}, expected: http.StatusOK},
+		{name: "refresh unknown token", method: http.MethodPost, target: "/api/refresh", header: http.Header{"Authorization": {"Bearer unknown"}}, expected: http.StatusUnauthorized},
+		{name: "revoke", method: http.MethodPost, target: "/api/revoke", header: http.Header{"Authorization": {"Bearer " + refreshToken}}, expected: http.StatusNoContent},
+		{name: "revoke without token", method: http.MethodPost, target: "/api/revoke", expected: http.StatusUnauthorized},
+		{name: "webhook upgrade", method: http.MethodPost, target: "/api/polka/webhooks", body: `{"event": "user.upgraded", "data": {"user_id": "` + user.ID.String() + `"}}`, header: polkaHeader, expected: http.StatusNoContent},
+		{name: "webhook other event", method: http.MethodPost, target: "/api/polka/webhooks", body: `{"event": "user.payment_failed", "data": {"user_id": "` + user.ID.String() + `"}}`, header: polkaHeader, expected: http.StatusNoContent},
+		{name: "webhook unknown user", method: http.MethodPost, target: "/api/polka/webhooks", body: `{"event": "user.upgraded", "data": {"user_id": "` + uuid.NewString() + `"}}`, header: polkaHeader, expected: http.StatusNotFound},
+		{name: "webhook bad user", method: http.MethodPost, target: "/api/polka/webhooks", body: `{"event": "user.upgraded", "data": {"user_id": "walt"}}`, header: polkaHeader, expected: http.StatusBadRequest},
+		{name: "webhook wrong key", method: http.MethodPost, target: "/api/polka/webhooks", body: `{"event": "user.upgraded"}`, header: http.Header{"Authorization": {"ApiKey wrong"}}, expected: http.StatusUnauthorized},
+		{name: "list notifications", method: http.MethodGet, target: "/api/notifications", header: bearerHeader(t, user.ID), expected: http.StatusOK},
+		{name: "list notifications anonymously", method: http.MethodGet, target: "/api/notifications", expected: http.StatusUnauthorized},
+		{name: "mark missing notification read", method: http.MethodPost, target: "/api/notifications/" + uuid.NewString() + "/read", header: bearerHeader(t, user.ID), expected: http.StatusNotFound},
+		{name: "mark notification read bad id", method: http.MethodPost, target: "/api/notifications/walt/read", header: bearerHeader(t, user.ID), expected: http.StatusBadRequest},
+		{name: "mark all notifications read", method: http.MethodPost, target: "/api/notifications/read-all", header: bearerHeader(t, user.ID), expected: http.StatusOK},
+		{name: "liveness", method: http.MethodGet, target: "/api/livez", expected: http.StatusOK},
+		{name: "health", method: http.MethodGet, target: "/api/healthz", expected: http.StatusOK},
+		{name: "readiness", method: http.MethodGet, target: "/api/readyz", expected: http.StatusOK},
+		{name: "openapi", method: http.MethodGet, target: "/api/openapi.json", expected: http.StatusOK},
+		{name: "prometheus metrics", method: http.MethodGet, target: "/metrics", expected: http.StatusOK},
+		{name: "admin metrics", method: http.MethodGet, target: "/admin/metrics", expected: http.StatusOK},
+		{name: "admin users", method: http.MethodGet, target: "/admin/users", expected: http.StatusOK},
+		{name: "reset outside dev", method: http.MethodPost, target: "/admin/reset", expected: http.StatusForbidden},
+		{name: "app index", method: http.MethodGet, target: "/app/", expected: http.StatusOK},
+	}
+
+	for _, c := range cases {
+		w := doContractRequest(t, cfg, router, c.method, c.target, c.body, c.header)
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+		}
+	}
+
+	cfg.health.Drain()
+	w := doContractRequest(t, cfg, router, http.MethodGet, "/api/readyz", "", nil)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("draining readiness status mismatch --> %d != %d <--", w.Code, http.StatusServiceUnavailable)
+	}
+
+	cfg.platform = "dev"
+	w = doContractRequest(t, cfg, router, http.MethodPost, "/admin/reset", "", nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("reset status mismatch --> %d != %d <--", w.Code, http.StatusOK)
+	}
+}
+
+func TestContractRateLimited(t *testing.T) {
+	_, router := loadSpec(t)
+	cfg := newTestConfig(t)
+	cfg.rateLimitPolicies = rateLimitPolicies(map[string]config.RatePolicy{
+		config.RateLimitLogin: {Limit: 1, Window: time.Minute},
+	})
+
+	body := `{"email": "walt@example.com", "password": "` + testPassword + `"}`
+	doContractRequest(t, cfg, router, http.MethodPost, "/api/login", body, nil)
+
+	w := doContractRequest(t, cfg, router, http.MethodPost, "/api/login", body, nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("status mismatch --> %d != %d <--", w.Code, http.StatusTooManyRequests)
+	}
+}
+
+// TestSpecCoversRoutes keeps the spec in step with main.go: every pattern
+// registered on the mux has to be documented.
+func TestSpecCoversRoutes(t *testing.T) {
+	doc, _ := loadSpec(t)
+
+	patterns := registeredPatterns(t)
+	if len(patterns) == 0 {
+		t.Fatal("found no routes in main.go")
+	}
+
+	for _, pattern := range patterns {
+		method, path, found := strings.Cut(pattern, " ")
+		if !found {
+			method, path = http.MethodGet, pattern
+		}
+
+		item := doc.Paths.Find(path)
+		if item == nil || item.GetOperation(method) == nil {
+			t.Errorf("route missing from spec --> %s %s <--", method, path)
+		}
+	}
+}
+
+// registeredPatterns reads the string patterns passed to mux.Handle and
+// mux.HandleFunc in main.go.
+func registeredPatterns(t *testing.T) []string {
+	t.Helper()
+
+	file, err := parser.ParseFile(token.NewFileSet(), "main.go", nil, 0)
+	if err != nil {
+		t.Fatalf("couldn't parse main.go: %v", err)
+	}
+
+	var patterns []string
+	ast.Inspect(file, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+
+		selector, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || (selector.Sel.Name != "Handle" && selector.Sel.Name != "HandleFunc") {
+			return true
+		}
+
+		receiver, ok := selector.X.(*ast.Ident)
+		if !ok || receiver.Name != "mux" {
+			return true
+		}
+
+		literal, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || literal.Kind != token.STRING {
+			return true
+		}
+
+		pattern, err := strconv.Unquote(literal.Value)
+		if err != nil {
+			t.Fatalf("couldn't unquote pattern %s: %v", literal.Value, err)
+		}
+		patterns = append(patterns, pattern)
+
+		return true
+	})
+
+	return patterns
+}
@@ -1,14 +1,16 @@
 package main
 
 import (
-	"encoding/json"
-	"io"
+	"errors"
+	"fmt"
 	"net/http"
-	"os"
-	"strings"
 
 	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/internal/apperr"
 	"github.com/magicznykacpur/chirpy/internal/auth"
+	"github.com/magicznykacpur/chirpy/internal/database"
+	"github.com/magicznykacpur/chirpy/internal/metrics"
+	"github.com/magicznykacpur/chirpy/internal/validate"
 )
 
 type polkaRQ struct {
@@ -18,57 +20,77 @@ type polkaRQ struct {
 	} `json:"data"`
 }
 
+// validate only asks for a user on upgrades, other events are acknowledged
+// and ignored whatever they carry.
+func (rq polkaRQ) validate() error {
+	if rq.Event != "user.upgraded" {
+		return validate.Check(validate.Field("event", rq.Event, validate.Required()))
+	}
+
+	return validate.Check(
+		validate.Field("data.user_id", rq.Data.UserId, validate.Required(), validate.UUID()),
+	)
+}
+
 func (cfg *apiConfig) handlerUpgradeWebhook(w http.ResponseWriter, r *http.Request) {
 	apiKey, err := auth.GetApiKey(r.Header)
-	if err != nil || apiKey != os.Getenv("POLKA_KEY") {
-		writeError(err, "api key invalid", http.StatusUnauthorized, w)
+	if err != nil || apiKey != cfg.polkaKey {
+		cfg.metrics.Webhook(metrics.WebhookUnauthorized)
+		respondWithError(w, r, apperr.Unauthorized("api key invalid", err))
 		return
 	}
 
-	defer r.Body.Close()
-
-	requestBytes, err := io.ReadAll(r.Body)
+	var upgradeRQ polkaRQ
+	err = decodeJSON(w, r, maxWebhookBodyBytes, &upgradeRQ)
 	if err != nil {
-		writeError(err, "couldn't read request bytes", http.StatusInternalServerError, w)
+		cfg.metrics.Webhook(metrics.WebhookInvalid)
+		respondWithError(w, r, err)
 		return
 	}
 
-	var upgradeRQ polkaRQ
-	err = json.Unmarshal(requestBytes, &upgradeRQ)
+	err = upgradeRQ.validate()
 	if err != nil {
-		writeError(err, "couldn't unmarshal request", http.StatusBadRequest, w)
+		cfg.metrics.Webhook(metrics.WebhookInvalid)
+		respondWithError(w, r, err)
 		return
 	}
 
 	if upgradeRQ.Event != "user.upgraded" {
+		cfg.metrics.Webhook(metrics.WebhookIgnored)
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
 
 	if upgradeRQ.Event == "user.upgraded" {
-		userId, err := uuid.Parse(upgradeRQ.Data.UserId)
-		if err != nil {
-			writeError(err, "user id invalid", http.StatusBadRequest, w)
-			return
-		}
+		// already validated
+		userId := uuid.MustParse(upgradeRQ.Data.UserId)
 
 		user, err := cfg.db.GetUserById(r.Context(), userId)
-		if err != nil && strings.Contains(err.Error(), "sql: no rows in result set") {
-			writeError(nil, "user not found", http.StatusNotFound, w)
+		if errors.Is(err, apperr.ErrNotFound) {
+			cfg.metrics.Webhook(metrics.WebhookUnknownUser)
+			respondWithError(w, r, err)
 			return
 		}
 
-		if err != nil && !strings.Contains(err.Error(), "sql: no rows in result set") {
-			writeError(err, "cannot retrieve user", http.StatusInternalServerError, w)
+		if err != nil {
+			cfg.metrics.Webhook(metrics.WebhookFailed)
+			respondWithError(w, r, err)
 			return
 		}
 
 		err = cfg.db.UpdateIsChirpyRed(r.Context(), user.ID)
 		if err != nil {
-			writeError(err, "cannot update user", http.StatusInternalServerError, w)
+			cfg.metrics.Webhook(metrics.WebhookFailed)
+			respondWithError(w, r, fmt.Errorf("cannot update user: %w", err))
 			return
 		}
 
+		// polka retries webhooks, only the first upgrade is news
+		if !user.IsChirpyRed.Bool {
+			cfg.notify(r.Context(), database.CreateNotificationParams{UserID: user.ID, Kind: notificationChirpyRed})
+		}
+
+		cfg.metrics.Webhook(metrics.WebhookUpgraded)
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
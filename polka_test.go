@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestHandlerUpgradeWebhook(t *testing.T) {
+	cfg := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com")
+
+	apiKey := http.Header{"Authorization": {"ApiKey " + testPolkaKey}}
+
+	cases := []struct {
+		name     string
+		body     string
+		header   http.Header
+		expected int
+	}{
+		{
+			name:     "missing api key",
+			body:     `{"event": "user.upgraded", "data": {"user_id": "` + user.ID.String() + `"}}`,
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "wrong api key",
+			body:     `{"event": "user.upgraded", "data": {"user_id": "` + user.ID.String() + `"}}`,
+			header:   http.Header{"Authorization": {"ApiKey wrong"}},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "other event",
+			body:     `{"event": "user.payment_failed", "data": {"user_id": "` + user.ID.String() + `"}}`,
+			header:   apiKey,
+			expected: http.StatusNoContent,
+		},
+		{
+			name:     "malformed json",
+			body:     `{"event":`,
+			header:   apiKey,
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "malformed user id",
+			body:     `{"event": "user.upgraded", "data": {"user_id": "walt"}}`,
+			header:   apiKey,
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "unknown user",
+			body:     `{"event": "user.upgraded", "data": {"user_id": "` + uuid.NewString() + `"}}`,
+			header:   apiKey,
+			expected: http.StatusNotFound,
+		},
+		{
+			name:     "upgrade",
+			body:     `{"event": "user.upgraded", "data": {"user_id": "` + user.ID.String() + `"}}`,
+			header:   apiKey,
+			expected: http.StatusNoContent,
+		},
+	}
+
+	for _, c := range cases {
+		w := doRequest(t, cfg, http.MethodPost, "/api/polka/webhooks", c.body, c.header)
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+		}
+	}
+
+	upgraded, err := cfg.db.GetUserById(context.Background(), user.ID)
+	if err != nil {
+		t.Fatalf("couldn't get user: %v", err)
+	}
+
+	if !upgraded.IsChirpyRed.Bool {
+		t.Errorf("user should be upgraded to chirpy red")
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/magicznykacpur/chirpy/internal/apperr"
+	"github.com/magicznykacpur/chirpy/internal/auth"
+	"github.com/magicznykacpur/chirpy/internal/database"
+	"github.com/magicznykacpur/chirpy/internal/logging"
+	"github.com/magicznykacpur/chirpy/internal/validate"
+)
+
+// Profile field limits.
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+)
+
+// profileRQ updates the fields it carries, a left out field keeps its
+// value and an empty one clears it. The username can be changed but not
+// removed.
+type profileRQ struct {
+	Username    *string `json:"username"`
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+	AvatarUrl   *string `json:"avatar_url"`
+}
+
+func (rq profileRQ) validate() error {
+	var fields []validate.Spec
+	if rq.Username != nil {
+		fields = append(fields, validate.Field("username", *rq.Username, validate.Required(), validate.Username()))
+	}
+	if rq.DisplayName != nil {
+		fields = append(fields, validate.Field("display_name", *rq.DisplayName, validate.MaxLength(maxDisplayNameLength)))
+	}
+	if rq.Bio != nil {
+		fields = append(fields, validate.Field("bio", *rq.Bio, validate.MaxLength(maxBioLength)))
+	}
+	if rq.AvatarUrl != nil && *rq.AvatarUrl != "" {
+		fields = append(fields, validate.Field("avatar_url", *rq.AvatarUrl, validate.URL()))
+	}
+
+	return validate.Check(fields...)
+}
+
+func nullString(value *string) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *value, Valid: true}
+}
+
+// profileRes is the public side of a user, it never carries the email.
+// There is no follower count as users can't follow each other yet.
+type profileRes struct {
+	Id          string    `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Username    string    `json:"username"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	AvatarUrl   string    `json:"avatar_url"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+	ChirpCount  int64     `json:"chirp_count"`
+}
+
+func (cfg *apiConfig) profileRes(r *http.Request, user database.User) (profileRes, error) {
+	chirpCount, err := cfg.db.CountChirpsByUser(r.Context(), user.ID)
+	if err != nil {
+		return profileRes{}, fmt.Errorf("couldn't count chirps: %w", err)
+	}
+
+	return profileRes{
+		Id:          user.ID.String(),
+		CreatedAt:   user.CreatedAt,
+		Username:    user.Username.String,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		AvatarUrl:   user.AvatarUrl,
+		IsChirpyRed: user.IsChirpyRed.Bool,
+		ChirpCount:  chirpCount,
+	}, nil
+}
+
+func (cfg *apiConfig) handlerGetProfile(w http.ResponseWriter, r *http.Request) {
+	user, err := cfg.db.GetUserByUsername(r.Context(), r.PathValue("handle"))
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	if user.DisabledAt.Valid {
+		respondWithError(w, r, apperr.NotFound("user", nil))
+		return
+	}
+
+	response, err := cfg.profileRes(r, user)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	responseBytes, err := json.Marshal(response)
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't marshal response: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(responseBytes)
+}
+
+func (cfg *apiConfig) handlerUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, apperr.Unauthorized("couldn't get bearer token", err))
+		return
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, r, apperr.Unauthorized("token invalid", err))
+		return
+	}
+
+	logging.SetUserId(r.Context(), userId)
+
+	var profileRQ profileRQ
+	err = decodeJSON(w, r, maxUserBodyBytes, &profileRQ)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	err = profileRQ.validate()
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	err = cfg.db.UpdateUserProfile(r.Context(), database.UpdateUserProfileParams{
+		Username:    nullString(profileRQ.Username),
+		DisplayName: nullString(profileRQ.DisplayName),
+		Bio:         nullString(profileRQ.Bio),
+		AvatarUrl:   nullString(profileRQ.AvatarUrl),
+		ID:          userId,
+	})
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't update profile: %w", err))
+		return
+	}
+
+	user, err := cfg.db.GetUserById(r.Context(), userId)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	response, err := cfg.profileRes(r, user)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	responseBytes, err := json.Marshal(response)
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't marshal response: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(responseBytes)
+}
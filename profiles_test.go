@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestHandlerProfiles(t *testing.T) {
+	cfg := newTestConfig(t)
+	walt := createTestUser(t, cfg, "walt@example.com")
+	jesse := createTestUser(t, cfg, "jesse@example.com")
+	createTestChirp(t, cfg, walt.ID, "Say my name")
+
+	cases := []struct {
+		name     string
+		method   string
+		target   string
+		body     string
+		header   bool
+		expected int
+	}{
+		{name: "update anonymously", method: http.MethodPatch, target: "/api/users", body: `{"username": "Heisenberg"}`, expected: http.StatusUnauthorized},
+		{name: "update", method: http.MethodPatch, target: "/api/users", header: true, expected: http.StatusOK,
+			body: `{"username": "Heisenberg", "display_name": "Walter White", "bio": "chemistry teacher", "avatar_url": "https://example.com/walt.png"}`},
+		{name: "update bio only", method: http.MethodPatch, target: "/api/users", body: `{"bio": "I am the one who knocks"}`, header: true, expected: http.StatusOK},
+		{name: "reserved username", method: http.MethodPatch, target: "/api/users", body: `{"username": "ADMIN"}`, header: true, expected: http.StatusBadRequest},
+		{name: "empty username", method: http.MethodPatch, target: "/api/users", body: `{"username": ""}`, header: true, expected: http.StatusBadRequest},
+		{name: "bad avatar", method: http.MethodPatch, target: "/api/users", body: `{"avatar_url": "javascript:alert(1)"}`, header: true, expected: http.StatusBadRequest},
+		{name: "long bio", method: http.MethodPatch, target: "/api/users", body: `{"bio": "` + strings.Repeat("a", 161) + `"}`, header: true, expected: http.StatusBadRequest},
+		{name: "unknown field", method: http.MethodPatch, target: "/api/users", body: `{"email": "walt@example.com"}`, header: true, expected: http.StatusBadRequest},
+		{name: "get", method: http.MethodGet, target: "/api/users/heisenberg", expected: http.StatusOK},
+		{name: "get unknown", method: http.MethodGet, target: "/api/users/saul", expected: http.StatusNotFound},
+	}
+
+	for _, c := range cases {
+		var header http.Header
+		if c.header {
+			header = bearerHeader(t, walt.ID)
+		}
+		w := doRequest(t, cfg, c.method, c.target, c.body, header)
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+		}
+	}
+
+	w := doRequest(t, cfg, http.MethodGet, "/api/users/HEISENBERG", "", nil)
+	var profile profileRes
+	if err := json.Unmarshal(w.Body.Bytes(), &profile); err != nil {
+		t.Fatalf("couldn't unmarshal response: %v", err)
+	}
+	if profile.Id != walt.ID.String() || profile.Username != "Heisenberg" || profile.DisplayName != "Walter White" ||
+		profile.Bio != "I am the one who knocks" || profile.AvatarUrl != "https://example.com/walt.png" || profile.ChirpCount != 1 {
+		t.Errorf("profile mismatch --> %+v <--", profile)
+	}
+	if strings.Contains(w.Body.String(), "walt@example.com") {
+		t.Errorf("profile shouldn't carry the email --> %s <--", w.Body.String())
+	}
+
+	w = doRequest(t, cfg, http.MethodPatch, "/api/users", `{"username": "heisenBERG"}`, bearerHeader(t, jesse.ID))
+	if w.Code != http.StatusConflict {
+		t.Errorf("taken username status mismatch --> %d != %d <--", w.Code, http.StatusConflict)
+	}
+
+	err := cfg.db.DisableUser(context.Background(), walt.ID)
+	if err != nil {
+		t.Fatalf("couldn't disable user: %v", err)
+	}
+	w = doRequest(t, cfg, http.MethodGet, "/api/users/heisenberg", "", nil)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("disabled user status mismatch --> %d != %d <--", w.Code, http.StatusNotFound)
+	}
+}
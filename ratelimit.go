@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/magicznykacpur/chirpy/internal/apperr"
+	"github.com/magicznykacpur/chirpy/internal/auth"
+	"github.com/magicznykacpur/chirpy/internal/config"
+	"github.com/magicznykacpur/chirpy/internal/health"
+	"github.com/magicznykacpur/chirpy/internal/ratelimit"
+	"github.com/magicznykacpur/chirpy/internal/store"
+)
+
+// rateLimitPolicies turns the configured policies into limiter policies,
+// the policy name doubles as the bucket key prefix.
+func rateLimitPolicies(policies map[string]config.RatePolicy) map[string]ratelimit.Policy {
+	limits := map[string]ratelimit.Policy{}
+	for name, policy := range policies {
+		limits[name] = ratelimit.Policy{Name: name, Limit: policy.Limit, Window: policy.Window}
+	}
+	return limits
+}
+
+// newRateLimitStore opens the configured bucket store, the postgres one
+// shares the application database.
+func newRateLimitStore(kind string, db *sql.DB, dbUrl string) (ratelimit.Store, error) {
+	if kind != config.RateLimitStorePostgres {
+		return ratelimit.NewMemory(), nil
+	}
+
+	backend, err := store.Backend(dbUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	if backend != store.BackendPostgres {
+		return nil, fmt.Errorf("the postgres rate limit store needs a postgres DB_URL, got %s", backend)
+	}
+
+	return ratelimit.NewPostgres(db), nil
+}
+
+// middlewareRateLimit takes a token from the caller's bucket for the named
+// policy before calling next. Unknown or disabled policies let everything
+// through.
+func (cfg *apiConfig) middlewareRateLimit(name string, next http.HandlerFunc) http.Handler {
+	policy, ok := cfg.rateLimitPolicies[name]
+	if !ok || policy.Limit == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := policy.Name + ":" + cfg.rateLimitSubject(r)
+
+		result, err := cfg.rateLimits.Take(r.Context(), key, policy, time.Now())
+		if err != nil {
+			// fail open, a limiter outage shouldn't take the api down with it
+			slog.WarnContext(r.Context(), "couldn't check rate limit", "policy", policy.Name, "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(policy.Limit))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", seconds(result.Reset))
+		header.Set("RateLimit-Policy", strconv.Itoa(policy.Limit)+";w="+seconds(policy.Window))
+
+		if !result.Allowed {
+			cfg.metrics.RateLimited(policy.Name)
+			header.Set("Retry-After", seconds(result.RetryAfter))
+			respondWithError(w, r, apperr.RateLimited(result.RetryAfter))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitSubject keys authenticated requests by user, so users behind one
+// NAT don't share a budget, and everything else by client address.
+func (cfg *apiConfig) rateLimitSubject(r *http.Request) string {
+	token, err := auth.GetBearerToken(r.Header)
+	if err == nil {
+		userId, err := auth.ValidateJWT(token, cfg.jwtSecret)
+		if err == nil {
+			return "user:" + userId.String()
+		}
+	}
+
+	return "ip:" + ratelimit.ClientIP(r, cfg.trustedProxies)
+}
+
+// sweepRateLimits drops idle buckets every interval until ctx is done. A
+// bucket idle for longer than the longest window is full, forgetting it
+// changes nothing.
+func sweepRateLimits(ctx context.Context, store ratelimit.Store, policies map[string]ratelimit.Policy, interval time.Duration, worker *health.Worker) {
+	var longest time.Duration
+	for _, policy := range policies {
+		longest = max(longest, policy.Window)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := store.Sweep(ctx, time.Now().Add(-longest))
+		if err != nil {
+			slog.ErrorContext(ctx, "couldn't sweep rate limit buckets", "error", err)
+		}
+		worker.Report(err)
+	}
+}
+
+// seconds rounds d up to whole seconds, clients retrying early would only
+// be rejected again.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
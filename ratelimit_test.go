@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/magicznykacpur/chirpy/internal/config"
+	"github.com/magicznykacpur/chirpy/internal/ratelimit"
+)
+
+func TestMiddlewareRateLimit(t *testing.T) {
+	cfg := newTestConfig(t)
+	cfg.rateLimitPolicies = rateLimitPolicies(map[string]config.RatePolicy{
+		config.RateLimitCreateUser: {Limit: 2, Window: time.Minute},
+	})
+
+	for i, email := range []string{"walt@example.com", "jesse@example.com"} {
+		w := doRequest(t, cfg, http.MethodPost, "/api/users", `{"email": "`+email+`", "password": "`+testPassword+`"}`, nil)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("request %d: status mismatch --> %d != %d <--", i, w.Code, http.StatusCreated)
+		}
+
+		if w.Header().Get("RateLimit-Remaining") != []string{"1", "0"}[i] {
+			t.Errorf("request %d: remaining mismatch --> %s <--", i, w.Header().Get("RateLimit-Remaining"))
+		}
+	}
+
+	w := doRequest(t, cfg, http.MethodPost, "/api/users", `{"email": "skyler@example.com", "password": "`+testPassword+`"}`, nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status mismatch --> %d != %d <--", w.Code, http.StatusTooManyRequests)
+	}
+
+	expectedHeaders := map[string]string{
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "60",
+		"RateLimit-Policy":    "2;w=60",
+		"Retry-After":         "30",
+		"Content-Type":        "application/problem+json",
+	}
+	for header, expected := range expectedHeaders {
+		if w.Header().Get(header) != expected {
+			t.Errorf("%s mismatch --> %s != %s <--", header, w.Header().Get(header), expected)
+		}
+	}
+
+	var problem problemRes
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("couldn't unmarshal problem: %v", err)
+	}
+	if problem.Type != problemRateLimited {
+		t.Errorf("type mismatch --> %s != %s <--", problem.Type, problemRateLimited)
+	}
+
+	w = doRequest(t, cfg, http.MethodPost, "/api/login", `{"email": "walt@example.com", "password": "`+testPassword+`"}`, nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("routes without a policy shouldn't be limited --> %d != %d <--", w.Code, http.StatusOK)
+	}
+}
+
+func TestRateLimitSubject(t *testing.T) {
+	cfg := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com")
+
+	trusted, err := ratelimit.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("couldn't parse trusted proxies: %v", err)
+	}
+	cfg.trustedProxies = trusted
+
+	cases := []struct {
+		name     string
+		header   http.Header
+		expected string
+	}{
+		{name: "authenticated", header: bearerHeader(t, user.ID), expected: "user:" + user.ID.String()},
+		{name: "invalid token", header: http.Header{"Authorization": {"Bearer nonsense"}}, expected: "ip:198.51.100.9"},
+		{name: "anonymous", header: http.Header{}, expected: "ip:198.51.100.9"},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodPost, "/api/chirps", strings.NewReader(""))
+		r.RemoteAddr = "10.0.0.5:4000"
+		r.Header = c.header
+		r.Header.Set("X-Forwarded-For", "198.51.100.9")
+
+		subject := cfg.rateLimitSubject(r)
+		if subject != c.expected {
+			t.Errorf("%s: subject mismatch --> %s != %s <--", c.name, subject, c.expected)
+		}
+	}
+}
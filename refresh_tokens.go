@@ -3,10 +3,12 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
-	"os"
 	"time"
 
+	"github.com/magicznykacpur/chirpy/internal/apperr"
 	"github.com/magicznykacpur/chirpy/internal/auth"
 )
 
@@ -17,36 +19,41 @@ type tokenRes struct {
 func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		writeError(err, "couldn't get bearer token", http.StatusUnauthorized, w)
+		respondWithError(w, r, apperr.Unauthorized("couldn't get bearer token", err))
 		return
 	}
 
 	refreshToken, err := cfg.db.GetRefreshToken(r.Context(), token)
+	if errors.Is(err, apperr.ErrNotFound) {
+		respondWithError(w, r, apperr.Unauthorized("couldn't get refresh token", err))
+		return
+	}
+
 	if err != nil {
-		writeError(err, "couldn't get refresh token", http.StatusUnauthorized, w)
+		respondWithError(w, r, fmt.Errorf("couldn't get refresh token: %w", err))
 		return
 	}
 
 	if time.Until(refreshToken.ExpiresAt) < 0 {
-		writeError(err, "token expired", http.StatusUnauthorized, w)
+		respondWithError(w, r, apperr.Unauthorized("token expired", nil))
 		return
 	}
 
 	if refreshToken.RevokedAt != (sql.NullTime{}) {
-		writeError(err, "token revoked", http.StatusUnauthorized, w)
+		respondWithError(w, r, apperr.Unauthorized("token revoked", nil))
 		return
 	}
 
-	jwtToken, err := auth.MakeJWT(refreshToken.UserID, os.Getenv("JWT_SECRET"), time.Hour)
+	jwtToken, err := auth.MakeJWT(refreshToken.UserID, cfg.jwtSecret, cfg.accessTokenTTL)
 	if err != nil {
-		writeError(err, "couldn't create a jwt token", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't create a jwt token: %w", err))
 		return
 	}
 
 	response := tokenRes{Token: jwtToken}
 	responseBytes, err := json.Marshal(response)
 	if err != nil {
-		writeError(err, "couldn't marshal response", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't marshal response: %w", err))
 		return
 	}
 
@@ -58,13 +65,13 @@ func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		writeError(err, "couldn't get bearer token", http.StatusUnauthorized, w)
+		respondWithError(w, r, apperr.Unauthorized("couldn't get bearer token", err))
 		return
 	}
 
 	err = cfg.db.RevokeRefreshToken(r.Context(), token)
 	if err != nil {
-		writeError(err, "couldn't revoke refresh token", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't revoke refresh token: %w", err))
 		return
 	}
 
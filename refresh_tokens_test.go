@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/internal/auth"
+	"github.com/magicznykacpur/chirpy/internal/database"
+)
+
+func TestHandlerRefresh(t *testing.T) {
+	cfg := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com")
+
+	valid := createTestRefreshToken(t, cfg, user.ID, time.Hour)
+	expired := createTestRefreshToken(t, cfg, user.ID, -time.Hour)
+	revoked := createTestRefreshToken(t, cfg, user.ID, time.Hour)
+	if err := cfg.db.RevokeRefreshToken(context.Background(), revoked); err != nil {
+		t.Fatalf("couldn't revoke refresh token: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		header   http.Header
+		expected int
+	}{
+		{name: "valid token", header: http.Header{"Authorization": {"Bearer " + valid}}, expected: http.StatusOK},
+		{name: "expired token", header: http.Header{"Authorization": {"Bearer " + expired}}, expected: http.StatusUnauthorized},
+		{name: "revoked token", header: http.Header{"Authorization": {"Bearer " + revoked}}, expected: http.StatusUnauthorized},
+		{name: "unknown token", header: http.Header{"Authorization": {"Bearer unknown"}}, expected: http.StatusUnauthorized},
+		{name: "missing token", expected: http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		w := doRequest(t, cfg, http.MethodPost, "/api/refresh", "", c.header)
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+			continue
+		}
+
+		if c.expected != http.StatusOK {
+			continue
+		}
+
+		var response tokenRes
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: couldn't unmarshal response: %v", c.name, err)
+		}
+
+		userId, err := auth.ValidateJWT(response.Token, testJWTSecret)
+		if err != nil || userId != user.ID {
+			t.Errorf("%s: returned token invalid --> %s %v <--", c.name, userId, err)
+		}
+	}
+}
+
+func TestHandlerRevoke(t *testing.T) {
+	cfg := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com")
+	token := createTestRefreshToken(t, cfg, user.ID, time.Hour)
+
+	cases := []struct {
+		name     string
+		target   string
+		header   http.Header
+		expected int
+	}{
+		{name: "missing token", target: "/api/revoke", expected: http.StatusUnauthorized},
+		{name: "revoke", target: "/api/revoke", header: http.Header{"Authorization": {"Bearer " + token}}, expected: http.StatusNoContent},
+		{name: "refresh after revoke", target: "/api/refresh", header: http.Header{"Authorization": {"Bearer " + token}}, expected: http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		w := doRequest(t, cfg, http.MethodPost, c.target, "", c.header)
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+		}
+	}
+}
+
+func createTestRefreshToken(t *testing.T, cfg *apiConfig, userId uuid.UUID, expiresIn time.Duration) string {
+	t.Helper()
+
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		t.Fatalf("couldn't make refresh token: %v", err)
+	}
+
+	_, err = cfg.db.CreateRefreshToken(context.Background(),
+		database.CreateRefreshTokenParams{
+			Token:     token,
+			UserID:    userId,
+			ExpiresAt: time.Now().Add(expiresIn),
+		},
+	)
+	if err != nil {
+		t.Fatalf("couldn't create refresh token: %v", err)
+	}
+
+	return token
+}
@@ -1,15 +1,23 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
-	"os"
+
+	"github.com/magicznykacpur/chirpy/internal/apperr"
 )
 
 func (cfg *apiConfig) handlerReset(w http.ResponseWriter, r *http.Request) {
-	if os.Getenv("PLATFORM") == "dev" {
-		cfg.fileserverHits.Swap(0)
-		cfg.db.DeleteUsers(r.Context())
-	} else {
-		w.WriteHeader(http.StatusForbidden)
+	if cfg.platform != "dev" {
+		respondWithError(w, r, apperr.Forbidden("reset is only allowed on the dev platform"))
+		return
+	}
+
+	cfg.fileserverHits.Swap(0)
+
+	err := cfg.db.DeleteUsers(r.Context())
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't delete users: %w", err))
+		return
 	}
 }
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestHandlerReset(t *testing.T) {
+	cases := []struct {
+		platform      string
+		expected      int
+		expectedUsers int
+	}{
+		{platform: "dev", expected: http.StatusOK, expectedUsers: 0},
+		{platform: "prod", expected: http.StatusForbidden, expectedUsers: 1},
+		{platform: "", expected: http.StatusForbidden, expectedUsers: 1},
+	}
+
+	for _, c := range cases {
+		cfg := newTestConfig(t)
+		cfg.platform = c.platform
+
+		createTestUser(t, cfg, "walt@example.com")
+		cfg.fileserverHits.Add(5)
+
+		w := doRequest(t, cfg, http.MethodPost, "/admin/reset", "", nil)
+		if w.Code != c.expected {
+			t.Errorf("platform %q: status mismatch --> %d != %d <--", c.platform, w.Code, c.expected)
+		}
+
+		users, err := cfg.db.GetUsers(context.Background())
+		if err != nil {
+			t.Fatalf("couldn't get users: %v", err)
+		}
+
+		if len(users) != c.expectedUsers {
+			t.Errorf("platform %q: users mismatch --> %d != %d <--", c.platform, len(users), c.expectedUsers)
+		}
+	}
+}
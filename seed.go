@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/magicznykacpur/chirpy/internal/apperr"
+	"github.com/magicznykacpur/chirpy/internal/auth"
+	"github.com/magicznykacpur/chirpy/internal/config"
+	"github.com/magicznykacpur/chirpy/internal/database"
+	"github.com/magicznykacpur/chirpy/internal/store"
+)
+
+const seedUsage = "usage: chirpy seed [-users N] [-chirps N] [-seed N] [-password PASSWORD] [-force]"
+
+// defaultSeedPassword passes the password policy for every generated email.
+const defaultSeedPassword = "chirpy-seed-42"
+
+var (
+	seedFirstNames = []string{
+		"walter", "jesse", "skyler", "hank", "marie", "saul", "mike", "gus",
+		"lydia", "todd", "kim", "howard", "nacho", "tuco", "gale", "jane",
+	}
+	seedLastNames = []string{
+		"white", "pinkman", "schrader", "goodman", "ehrmantraut", "fring",
+		"wexler", "hamlin", "varga", "salamanca", "boetticher", "margolis",
+	}
+	seedDomains = []string{"example.com", "example.org", "example.net"}
+
+	seedOpenings = []string{
+		"Just", "Finally", "Honestly,", "Today I", "Can't believe I", "Note to self:", "Hot take:",
+	}
+	seedMiddles = []string{
+		"shipped the new feature", "fixed a bug that haunted me for weeks",
+		"drank way too much coffee", "read a great book about distributed systems",
+		"went for a long walk in the desert", "rewrote it all in Go",
+		"learned something new about SQL indexes", "watched the sunrise",
+	}
+	seedEndings = []string{
+		".", "!", " and it felt great.", ", what a day.", " #golang", " #buildinpublic", " :)",
+	}
+)
+
+// runSeed handles `chirpy seed`, it fills a dev database with fake users
+// and chirps. Every user gets the same password so they can log in.
+func runSeed(ctx context.Context, db store.Store, conf config.Config, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	users := flags.Int("users", 10, "number of users to create")
+	chirps := flags.Int("chirps", 5, "number of chirps per user")
+	seed := flags.Uint64("seed", 1, "random seed, the same seed creates the same data")
+	password := flags.String("password", defaultSeedPassword, "password of every seeded user")
+	force := flags.Bool("force", false, "seed even when PLATFORM is not dev")
+
+	err := flags.Parse(args)
+	if err != nil || flags.NArg() > 0 || *users < 0 || *chirps < 0 {
+		return usageError(seedUsage)
+	}
+
+	if conf.Platform != "dev" && !*force {
+		return fmt.Errorf("refusing to seed with PLATFORM %q, only dev databases are seeded unless -force is given", conf.Platform)
+	}
+
+	// one hash for everyone, bcrypt is slow on purpose
+	hashedPassword, err := auth.HashPassword(*password)
+	if err != nil {
+		return fmt.Errorf("couldn't hash password: %w", err)
+	}
+
+	random := rand.New(rand.NewPCG(*seed, *seed))
+
+	var createdUsers, createdChirps int
+	for range *users {
+		user, err := createSeedUser(ctx, db, random, hashedPassword)
+		if err != nil {
+			return err
+		}
+		createdUsers++
+
+		for range *chirps {
+			_, err := db.CreateChirp(ctx, database.CreateChirpParams{
+				Body:   seedChirpBody(random, conf.Limits.ChirpLength),
+				UserID: user.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("couldn't create chirp: %w", err)
+			}
+			createdChirps++
+		}
+	}
+
+	fmt.Fprintf(out, "created %d users and %d chirps, every user's password is %q\n", createdUsers, createdChirps, *password)
+	return nil
+}
+
+// createSeedUser creates a user with a random email, picking another one
+// when it is taken by an earlier run.
+func createSeedUser(ctx context.Context, db store.Store, random *rand.Rand, hashedPassword string) (database.User, error) {
+	const attempts = 10
+
+	for range attempts {
+		email := fmt.Sprintf("%s.%s%d@%s",
+			seedFirstNames[random.IntN(len(seedFirstNames))],
+			seedLastNames[random.IntN(len(seedLastNames))],
+			random.IntN(1000),
+			seedDomains[random.IntN(len(seedDomains))],
+		)
+
+		user, err := db.Createuser(ctx, database.CreateuserParams{Email: email, HashedPassword: hashedPassword})
+		if errors.Is(err, apperr.ErrConflict) {
+			continue
+		}
+		if err != nil {
+			return database.User{}, fmt.Errorf("couldn't create user: %w", err)
+		}
+
+		return user, nil
+	}
+
+	return database.User{}, fmt.Errorf("couldn't find a free email in %d attempts, try another -seed", attempts)
+}
+
+// seedChirpBody strings random phrases together, cut to maxLength runes.
+func seedChirpBody(random *rand.Rand, maxLength int) string {
+	body := strings.Join([]string{
+		seedOpenings[random.IntN(len(seedOpenings))],
+		seedMiddles[random.IntN(len(seedMiddles))],
+	}, " ") + seedEndings[random.IntN(len(seedEndings))]
+
+	if utf8.RuneCountInString(body) > maxLength {
+		body = string([]rune(body)[:maxLength])
+	}
+
+	return body
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/magicznykacpur/chirpy/internal/config"
+)
+
+func newServer(handler http.Handler, addr string, timeouts config.Server) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		Addr:              addr,
+		ReadHeaderTimeout: timeouts.ReadHeaderTimeout,
+		ReadTimeout:       timeouts.ReadTimeout,
+		WriteTimeout:      timeouts.WriteTimeout,
+		IdleTimeout:       timeouts.IdleTimeout,
+	}
+}
+
+// serve runs server until ctx is cancelled, then runs the shutdown sequence
+// with drainTimeout to finish. It returns an error if the listener fails or
+// shutdown doesn't complete in time.
+func serve(ctx context.Context, server *http.Server, drainTimeout time.Duration, shutdown *shutdownSequence) error {
+	listenErr := make(chan error, 1)
+	go func() {
+		slog.Info("starting server", "addr", server.Addr)
+		listenErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-listenErr:
+		shutdown.run(context.Background())
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down", "drain_timeout", drainTimeout.String())
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	err := shutdown.run(drainCtx)
+	if err != nil {
+		return err
+	}
+
+	err = <-listenErr
+	if !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("server failed: %w", err)
+	}
+
+	return nil
+}
+
+// readinessTimeout bounds each readiness check, probes usually give up
+// after a few seconds.
+const readinessTimeout = 2 * time.Second
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startWorker runs work in the background with a context of its own, so a
+// signal doesn't stop it before the server drained. The returned function
+// is its shutdown step, it cancels work and waits for it to return.
+func startWorker(work func(ctx context.Context)) func(context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		work(ctx)
+	}()
+
+	return func(stopCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-stopCtx.Done():
+			return stopCtx.Err()
+		}
+	}
+}
+
+type shutdownStep struct {
+	name string
+	stop func(context.Context) error
+}
+
+// shutdownSequence stops components one after another in the order they
+// were added.
+type shutdownSequence struct {
+	steps []shutdownStep
+}
+
+func (s *shutdownSequence) add(name string, stop func(context.Context) error) {
+	s.steps = append(s.steps, shutdownStep{name: name, stop: stop})
+}
+
+// run stops every component, even if an earlier one failed, and returns all
+// the errors joined.
+func (s *shutdownSequence) run(ctx context.Context) error {
+	var errs []error
+	for _, step := range s.steps {
+		err := step.stop(ctx)
+		if err != nil {
+			slog.Error("couldn't stop component", "component", step.name, "error", err)
+			errs = append(errs, fmt.Errorf("couldn't stop %s: %w", step.name, err))
+			continue
+		}
+		slog.Info("stopped component", "component", step.name)
+	}
+
+	return errors.Join(errs...)
+}
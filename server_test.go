@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/magicznykacpur/chirpy/internal/config"
+)
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	addr := freeAddr(t)
+	server := newServer(handler, addr, config.Server{})
+
+	stopped := []string{}
+	shutdown := shutdownSequence{}
+	shutdown.add("http server", server.Shutdown)
+	shutdown.add("database", func(context.Context) error {
+		stopped = append(stopped, "database")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- serve(ctx, server, 5*time.Second, &shutdown) }()
+
+	response := make(chan int, 1)
+	go func() {
+		for {
+			res, err := http.Get("http://" + addr)
+			if err == nil {
+				res.Body.Close()
+				response <- res.StatusCode
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	<-started
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	if status := <-response; status != http.StatusTeapot {
+		t.Errorf("in-flight request should complete --> %d <--", status)
+	}
+
+	if err := <-served; err != nil {
+		t.Errorf("graceful shutdown shouldn't fail: %v", err)
+	}
+
+	if len(stopped) != 1 {
+		t.Errorf("every component should be stopped --> %v <--", stopped)
+	}
+}
+
+func TestServeListenerFailure(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("couldn't listen: %v", err)
+	}
+	defer listener.Close()
+
+	server := newServer(http.NotFoundHandler(), listener.Addr().String(), config.Server{})
+	shutdown := shutdownSequence{}
+
+	err = serve(context.Background(), server, time.Second, &shutdown)
+	if err == nil {
+		t.Errorf("serve should fail when the address is taken")
+	}
+}
+
+func TestShutdownSequenceRunsEveryStep(t *testing.T) {
+	order := []string{}
+	shutdown := shutdownSequence{}
+
+	for _, name := range []string{"http server", "tracing", "database"} {
+		shutdown.add(name, func(context.Context) error {
+			order = append(order, name)
+			if name == "tracing" {
+				return errors.New("exporter unreachable")
+			}
+			return nil
+		})
+	}
+
+	err := shutdown.run(context.Background())
+	if err == nil {
+		t.Errorf("failed step should be reported")
+	}
+
+	expected := []string{"http server", "tracing", "database"}
+	if len(order) != len(expected) {
+		t.Fatalf("order mismatch --> %v != %v <--", order, expected)
+	}
+
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Errorf("order mismatch --> %v != %v <--", order, expected)
+		}
+	}
+}
+
+func TestStartWorker(t *testing.T) {
+	var stopped atomic.Bool
+	stop := startWorker(func(ctx context.Context) {
+		<-ctx.Done()
+		stopped.Store(true)
+	})
+
+	if stopped.Load() {
+		t.Fatalf("worker stopped before its shutdown step")
+	}
+
+	err := stop(context.Background())
+	if err != nil || !stopped.Load() {
+		t.Errorf("stop should wait for the worker to return --> %v %v <--", err, stopped.Load())
+	}
+
+	release := make(chan struct{})
+	defer close(release)
+	stop = startWorker(func(context.Context) { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = stop(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("stuck worker error mismatch --> %v != %v <--", err, context.DeadlineExceeded)
+	}
+}
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("couldn't listen: %v", err)
+	}
+	defer listener.Close()
+
+	return listener.Addr().String()
+}
@@ -0,0 +1,26 @@
+// Package sql embeds the goose migrations so the binary can apply them
+// without the sql directory being deployed next to it.
+package sql
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed schema/*.sql
+var postgres embed.FS
+
+//go:embed sqlite/schema/*.sql
+var sqlite embed.FS
+
+var PostgresMigrations = mustSub(postgres, "schema")
+
+var SQLiteMigrations = mustSub(sqlite, "sqlite/schema")
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
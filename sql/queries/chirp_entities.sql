@@ -0,0 +1,11 @@
+-- name: CreateChirpEntity :exec
+INSERT INTO chirp_entities(chirp_id, kind, start_offset, end_offset, value, user_id)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetChirpEntities :many
+SELECT * FROM chirp_entities WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]) ORDER BY chirp_id, start_offset;
+
+-- name: GetHashtagsCreatedBetween :many
+SELECT chirp_entities.value, chirps.created_at FROM chirp_entities
+JOIN chirps ON chirps.id = chirp_entities.chirp_id
+WHERE chirp_entities.kind = 'hashtag' AND chirps.created_at >= sqlc.arg('since') AND chirps.created_at < sqlc.arg('until');
@@ -15,8 +15,16 @@ SELECT * FROM chirps WHERE id = $1;
 -- name: GetChirpsByUser :many
 SELECT * FROM chirps WHERE user_id = $1 ORDER BY created_at;
 
+-- name: GetChirpsByHashtag :many
+SELECT * FROM chirps WHERE id IN (
+    SELECT chirp_id FROM chirp_entities WHERE kind = 'hashtag' AND value = $1
+) ORDER BY created_at;
+
 -- name: GetAllChirps :many
 SELECT * FROM chirps ORDER BY created_at;
 
 -- name: DeleteChirp :exec
-DELETE FROM chirps WHERE id = $1 and user_id = $2;
\ No newline at end of file
+DELETE FROM chirps WHERE id = $1 and user_id = $2;
+
+-- name: CountChirpsByUser :one
+SELECT COUNT(*) FROM chirps WHERE user_id = $1;
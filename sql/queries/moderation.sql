@@ -0,0 +1,21 @@
+-- name: CreateModerator :exec
+INSERT INTO moderators (user_id, created_at)
+VALUES ($1, NOW())
+ON CONFLICT (user_id) DO NOTHING;
+
+-- name: DeleteModerator :execrows
+DELETE FROM moderators WHERE user_id = $1;
+
+-- name: IsModerator :one
+SELECT EXISTS (SELECT 1 FROM moderators WHERE user_id = $1);
+
+-- name: ExcludeTrendingTag :exec
+INSERT INTO trending_excluded_tags (tag, created_at, excluded_by)
+VALUES ($1, NOW(), $2)
+ON CONFLICT (tag) DO NOTHING;
+
+-- name: IncludeTrendingTag :execrows
+DELETE FROM trending_excluded_tags WHERE tag = $1;
+
+-- name: GetExcludedTrendingTags :many
+SELECT * FROM trending_excluded_tags ORDER BY tag;
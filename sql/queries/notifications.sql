@@ -0,0 +1,24 @@
+-- name: CreateNotification :one
+INSERT INTO notifications(id, created_at, user_id, kind, actor_id, chirp_id, read_at)
+VALUES (
+    gen_random_uuid (),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    NULL
+)
+RETURNING *;
+
+-- name: ListNotifications :many
+SELECT * FROM notifications WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2;
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications SET read_at = COALESCE(read_at, NOW()) WHERE id = $1 AND user_id = $2;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL;
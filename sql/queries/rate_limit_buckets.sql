@@ -0,0 +1,14 @@
+-- name: TakeRateLimitToken :one
+INSERT INTO rate_limit_buckets(key, tokens, updated_at)
+VALUES (sqlc.arg(key), sqlc.arg(burst)::float8 - 1, sqlc.arg(now)::timestamp)
+ON CONFLICT (key) DO UPDATE
+SET tokens = LEAST(sqlc.arg(burst)::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM (sqlc.arg(now)::timestamp - rate_limit_buckets.updated_at))::float8 * sqlc.arg(rate)::float8) - 1,
+    updated_at = sqlc.arg(now)::timestamp
+WHERE LEAST(sqlc.arg(burst)::float8, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM (sqlc.arg(now)::timestamp - rate_limit_buckets.updated_at))::float8 * sqlc.arg(rate)::float8) >= 1
+RETURNING tokens;
+
+-- name: GetRateLimitBucket :one
+SELECT * FROM rate_limit_buckets WHERE key = $1;
+
+-- name: DeleteRateLimitBucketsBefore :exec
+DELETE FROM rate_limit_buckets WHERE updated_at < $1;
@@ -7,4 +7,10 @@ RETURNING *;
 SELECT * FROM refresh_tokens WHERE token = $1;
 
 -- name: RevokeRefreshToken :exec
-UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() WHERE token = $1;
\ No newline at end of file
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() WHERE token = $1;
+
+-- name: RevokeUserRefreshTokens :execrows
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: RevokeAllRefreshTokens :execrows
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() WHERE revoked_at IS NULL;
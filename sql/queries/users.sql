@@ -1,6 +1,6 @@
 -- name: Createuser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
-VALUES (gen_random_uuid (), NOW(), NOW(), $1, $2)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, username)
+VALUES (gen_random_uuid (), NOW(), NOW(), $1, $2, $3)
 RETURNING *;
 
 -- name: DeleteUsers :exec
@@ -15,8 +15,25 @@ SELECT * FROM users WHERE email = $1;
 -- name: GetUserById :one
 SELECT * FROM users WHERE id = $1;
 
+-- name: GetUserByUsername :one
+SELECT * FROM users WHERE lower(username) = lower(sqlc.arg('username'));
+
 -- name: UpdateUserEmailAndPassword :exec
-UPDATE users SET updated_at = NOW(), email = $1, hashed_password = $2 WHERE id = $3;
+UPDATE users SET updated_at = NOW(), email = sqlc.arg('email'), hashed_password = sqlc.arg('hashed_password'), username = COALESCE(sqlc.narg('username'), username) WHERE id = sqlc.arg('id');
+
+-- name: UpdateUserProfile :exec
+UPDATE users SET updated_at = NOW(),
+    username = COALESCE(sqlc.narg('username'), username),
+    display_name = COALESCE(sqlc.narg('display_name'), display_name),
+    bio = COALESCE(sqlc.narg('bio'), bio),
+    avatar_url = COALESCE(sqlc.narg('avatar_url'), avatar_url)
+WHERE id = sqlc.arg('id');
 
 -- name: UpdateIsChirpyRed :exec
-UPDATE users SET updated_at = NOW(), is_chirpy_red = TRUE WHERE id = $1;
\ No newline at end of file
+UPDATE users SET updated_at = NOW(), is_chirpy_red = TRUE WHERE id = $1;
+
+-- name: UpdateUserPassword :exec
+UPDATE users SET updated_at = NOW(), hashed_password = $1 WHERE id = $2;
+
+-- name: DisableUser :exec
+UPDATE users SET updated_at = NOW(), disabled_at = NOW() WHERE id = $1 AND disabled_at IS NULL;
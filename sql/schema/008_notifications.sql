@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE notifications(
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL,
+    kind TEXT NOT NULL,
+    actor_id UUID,
+    chirp_id UUID,
+    read_at TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (chirp_id) REFERENCES chirps (id) ON DELETE CASCADE
+);
+
+CREATE INDEX notifications_user_id_created_at ON notifications (user_id, created_at);
+
+-- +goose Down
+DROP TABLE notifications;
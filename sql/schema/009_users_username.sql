@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN username TEXT;
+
+CREATE UNIQUE INDEX users_username ON users (username);
+
+-- +goose Down
+DROP INDEX users_username;
+
+ALTER TABLE users DROP COLUMN username;
@@ -0,0 +1,11 @@
+-- name: CreateChirpEntity :exec
+INSERT INTO chirp_entities(chirp_id, kind, start_offset, end_offset, value, user_id)
+VALUES (?, ?, ?, ?, ?, ?);
+
+-- name: GetChirpEntities :many
+SELECT * FROM chirp_entities WHERE chirp_id IN (sqlc.slice('chirp_ids')) ORDER BY chirp_id, start_offset;
+
+-- name: GetHashtagsCreatedBetween :many
+SELECT chirp_entities.value, chirps.created_at FROM chirp_entities
+JOIN chirps ON chirps.id = chirp_entities.chirp_id
+WHERE chirp_entities.kind = 'hashtag' AND chirps.created_at >= strftime('%Y-%m-%d %H:%M:%f', sqlc.arg('since')) AND chirps.created_at < strftime('%Y-%m-%d %H:%M:%f', sqlc.arg('until'));
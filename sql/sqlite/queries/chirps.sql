@@ -0,0 +1,30 @@
+-- name: CreateChirp :one
+INSERT INTO chirps(id, created_at, updated_at, body, user_id)
+VALUES (
+    lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))),
+    strftime('%Y-%m-%d %H:%M:%f', 'now'),
+    strftime('%Y-%m-%d %H:%M:%f', 'now'),
+    ?,
+    ?
+)
+RETURNING *;
+
+-- name: GetChirp :one
+SELECT * FROM chirps WHERE id = ?;
+
+-- name: GetChirpsByUser :many
+SELECT * FROM chirps WHERE user_id = ? ORDER BY created_at;
+
+-- name: GetChirpsByHashtag :many
+SELECT * FROM chirps WHERE id IN (
+    SELECT chirp_id FROM chirp_entities WHERE kind = 'hashtag' AND value = ?
+) ORDER BY created_at;
+
+-- name: GetAllChirps :many
+SELECT * FROM chirps ORDER BY created_at;
+
+-- name: DeleteChirp :exec
+DELETE FROM chirps WHERE id = ? and user_id = ?;
+
+-- name: CountChirpsByUser :one
+SELECT COUNT(*) FROM chirps WHERE user_id = ?;
@@ -0,0 +1,24 @@
+-- name: CreateNotification :one
+INSERT INTO notifications(id, created_at, user_id, kind, actor_id, chirp_id, read_at)
+VALUES (
+    lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))),
+    strftime('%Y-%m-%d %H:%M:%f', 'now'),
+    ?,
+    ?,
+    ?,
+    ?,
+    NULL
+)
+RETURNING *;
+
+-- name: ListNotifications :many
+SELECT * FROM notifications WHERE user_id = ? ORDER BY created_at DESC LIMIT ?;
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL;
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications SET read_at = COALESCE(read_at, strftime('%Y-%m-%d %H:%M:%f', 'now')) WHERE id = ? AND user_id = ?;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications SET read_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE user_id = ? AND read_at IS NULL;
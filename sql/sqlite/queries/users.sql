@@ -0,0 +1,46 @@
+-- name: Createuser :one
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, username)
+VALUES (
+    lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))),
+    strftime('%Y-%m-%d %H:%M:%f', 'now'),
+    strftime('%Y-%m-%d %H:%M:%f', 'now'),
+    ?,
+    ?,
+    ?
+)
+RETURNING *;
+
+-- name: DeleteUsers :exec
+DELETE FROM users;
+
+-- name: GetUsers :many
+SELECT * FROM users;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users WHERE email = ?;
+
+-- name: GetUserById :one
+SELECT * FROM users WHERE id = ?;
+
+-- name: GetUserByUsername :one
+SELECT * FROM users WHERE lower(username) = lower(sqlc.arg('username'));
+
+-- name: UpdateUserEmailAndPassword :exec
+UPDATE users SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now'), email = sqlc.arg('email'), hashed_password = sqlc.arg('hashed_password'), username = COALESCE(sqlc.narg('username'), username) WHERE id = sqlc.arg('id');
+
+-- name: UpdateUserProfile :exec
+UPDATE users SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now'),
+    username = COALESCE(sqlc.narg('username'), username),
+    display_name = COALESCE(sqlc.narg('display_name'), display_name),
+    bio = COALESCE(sqlc.narg('bio'), bio),
+    avatar_url = COALESCE(sqlc.narg('avatar_url'), avatar_url)
+WHERE id = sqlc.arg('id');
+
+-- name: UpdateIsChirpyRed :exec
+UPDATE users SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now'), is_chirpy_red = TRUE WHERE id = ?;
+
+-- name: UpdateUserPassword :exec
+UPDATE users SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now'), hashed_password = ? WHERE id = ?;
+
+-- name: DisableUser :exec
+UPDATE users SET updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now'), disabled_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE id = ? AND disabled_at IS NULL;
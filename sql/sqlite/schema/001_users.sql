@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE users(
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    email TEXT UNIQUE NOT NULL
+);
+
+-- +goose Down
+DROP TABLE users;
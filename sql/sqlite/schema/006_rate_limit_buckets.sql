@@ -0,0 +1,8 @@
+-- rate limit buckets only live in postgres, the sqlite store keeps them in
+-- memory, this keeps the version numbers in step with sql/schema
+
+-- +goose Up
+SELECT 1;
+
+-- +goose Down
+SELECT 1;
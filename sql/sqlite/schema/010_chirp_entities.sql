@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE chirp_entities(
+    chirp_id TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    start_offset INTEGER NOT NULL,
+    end_offset INTEGER NOT NULL,
+    value TEXT NOT NULL,
+    user_id TEXT,
+    PRIMARY KEY (chirp_id, start_offset),
+    FOREIGN KEY (chirp_id) REFERENCES chirps (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX chirp_entities_kind_value ON chirp_entities (kind, value);
+
+-- +goose Down
+DROP TABLE chirp_entities;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE moderators(
+    user_id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE trending_excluded_tags(
+    tag TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    excluded_by TEXT,
+    FOREIGN KEY (excluded_by) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX chirps_created_at ON chirps (created_at);
+
+-- +goose Down
+DROP INDEX chirps_created_at;
+DROP TABLE trending_excluded_tags;
+DROP TABLE moderators;
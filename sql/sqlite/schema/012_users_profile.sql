@@ -0,0 +1,32 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN display_name TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE users ADD COLUMN bio TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE users ADD COLUMN avatar_url TEXT NOT NULL DEFAULT '';
+
+-- handles differing only in case would look like the same user. The oldest
+-- account keeps its handle, the others get a numbered suffix that still fits
+-- the 15 character limit.
+UPDATE users SET username = substr(users.username, 1, 14 - length(CAST(duplicates.n AS TEXT))) || '_' || CAST(duplicates.n AS TEXT)
+FROM (
+    SELECT id, row_number() OVER (PARTITION BY lower(username) ORDER BY created_at, id) AS n
+    FROM users
+    WHERE username IS NOT NULL
+) AS duplicates
+WHERE users.id = duplicates.id AND duplicates.n > 1;
+
+DROP INDEX users_username;
+
+CREATE UNIQUE INDEX users_username ON users (lower(username));
+
+-- +goose Down
+DROP INDEX users_username;
+
+CREATE UNIQUE INDEX users_username ON users (username);
+
+ALTER TABLE users DROP COLUMN avatar_url;
+
+ALTER TABLE users DROP COLUMN bio;
+
+ALTER TABLE users DROP COLUMN display_name;
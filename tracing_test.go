@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/magicznykacpur/chirpy/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing(t *testing.T) {
+	exporter, shutdown := tracing.SetupInMemory()
+	defer shutdown(context.Background())
+
+	cfg := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com")
+	exporter.Reset()
+
+	const traceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+	header := bearerHeader(t, user.ID)
+	header.Set("traceparent", "00-"+traceId+"-00f067aa0ba902b7-01")
+
+	w := doRequest(t, cfg, http.MethodPost, "/api/chirps", `{"body": "hello chirpy"}`, header)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status mismatch --> %d != %d <--", w.Code, http.StatusCreated)
+	}
+
+	cases := []struct {
+		span       string
+		attributes map[attribute.Key]string
+	}{
+		{
+			span: "POST /api/chirps",
+			attributes: map[attribute.Key]string{
+				"http.route":      "POST /api/chirps",
+				tracing.UserIdKey: user.ID.String(),
+			},
+		},
+		{
+			span: "CreateChirp",
+			attributes: map[attribute.Key]string{
+				"db.operation.name": "CreateChirp",
+				"db.system":         "memory",
+				tracing.UserIdKey:   user.ID.String(),
+			},
+		},
+	}
+
+	spans := exporter.GetSpans()
+	for _, c := range cases {
+		span := findSpan(spans, c.span)
+		if span == nil {
+			t.Errorf("span %s not recorded", c.span)
+			continue
+		}
+
+		if span.SpanContext.TraceID().String() != traceId {
+			t.Errorf("%s: trace id should come from traceparent --> %s != %s <--", c.span, span.SpanContext.TraceID(), traceId)
+		}
+
+		attributes := map[attribute.Key]string{}
+		for _, kv := range span.Attributes {
+			attributes[kv.Key] = kv.Value.Emit()
+		}
+
+		for key, value := range c.attributes {
+			if attributes[key] != value {
+				t.Errorf("%s: attribute %s mismatch --> %s != %s <--", c.span, key, attributes[key], value)
+			}
+		}
+	}
+}
+
+func findSpan(spans tracetest.SpanStubs, name string) *tracetest.SpanStub {
+	for i := range spans {
+		if spans[i].Name == name {
+			return &spans[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/magicznykacpur/chirpy/internal/apperr"
+	"github.com/magicznykacpur/chirpy/internal/auth"
+	"github.com/magicznykacpur/chirpy/internal/database"
+	"github.com/magicznykacpur/chirpy/internal/entities"
+	"github.com/magicznykacpur/chirpy/internal/health"
+	"github.com/magicznykacpur/chirpy/internal/logging"
+	"github.com/magicznykacpur/chirpy/internal/store"
+	"github.com/magicznykacpur/chirpy/internal/trending"
+)
+
+const (
+	// trendingInterval is how often new hashtags are counted and the tags
+	// ranked again
+	trendingInterval = time.Minute
+	// trendingSettle leaves the chirps of the last seconds to the next run,
+	// created_at is set before their inserts commit
+	trendingSettle = 5 * time.Second
+	// trendingLimit is how many tags each window lists
+	trendingLimit = 10
+)
+
+type trendingTagRes struct {
+	Tag   string  `json:"tag"`
+	Count int     `json:"count"`
+	Score float64 `json:"score"`
+}
+
+type trendingWindowRes struct {
+	Window string           `json:"window"`
+	Tags   []trendingTagRes `json:"tags"`
+}
+
+type trendingRes struct {
+	RankedAt *time.Time          `json:"ranked_at,omitempty"`
+	Windows  []trendingWindowRes `json:"windows"`
+}
+
+type excludedTagRes struct {
+	Tag        string    `json:"tag"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExcludedBy string    `json:"excluded_by,omitempty"`
+}
+
+// trendingJob feeds the hashtags of new chirps to the tracker, every run
+// only reads the chirps created since the previous one.
+type trendingJob struct {
+	db      store.Store
+	tracker *trending.Tracker
+	since   time.Time
+}
+
+// newTrendingJob starts counting from the oldest hashtags the tracker
+// keeps.
+func newTrendingJob(db store.Store, tracker *trending.Tracker, now time.Time) *trendingJob {
+	return &trendingJob{db: db, tracker: tracker, since: now.Add(-trending.Retention)}
+}
+
+func (j *trendingJob) run(ctx context.Context, now time.Time) error {
+	until := now.Add(-trendingSettle)
+	if until.After(j.since) {
+		hashtags, err := j.db.GetHashtagsCreatedBetween(ctx, database.GetHashtagsCreatedBetweenParams{
+			Since: j.since,
+			Until: until,
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't load new hashtags: %w", err)
+		}
+
+		for _, hashtag := range hashtags {
+			j.tracker.Add(hashtag.Value, hashtag.CreatedAt)
+		}
+		j.since = until
+	}
+
+	// exclusions made on other instances show up here
+	return loadExcludedTags(ctx, j.db, j.tracker, now)
+}
+
+// loadExcludedTags hands the excluded tags to tracker and ranks again so
+// they leave the rankings right away.
+func loadExcludedTags(ctx context.Context, db store.Store, tracker *trending.Tracker, now time.Time) error {
+	excluded, err := db.GetExcludedTrendingTags(ctx)
+	if err != nil {
+		return fmt.Errorf("couldn't load excluded tags: %w", err)
+	}
+
+	tags := make([]string, 0, len(excluded))
+	for _, tag := range excluded {
+		tags = append(tags, tag.Tag)
+	}
+	tracker.Exclude(tags)
+	tracker.Rank(now)
+
+	return nil
+}
+
+// runTrending runs job right away and then every interval until ctx is
+// done. Chirps deleted after they were counted keep counting until they age
+// out of the windows.
+func runTrending(ctx context.Context, job *trendingJob, interval time.Duration, worker *health.Worker) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		err := job.run(ctx, time.Now())
+		if err != nil {
+			slog.ErrorContext(ctx, "couldn't rank trending tags", "error", err)
+		}
+		worker.Report(err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (cfg *apiConfig) handlerGetTrending(w http.ResponseWriter, r *http.Request) {
+	snapshot := cfg.trending.Snapshot()
+
+	response := trendingRes{Windows: []trendingWindowRes{}}
+	if !snapshot.RankedAt.IsZero() {
+		response.RankedAt = &snapshot.RankedAt
+	}
+
+	for _, ranking := range snapshot.Rankings {
+		window := trendingWindowRes{Window: ranking.Window, Tags: []trendingTagRes{}}
+		for _, tag := range ranking.Tags {
+			window.Tags = append(window.Tags, trendingTagRes{Tag: tag.Tag, Count: tag.Count, Score: tag.Score})
+		}
+		response.Windows = append(response.Windows, window)
+	}
+
+	responseBytes, err := json.Marshal(response)
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't marshal response: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(responseBytes)
+}
+
+// authorizeModerator returns the id of the user behind the request's bearer
+// token when they are a moderator.
+func (cfg *apiConfig) authorizeModerator(r *http.Request) (uuid.UUID, error) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.Nil, apperr.Unauthorized("couldn't get bearer token", err)
+	}
+
+	userId, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		return uuid.Nil, apperr.Unauthorized("token invalid", err)
+	}
+
+	logging.SetUserId(r.Context(), userId)
+
+	isModerator, err := cfg.db.IsModerator(r.Context(), userId)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("couldn't check moderator: %w", err)
+	}
+
+	if !isModerator {
+		return uuid.Nil, apperr.Forbidden("only moderators can do that")
+	}
+
+	return userId, nil
+}
+
+func (cfg *apiConfig) handlerGetExcludedTags(w http.ResponseWriter, r *http.Request) {
+	_, err := cfg.authorizeModerator(r)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	excluded, err := cfg.db.GetExcludedTrendingTags(r.Context())
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't retrieve excluded tags: %w", err))
+		return
+	}
+
+	response := []excludedTagRes{}
+	for _, tag := range excluded {
+		res := excludedTagRes{Tag: tag.Tag, CreatedAt: tag.CreatedAt}
+		if tag.ExcludedBy.Valid {
+			res.ExcludedBy = tag.ExcludedBy.UUID.String()
+		}
+		response = append(response, res)
+	}
+
+	responseBytes, err := json.Marshal(response)
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't marshal response: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(responseBytes)
+}
+
+func (cfg *apiConfig) handlerExcludeTag(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authorizeModerator(r)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	tag, ok := entities.NormalizeHashtag(r.PathValue("tag"))
+	if !ok {
+		respondWithError(w, r, apperr.InvalidField("tag", "must be a hashtag like chirpy"))
+		return
+	}
+
+	err = cfg.db.ExcludeTrendingTag(r.Context(), database.ExcludeTrendingTagParams{
+		Tag:        tag,
+		ExcludedBy: uuid.NullUUID{UUID: userId, Valid: true},
+	})
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't exclude tag: %w", err))
+		return
+	}
+
+	cfg.reloadExcludedTags(r.Context())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerIncludeTag(w http.ResponseWriter, r *http.Request) {
+	_, err := cfg.authorizeModerator(r)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	tag, ok := entities.NormalizeHashtag(r.PathValue("tag"))
+	if !ok {
+		respondWithError(w, r, apperr.InvalidField("tag", "must be a hashtag like chirpy"))
+		return
+	}
+
+	included, err := cfg.db.IncludeTrendingTag(r.Context(), tag)
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't include tag: %w", err))
+		return
+	}
+
+	if included == 0 {
+		respondWithError(w, r, apperr.NotFound("excluded tag", nil))
+		return
+	}
+
+	cfg.reloadExcludedTags(r.Context())
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reloadExcludedTags applies a moderator's change to this instance's
+// rankings right away, the change is stored so failing here only delays it
+// until the next run of the trending job.
+func (cfg *apiConfig) reloadExcludedTags(ctx context.Context) {
+	err := loadExcludedTags(ctx, cfg.db, cfg.trending, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "couldn't reload excluded tags", "error", err)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/magicznykacpur/chirpy/internal/database"
+)
+
+func TestTrendingJob(t *testing.T) {
+	cfg := newTestConfig(t)
+	ctx := context.Background()
+	user := createTestUser(t, cfg, "walt@example.com")
+
+	createTagged := func(body string) {
+		t.Helper()
+		w := doRequest(t, cfg, http.MethodPost, "/api/chirps", `{"body": "`+body+`"}`, bearerHeader(t, user.ID))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create chirp status mismatch --> %d != %d <--", w.Code, http.StatusCreated)
+		}
+	}
+
+	createTagged("#Science rocks")
+	createTagged("more #science")
+
+	job := newTrendingJob(cfg.db, cfg.trending, time.Now())
+	err := job.run(ctx, time.Now().Add(trendingSettle))
+	if err != nil {
+		t.Fatalf("couldn't run trending job: %v", err)
+	}
+
+	// counted chirps are not read again
+	createTagged("#chemistry")
+	err = job.run(ctx, time.Now().Add(trendingSettle))
+	if err != nil {
+		t.Fatalf("couldn't run trending job again: %v", err)
+	}
+
+	hour := cfg.trending.Snapshot().Rankings[0]
+	if len(hour.Tags) != 2 || hour.Tags[0].Tag != "science" || hour.Tags[0].Count != 2 || hour.Tags[1].Tag != "chemistry" {
+		t.Errorf("ranking mismatch --> %+v <--", hour.Tags)
+	}
+
+	err = cfg.db.ExcludeTrendingTag(ctx, database.ExcludeTrendingTagParams{Tag: "science"})
+	if err != nil {
+		t.Fatalf("couldn't exclude tag: %v", err)
+	}
+	err = job.run(ctx, time.Now().Add(trendingSettle))
+	if err != nil {
+		t.Fatalf("couldn't run trending job: %v", err)
+	}
+
+	hour = cfg.trending.Snapshot().Rankings[0]
+	if len(hour.Tags) != 1 || hour.Tags[0].Tag != "chemistry" {
+		t.Errorf("ranking without excluded tags mismatch --> %+v <--", hour.Tags)
+	}
+}
+
+func TestHandlerTrending(t *testing.T) {
+	cfg := newTestConfig(t)
+	ctx := context.Background()
+	moderator := createTestUser(t, cfg, "walt@example.com")
+	user := createTestUser(t, cfg, "jesse@example.com")
+
+	err := cfg.db.CreateModerator(ctx, moderator.ID)
+	if err != nil {
+		t.Fatalf("couldn't create moderator: %v", err)
+	}
+
+	w := doRequest(t, cfg, http.MethodGet, "/api/trending", "", nil)
+	var before trendingRes
+	if err := json.Unmarshal(w.Body.Bytes(), &before); err != nil {
+		t.Fatalf("couldn't unmarshal response: %v", err)
+	}
+	if w.Code != http.StatusOK || before.RankedAt != nil || len(before.Windows) != 2 || before.Windows[0].Tags == nil {
+		t.Errorf("trending before the first run mismatch --> %d %s <--", w.Code, w.Body.String())
+	}
+
+	for _, body := range []string{"#blue crystal", "#Blue again", "#ads"} {
+		w := doRequest(t, cfg, http.MethodPost, "/api/chirps", `{"body": "`+body+`"}`, bearerHeader(t, user.ID))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create chirp status mismatch --> %d != %d <--", w.Code, http.StatusCreated)
+		}
+	}
+
+	err = newTrendingJob(cfg.db, cfg.trending, time.Now()).run(ctx, time.Now().Add(trendingSettle))
+	if err != nil {
+		t.Fatalf("couldn't run trending job: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		method   string
+		target   string
+		header   http.Header
+		expected int
+		tags     []string
+	}{
+		{name: "trending", method: http.MethodGet, target: "/api/trending", expected: http.StatusOK, tags: []string{"blue", "ads"}},
+		{name: "exclude anonymously", method: http.MethodPut, target: "/api/trending/excluded/ads", expected: http.StatusUnauthorized},
+		{name: "exclude as a user", method: http.MethodPut, target: "/api/trending/excluded/ads", header: bearerHeader(t, user.ID), expected: http.StatusForbidden},
+		{name: "exclude bad tag", method: http.MethodPut, target: "/api/trending/excluded/2008", header: bearerHeader(t, moderator.ID), expected: http.StatusBadRequest},
+		{name: "exclude", method: http.MethodPut, target: "/api/trending/excluded/%23ADS", header: bearerHeader(t, moderator.ID), expected: http.StatusNoContent},
+		{name: "exclude again", method: http.MethodPut, target: "/api/trending/excluded/ads", header: bearerHeader(t, moderator.ID), expected: http.StatusNoContent},
+		{name: "trending without excluded", method: http.MethodGet, target: "/api/trending", expected: http.StatusOK, tags: []string{"blue"}},
+		{name: "list excluded as a user", method: http.MethodGet, target: "/api/trending/excluded", header: bearerHeader(t, user.ID), expected: http.StatusForbidden},
+		{name: "list excluded", method: http.MethodGet, target: "/api/trending/excluded", header: bearerHeader(t, moderator.ID), expected: http.StatusOK, tags: []string{"ads"}},
+		{name: "include", method: http.MethodDelete, target: "/api/trending/excluded/ads", header: bearerHeader(t, moderator.ID), expected: http.StatusNoContent},
+		{name: "include not excluded", method: http.MethodDelete, target: "/api/trending/excluded/ads", header: bearerHeader(t, moderator.ID), expected: http.StatusNotFound},
+		{name: "trending after include", method: http.MethodGet, target: "/api/trending", expected: http.StatusOK, tags: []string{"blue", "ads"}},
+	}
+
+	for _, c := range cases {
+		w := doRequest(t, cfg, c.method, c.target, "", c.header)
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+			continue
+		}
+
+		if c.tags == nil {
+			continue
+		}
+
+		var tags []string
+		if c.target == "/api/trending" {
+			var response trendingRes
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("%s: couldn't unmarshal response: %v", c.name, err)
+			}
+			if response.RankedAt == nil || response.Windows[0].Window != "1h" {
+				t.Errorf("%s: trending mismatch --> %s <--", c.name, w.Body.String())
+			}
+			for _, tag := range response.Windows[0].Tags {
+				tags = append(tags, tag.Tag)
+			}
+		} else {
+			var response []excludedTagRes
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("%s: couldn't unmarshal response: %v", c.name, err)
+			}
+			for _, tag := range response {
+				tags = append(tags, tag.Tag)
+				if tag.ExcludedBy != moderator.ID.String() {
+					t.Errorf("%s: excluded by mismatch --> %s != %s <--", c.name, tag.ExcludedBy, moderator.ID)
+				}
+			}
+		}
+
+		if len(tags) != len(c.tags) {
+			t.Errorf("%s: tags mismatch --> %v != %v <--", c.name, tags, c.tags)
+			continue
+		}
+		for i := range tags {
+			if tags[i] != c.tags[i] {
+				t.Errorf("%s: tags mismatch --> %v != %v <--", c.name, tags, c.tags)
+				break
+			}
+		}
+	}
+}
@@ -1,19 +1,53 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
-	"io"
+	"errors"
+	"fmt"
 	"net/http"
-	"os"
 	"time"
 
+	"github.com/magicznykacpur/chirpy/internal/apperr"
 	"github.com/magicznykacpur/chirpy/internal/auth"
 	"github.com/magicznykacpur/chirpy/internal/database"
+	"github.com/magicznykacpur/chirpy/internal/logging"
+	"github.com/magicznykacpur/chirpy/internal/metrics"
+	"github.com/magicznykacpur/chirpy/internal/validate"
 )
 
 type userRQ struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Username string `json:"username,omitempty"`
+}
+
+// validate checks the credentials of a new or updated account against the
+// password policy. The username is optional, leaving it out keeps the
+// current one.
+func (rq userRQ) validate() error {
+	fields := []validate.Spec{
+		validate.Field("email", rq.Email, validate.Required(), validate.Email()),
+		validate.Field("password", rq.Password, validate.Required(), validate.Password(rq.Email)),
+	}
+	if rq.Username != "" {
+		fields = append(fields, validate.Field("username", rq.Username, validate.Username()))
+	}
+
+	return validate.Check(fields...)
+}
+
+func (rq userRQ) username() sql.NullString {
+	return sql.NullString{String: rq.Username, Valid: rq.Username != ""}
+}
+
+// validateLogin only requires the credentials, accounts created before the
+// password policy must still be able to log in.
+func (rq userRQ) validateLogin() error {
+	return validate.Check(
+		validate.Field("email", rq.Email, validate.Required()),
+		validate.Field("password", rq.Password, validate.Required()),
+	)
 }
 
 type userRes struct {
@@ -21,46 +55,39 @@ type userRes struct {
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 	Email        string    `json:"email"`
+	Username     string    `json:"username,omitempty"`
 	IsChirpyRed  bool      `json:"is_chirpy_red"`
 	Token        string    `json:"token,omitempty"`
 	RefreshToken string    `json:"refresh_token,omitempty"`
 }
 
 func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request) {
-	defer r.Body.Close()
-
-	requestBytes, err := io.ReadAll(r.Body)
-	if err != nil {
-		writeError(err, "couldn't read request bytes", http.StatusInternalServerError, w)
-		return
-	}
-
 	var userRQ userRQ
-	err = json.Unmarshal(requestBytes, &userRQ)
+	err := decodeJSON(w, r, maxUserBodyBytes, &userRQ)
 	if err != nil {
-		writeError(err, "couldn't unmarshall user request", http.StatusBadRequest, w)
-		return
-	}
-
-	if userRQ.Email == "" {
-		writeError(nil, "user email cannot be empty", http.StatusBadRequest, w)
+		respondWithError(w, r, err)
 		return
 	}
 
-	if userRQ.Password == "" {
-		writeError(nil, "user password cannot be empty", http.StatusBadRequest, w)
+	err = userRQ.validate()
+	if err != nil {
+		respondWithError(w, r, err)
 		return
 	}
 
 	hashedPassword, err := auth.HashPassword(userRQ.Password)
 	if err != nil {
-		writeError(err, "couldn't hash password", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't hash password: %w", err))
 		return
 	}
 
-	user, err := cfg.db.Createuser(r.Context(), database.CreateuserParams{Email: userRQ.Email, HashedPassword: hashedPassword})
+	user, err := cfg.db.Createuser(r.Context(), database.CreateuserParams{
+		Email:          userRQ.Email,
+		HashedPassword: hashedPassword,
+		Username:       userRQ.username(),
+	})
 	if err != nil {
-		writeError(err, "couldn't create user", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't create user: %w", err))
 		return
 	}
 
@@ -69,11 +96,12 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		CreatedAt:   user.CreatedAt,
 		UpdatedAt:   user.UpdatedAt,
 		Email:       user.Email,
+		Username:    user.Username.String,
 		IsChirpyRed: user.IsChirpyRed.Bool,
 	}
 	responseBytes, err := json.Marshal(response)
 	if err != nil {
-		writeError(err, "couldn't marshall user response", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't marshal user response: %w", err))
 		return
 	}
 
@@ -83,52 +111,57 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 }
 
 func (cfg *apiConfig) handlerLoginUser(w http.ResponseWriter, r *http.Request) {
-	defer r.Body.Close()
-
-	requestBytes, err := io.ReadAll(r.Body)
+	var userRQ userRQ
+	err := decodeJSON(w, r, maxUserBodyBytes, &userRQ)
 	if err != nil {
-		writeError(err, "couldn't read request bytes", http.StatusInternalServerError, w)
+		respondWithError(w, r, err)
 		return
 	}
 
-	var userRQ userRQ
-	err = json.Unmarshal(requestBytes, &userRQ)
+	err = userRQ.validateLogin()
 	if err != nil {
-		writeError(err, "couldn't unmarshall user request", http.StatusBadRequest, w)
+		respondWithError(w, r, err)
 		return
 	}
 
-	if userRQ.Email == "" {
-		writeError(nil, "user email cannot be empty", http.StatusBadRequest, w)
+	user, err := cfg.db.GetUserByEmail(r.Context(), userRQ.Email)
+	if errors.Is(err, apperr.ErrNotFound) {
+		cfg.metrics.Login(metrics.LoginFailed)
+		respondWithError(w, r, apperr.Unauthorized("incorrect email or password", nil))
 		return
 	}
 
-	if userRQ.Password == "" {
-		writeError(nil, "user password cannot be empty", http.StatusBadRequest, w)
+	if err != nil {
+		respondWithError(w, r, fmt.Errorf("couldn't retrieve user: %w", err))
 		return
 	}
 
-	user, err := cfg.db.GetUserByEmail(r.Context(), userRQ.Email)
+	err = auth.CheckPasswordHash(user.HashedPassword, userRQ.Password)
 	if err != nil {
-		writeError(nil, "incorrect email or password", http.StatusUnauthorized, w)
+		cfg.metrics.Login(metrics.LoginFailed)
+		respondWithError(w, r, apperr.Unauthorized("incorrect email or password", nil))
 		return
 	}
 
-	err = auth.CheckPasswordHash(user.HashedPassword, userRQ.Password)
-	if err != nil {
-		writeError(nil, "incorrect email or password", http.StatusUnauthorized, w)
+	// checked after the password so the account state isn't revealed to
+	// anyone guessing
+	if user.DisabledAt.Valid {
+		cfg.metrics.Login(metrics.LoginFailed)
+		respondWithError(w, r, apperr.Forbidden("account disabled"))
 		return
 	}
 
-	token, err := auth.MakeJWT(user.ID, cfg.jwtSecret, time.Hour)
+	logging.SetUserId(r.Context(), user.ID)
+
+	token, err := auth.MakeJWT(user.ID, cfg.jwtSecret, cfg.accessTokenTTL)
 	if err != nil {
-		writeError(err, "couldn't create a token", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't create a token: %w", err))
 		return
 	}
 
 	randomString, err := auth.MakeRefreshToken()
 	if err != nil {
-		writeError(err, "couldn't generate random string", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't generate random string: %w", err))
 		return
 	}
 
@@ -136,11 +169,11 @@ func (cfg *apiConfig) handlerLoginUser(w http.ResponseWriter, r *http.Request) {
 		database.CreateRefreshTokenParams{
 			Token:     randomString,
 			UserID:    user.ID,
-			ExpiresAt: time.Now().Add(time.Hour * 24 * 60),
+			ExpiresAt: time.Now().Add(cfg.refreshTokenTTL),
 		},
 	)
 	if err != nil {
-		writeError(err, "couldn't create refresh token", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't create refresh token: %w", err))
 		return
 	}
 
@@ -149,6 +182,7 @@ func (cfg *apiConfig) handlerLoginUser(w http.ResponseWriter, r *http.Request) {
 		CreatedAt:    user.CreatedAt,
 		UpdatedAt:    user.UpdatedAt,
 		Email:        user.Email,
+		Username:     user.Username.String,
 		IsChirpyRed:  user.IsChirpyRed.Bool,
 		Token:        token,
 		RefreshToken: refreshToken.Token,
@@ -156,10 +190,12 @@ func (cfg *apiConfig) handlerLoginUser(w http.ResponseWriter, r *http.Request) {
 
 	responseBytes, err := json.Marshal(response)
 	if err != nil {
-		writeError(err, "couldn't marshal response", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't marshal response: %w", err))
 		return
 	}
 
+	cfg.metrics.Login(metrics.LoginSucceeded)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	w.Write(responseBytes)
@@ -168,33 +204,34 @@ func (cfg *apiConfig) handlerLoginUser(w http.ResponseWriter, r *http.Request) {
 func (cfg *apiConfig) handlerUpdateEmailAndPassword(w http.ResponseWriter, r *http.Request) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		writeError(err, "couldn't get bearer token", http.StatusUnauthorized, w)
+		respondWithError(w, r, apperr.Unauthorized("couldn't get bearer token", err))
 		return
 	}
 
-	userId, err := auth.ValidateJWT(token, os.Getenv("JWT_SECRET"))
+	userId, err := auth.ValidateJWT(token, cfg.jwtSecret)
 	if err != nil {
-		writeError(err, "token invalid", http.StatusUnauthorized, w)
+		respondWithError(w, r, apperr.Unauthorized("token invalid", err))
 		return
 	}
 
-	defer r.Body.Close()
-	requestBytes, err := io.ReadAll(r.Body)
+	logging.SetUserId(r.Context(), userId)
+
+	var userRQ userRQ
+	err = decodeJSON(w, r, maxUserBodyBytes, &userRQ)
 	if err != nil {
-		writeError(err, "couldn't read request bytes", http.StatusInternalServerError, w)
+		respondWithError(w, r, err)
 		return
 	}
 
-	var userRQ userRQ
-	err = json.Unmarshal(requestBytes, &userRQ)
+	err = userRQ.validate()
 	if err != nil {
-		writeError(err, "couldn't unmarshall request bytes", http.StatusInternalServerError, w)
+		respondWithError(w, r, err)
 		return
 	}
 
 	hashedPassword, err := auth.HashPassword(userRQ.Password)
 	if err != nil {
-		writeError(err, "couldn't hash password", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't hash password: %w", err))
 		return
 	}
 
@@ -202,17 +239,18 @@ func (cfg *apiConfig) handlerUpdateEmailAndPassword(w http.ResponseWriter, r *ht
 		database.UpdateUserEmailAndPasswordParams{
 			Email:          userRQ.Email,
 			HashedPassword: hashedPassword,
+			Username:       userRQ.username(),
 			ID:             userId,
 		},
 	)
 	if err != nil {
-		writeError(err, "couldn't update users data", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't update users data: %w", err))
 		return
 	}
 
-	user, err := cfg.db.GetUserByEmail(r.Context(), userRQ.Email)
+	user, err := cfg.db.GetUserById(r.Context(), userId)
 	if err != nil {
-		writeError(err, "couldn't retrieve user", http.StatusNotFound, w)
+		respondWithError(w, r, err)
 		return
 	}
 
@@ -221,12 +259,13 @@ func (cfg *apiConfig) handlerUpdateEmailAndPassword(w http.ResponseWriter, r *ht
 		CreatedAt:   user.CreatedAt,
 		UpdatedAt:   user.UpdatedAt,
 		Email:       user.Email,
+		Username:    user.Username.String,
 		IsChirpyRed: user.IsChirpyRed.Bool,
 	}
 
 	responseBytes, err := json.Marshal(userRes)
 	if err != nil {
-		writeError(err, "couldn't marshal response", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't marshal response: %w", err))
 		return
 	}
 
@@ -238,7 +277,8 @@ func (cfg *apiConfig) handlerUpdateEmailAndPassword(w http.ResponseWriter, r *ht
 func (cfg *apiConfig) handlerGetUsers(w http.ResponseWriter, r *http.Request) {
 	users, err := cfg.db.GetUsers(r.Context())
 	if err != nil {
-		writeError(err, "couldn't retrieve users", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't retrieve users: %w", err))
+		return
 	}
 
 	response := []userRes{}
@@ -249,6 +289,7 @@ func (cfg *apiConfig) handlerGetUsers(w http.ResponseWriter, r *http.Request) {
 				CreatedAt:   user.CreatedAt,
 				UpdatedAt:   user.UpdatedAt,
 				Email:       user.Email,
+				Username:    user.Username.String,
 				IsChirpyRed: user.IsChirpyRed.Bool,
 			},
 		)
@@ -256,7 +297,8 @@ func (cfg *apiConfig) handlerGetUsers(w http.ResponseWriter, r *http.Request) {
 
 	responseBytes, err := json.Marshal(response)
 	if err != nil {
-		writeError(err, "couldn't marshall users response", http.StatusInternalServerError, w)
+		respondWithError(w, r, fmt.Errorf("couldn't marshal users response: %w", err))
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
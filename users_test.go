@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/magicznykacpur/chirpy/internal/auth"
+)
+
+func TestHandlerCreateUser(t *testing.T) {
+	cfg := newTestConfig(t)
+
+	cases := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{name: "valid user", body: `{"email": "walt@example.com", "password": "heisenberg99"}`, expected: http.StatusCreated},
+		{name: "missing email", body: `{"password": "heisenberg99"}`, expected: http.StatusBadRequest},
+		{name: "missing password", body: `{"email": "jesse@example.com"}`, expected: http.StatusBadRequest},
+		{name: "malformed json", body: `{"email":`, expected: http.StatusBadRequest},
+		{name: "invalid email", body: `{"email": "walt at example.com", "password": "heisenberg99"}`, expected: http.StatusBadRequest},
+		{name: "weak password", body: `{"email": "jesse@example.com", "password": "heisenberg"}`, expected: http.StatusBadRequest},
+		{name: "duplicate email", body: `{"email": "walt@example.com", "password": "heisenberg99"}`, expected: http.StatusConflict},
+	}
+
+	for _, c := range cases {
+		w := doRequest(t, cfg, http.MethodPost, "/api/users", c.body, nil)
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+			continue
+		}
+
+		if c.expected != http.StatusCreated {
+			continue
+		}
+
+		var user userRes
+		if err := json.Unmarshal(w.Body.Bytes(), &user); err != nil {
+			t.Fatalf("%s: couldn't unmarshal response: %v", c.name, err)
+		}
+
+		if user.Email != "walt@example.com" || user.IsChirpyRed {
+			t.Errorf("%s: user mismatch --> %+v <--", c.name, user)
+		}
+
+		if user.Token != "" || user.RefreshToken != "" {
+			t.Errorf("%s: created user shouldn't carry tokens", c.name)
+		}
+	}
+}
+
+func TestHandlerLoginUser(t *testing.T) {
+	cfg := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com")
+
+	cases := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{name: "valid credentials", body: `{"email": "walt@example.com", "password": "` + testPassword + `"}`, expected: http.StatusOK},
+		{name: "wrong password", body: `{"email": "walt@example.com", "password": "wrong"}`, expected: http.StatusUnauthorized},
+		{name: "unknown email", body: `{"email": "jesse@example.com", "password": "` + testPassword + `"}`, expected: http.StatusUnauthorized},
+		{name: "missing email", body: `{"password": "` + testPassword + `"}`, expected: http.StatusBadRequest},
+		{name: "missing password", body: `{"email": "walt@example.com"}`, expected: http.StatusBadRequest},
+		{name: "malformed json", body: `{"email":`, expected: http.StatusBadRequest},
+	}
+
+	for _, c := range cases {
+		w := doRequest(t, cfg, http.MethodPost, "/api/login", c.body, nil)
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+			continue
+		}
+
+		if c.expected != http.StatusOK {
+			continue
+		}
+
+		var response userRes
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: couldn't unmarshal response: %v", c.name, err)
+		}
+
+		userId, err := auth.ValidateJWT(response.Token, testJWTSecret)
+		if err != nil {
+			t.Errorf("%s: returned token invalid: %v", c.name, err)
+		}
+
+		if userId != user.ID {
+			t.Errorf("%s: token subject mismatch --> %s != %s <--", c.name, userId, user.ID)
+		}
+
+		if len(response.RefreshToken) != 64 {
+			t.Errorf("%s: refresh token should be of length 64, but is %d", c.name, len(response.RefreshToken))
+		}
+	}
+}
+
+func TestHandlerUpdateEmailAndPassword(t *testing.T) {
+	cfg := newTestConfig(t)
+	user := createTestUser(t, cfg, "walt@example.com")
+	createTestUser(t, cfg, "jesse@example.com")
+
+	cases := []struct {
+		name     string
+		body     string
+		header   http.Header
+		expected int
+	}{
+		{
+			name:     "missing token",
+			body:     `{"email": "heisenberg@example.com", "password": "newPassword1"}`,
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "invalid token",
+			body:     `{"email": "heisenberg@example.com", "password": "newPassword1"}`,
+			header:   http.Header{"Authorization": {"Bearer not-a-jwt"}},
+			expected: http.StatusUnauthorized,
+		},
+		{
+			name:     "email taken",
+			body:     `{"email": "jesse@example.com", "password": "newPassword1"}`,
+			header:   bearerHeader(t, user.ID),
+			expected: http.StatusConflict,
+		},
+		{
+			name:     "empty email and password",
+			body:     `{"email": "", "password": ""}`,
+			header:   bearerHeader(t, user.ID),
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "weak password",
+			body:     `{"email": "heisenberg@example.com", "password": "short"}`,
+			header:   bearerHeader(t, user.ID),
+			expected: http.StatusBadRequest,
+		},
+		{
+			name:     "valid update",
+			body:     `{"email": "heisenberg@example.com", "password": "newPassword1"}`,
+			header:   bearerHeader(t, user.ID),
+			expected: http.StatusOK,
+		},
+	}
+
+	for _, c := range cases {
+		w := doRequest(t, cfg, http.MethodPut, "/api/users", c.body, c.header)
+		if w.Code != c.expected {
+			t.Errorf("%s: status mismatch --> %d != %d <--", c.name, w.Code, c.expected)
+		}
+	}
+
+	w := doRequest(t, cfg, http.MethodPost, "/api/login", `{"email": "heisenberg@example.com", "password": "newPassword1"}`, nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("login with updated credentials failed --> %d <--", w.Code)
+	}
+}
+
+func TestHandlerGetUsers(t *testing.T) {
+	cfg := newTestConfig(t)
+
+	w := doRequest(t, cfg, http.MethodGet, "/admin/users", "", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "[]" {
+		t.Errorf("empty users mismatch --> %d %s <--", w.Code, w.Body.String())
+	}
+
+	createTestUser(t, cfg, "walt@example.com")
+	createTestUser(t, cfg, "jesse@example.com")
+
+	w = doRequest(t, cfg, http.MethodGet, "/admin/users", "", nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("status mismatch --> %d != %d <--", w.Code, http.StatusOK)
+	}
+
+	var users []userRes
+	if err := json.Unmarshal(w.Body.Bytes(), &users); err != nil {
+		t.Fatalf("couldn't unmarshal response: %v", err)
+	}
+
+	if len(users) != 2 {
+		t.Errorf("users length mismatch --> %d != 2 <--", len(users))
+	}
+}
+
+func TestUserValidationReportsEveryField(t *testing.T) {
+	cfg := newTestConfig(t)
+
+	w := doRequest(t, cfg, http.MethodPost, "/api/users", `{"email": "walt", "password": "walt"}`, nil)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status mismatch --> %d != %d <--", w.Code, http.StatusBadRequest)
+	}
+
+	var problem problemRes
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("couldn't unmarshal problem: %v", err)
+	}
+
+	expected := []problemFieldRes{
+		{Field: "email", Message: "must be an email address like name@example.com"},
+		{Field: "password", Message: "too short, min 8 characters"},
+	}
+	if len(problem.Errors) != len(expected) {
+		t.Fatalf("errors mismatch --> %+v != %+v <--", problem.Errors, expected)
+	}
+	for i := range expected {
+		if problem.Errors[i] != expected[i] {
+			t.Errorf("error mismatch --> %+v != %+v <--", problem.Errors[i], expected[i])
+		}
+	}
+}
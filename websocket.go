@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/magicznykacpur/chirpy/internal/apperr"
+	"github.com/magicznykacpur/chirpy/internal/auth"
+	"github.com/magicznykacpur/chirpy/internal/logging"
+	"github.com/magicznykacpur/chirpy/internal/metrics"
+	"github.com/magicznykacpur/chirpy/internal/stream"
+)
+
+// Websocket limits, client messages are small subscribe requests.
+const (
+	wsMaxMessageBytes = 4096
+	wsMaxChannels     = 100
+)
+
+// wsCloseTokenExpired closes connections whose access token expired, the
+// client should refresh it and reconnect. 4000-4999 are for applications.
+const wsCloseTokenExpired = 4001
+
+// Channels a connection can subscribe to, user and thread take an id after
+// a colon. notifications is the connected user's own.
+const (
+	wsChannelTimeline      = "timeline"
+	wsChannelUser          = "user"
+	wsChannelThread        = "thread"
+	wsChannelNotifications = "notifications"
+)
+
+// Message types, besides the stream.Event types.
+const (
+	wsSubscribe    = "subscribe"
+	wsUnsubscribe  = "unsubscribe"
+	wsReady        = "ready"
+	wsSubscribed   = "subscribed"
+	wsUnsubscribed = "unsubscribed"
+	wsError        = "error"
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// wsRequest is a message from the client, Id is echoed in the reply.
+type wsRequest struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+	Id      string `json:"id,omitempty"`
+}
+
+// wsMessage is a message to the client, either a reply to a request or an
+// event on the Channels it matched.
+type wsMessage struct {
+	Type      string          `json:"type"`
+	Id        string          `json:"id,omitempty"`
+	Channel   string          `json:"channel,omitempty"`
+	Channels  []string        `json:"channels,omitempty"`
+	EventId   string          `json:"event_id,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	UserId    string          `json:"user_id,omitempty"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+}
+
+// handlerWebSocket upgrades to a websocket that delivers the chirp events of
+// the channels the client subscribes to and the user's notifications. The
+// connection is pinged every streamHeartbeat and closed when the access
+// token expires, when the client stops answering or when it falls further
+// behind than the broker buffers.
+func (cfg *apiConfig) handlerWebSocket(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, r, apperr.Unauthorized("couldn't get bearer token", err))
+		return
+	}
+
+	userId, expiresAt, err := auth.ValidateJWTWithExpiry(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, r, apperr.Unauthorized("token invalid", err))
+		return
+	}
+
+	logging.SetUserId(r.Context(), userId)
+
+	// the upgrader has already answered with an error
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	subscription, _ := cfg.broker.SubscribeAs(userId, uuid.Nil, "")
+	defer subscription.Close()
+
+	cfg.metrics.StreamOpened(metrics.TransportWebSocket)
+	defer func() { cfg.metrics.StreamClosed(metrics.TransportWebSocket, subscription.Lagged()) }()
+
+	ws := &wsConn{
+		conn:      conn,
+		userId:    userId,
+		writeWait: cfg.streamWriteTimeout,
+		channels:  map[string]wsChannel{},
+	}
+
+	code, reason := ws.serve(subscription, cfg.streamHeartbeat, userId, expiresAt)
+	ws.close(code, reason)
+	slog.InfoContext(r.Context(), "websocket closed", "code", code, "reason", reason)
+}
+
+// wsChannel is a parsed channel name.
+type wsChannel struct {
+	kind string
+	id   uuid.UUID
+}
+
+func parseWsChannel(name string) (wsChannel, error) {
+	kind, rawId, hasId := strings.Cut(name, ":")
+	switch {
+	case (kind == wsChannelTimeline || kind == wsChannelNotifications) && !hasId:
+		return wsChannel{kind: kind}, nil
+	case kind == wsChannelUser || kind == wsChannelThread:
+		id, err := uuid.Parse(rawId)
+		if err != nil {
+			return wsChannel{}, fmt.Errorf("%s channels need an id, like %s:<uuid>", kind, kind)
+		}
+		return wsChannel{kind: kind, id: id}, nil
+	default:
+		return wsChannel{}, fmt.Errorf("unknown channel %q, use timeline, user:<id>, thread:<id> or notifications", name)
+	}
+}
+
+func (c wsChannel) String() string {
+	if c.kind == wsChannelTimeline || c.kind == wsChannelNotifications {
+		return c.kind
+	}
+	return c.kind + ":" + c.id.String()
+}
+
+// matches reports whether event belongs on the channel. Chirps can't reply
+// to each other yet, so a thread is just its first chirp. A notifications
+// channel holds the id of the user that subscribed to it.
+func (c wsChannel) matches(event stream.Event) bool {
+	if event.Private() {
+		return c.kind == wsChannelNotifications && event.UserID == c.id
+	}
+
+	switch c.kind {
+	case wsChannelTimeline:
+		return true
+	case wsChannelUser:
+		return event.UserID == c.id
+	case wsChannelThread:
+		return event.ChirpID == c.id
+	default:
+		return false
+	}
+}
+
+// wsConn is one websocket. serve is the only writer, the reads happen on
+// their own goroutine as gorilla/websocket allows one of each.
+type wsConn struct {
+	conn      *websocket.Conn
+	userId    uuid.UUID
+	writeWait time.Duration
+	// channels is keyed by the channel name, serve owns it
+	channels map[string]wsChannel
+}
+
+// serve runs the connection until it has to close and returns the close
+// code and reason.
+func (ws *wsConn) serve(subscription *stream.Subscription, pingInterval time.Duration, userId uuid.UUID, expiresAt time.Time) (int, string) {
+	requests := make(chan wsRequest)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+
+	// a pong must come back before the next ping is due
+	pongWait := 2 * pingInterval
+	ws.conn.SetReadLimit(wsMaxMessageBytes)
+	ws.conn.SetReadDeadline(time.Now().Add(pongWait))
+	ws.conn.SetPongHandler(func(string) error {
+		return ws.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	go func() {
+		for {
+			_, data, err := ws.conn.ReadMessage()
+			if err != nil {
+				readErr <- err
+				return
+			}
+
+			// malformed requests get an error reply, handle rejects the
+			// empty type
+			var request wsRequest
+			json.Unmarshal(data, &request)
+
+			select {
+			case requests <- request:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	// a token without expiry never fires
+	var expired <-chan time.Time
+	if !expiresAt.IsZero() {
+		timer := time.NewTimer(time.Until(expiresAt))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	ready := wsMessage{Type: wsReady, UserId: userId.String()}
+	if !expiresAt.IsZero() {
+		ready.ExpiresAt = &expiresAt
+	}
+	err := ws.write(ready)
+
+	for err == nil {
+		select {
+		case <-expired:
+			return wsCloseTokenExpired, "access token expired"
+		case err := <-readErr:
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return websocket.CloseNormalClosure, ""
+			}
+			if errors.Is(err, websocket.ErrReadLimit) {
+				return websocket.CloseMessageTooBig, "message too big"
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return websocket.CloseGoingAway, "no pong"
+			}
+			return websocket.CloseGoingAway, "read failed"
+		case request := <-requests:
+			err = ws.write(ws.handle(request))
+		case event, ok := <-subscription.Events():
+			if !ok && subscription.Lagged() {
+				return websocket.CloseTryAgainLater, "fell behind, reconnect"
+			}
+			if !ok {
+				return websocket.CloseGoingAway, "server shutting down"
+			}
+			err = ws.deliver(event)
+		case <-ping.C:
+			err = ws.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(ws.writeWait))
+		}
+	}
+
+	return websocket.CloseGoingAway, "write failed"
+}
+
+// handle applies a subscribe or unsubscribe request and returns the reply.
+func (ws *wsConn) handle(request wsRequest) wsMessage {
+	reply := wsMessage{Id: request.Id, Channel: request.Channel}
+
+	if request.Type != wsSubscribe && request.Type != wsUnsubscribe {
+		reply.Type = wsError
+		reply.Error = "messages must be JSON objects with a type of subscribe or unsubscribe"
+		return reply
+	}
+
+	channel, err := parseWsChannel(request.Channel)
+	if err != nil {
+		reply.Type = wsError
+		reply.Error = err.Error()
+		return reply
+	}
+	if channel.kind == wsChannelNotifications {
+		channel.id = ws.userId
+	}
+	reply.Channel = channel.String()
+
+	if request.Type == wsUnsubscribe {
+		delete(ws.channels, channel.String())
+		reply.Type = wsUnsubscribed
+		return reply
+	}
+
+	if _, ok := ws.channels[channel.String()]; !ok && len(ws.channels) >= wsMaxChannels {
+		reply.Type = wsError
+		reply.Error = fmt.Sprintf("at most %d channels per connection", wsMaxChannels)
+		return reply
+	}
+
+	ws.channels[channel.String()] = channel
+	reply.Type = wsSubscribed
+	return reply
+}
+
+// deliver sends event once, naming every subscribed channel it matched.
+func (ws *wsConn) deliver(event stream.Event) error {
+	var matched []string
+	for name, channel := range ws.channels {
+		if channel.matches(event) {
+			matched = append(matched, name)
+		}
+	}
+
+	if len(matched) == 0 {
+		return nil
+	}
+	slices.Sort(matched)
+
+	return ws.write(wsMessage{Type: event.Type, Channels: matched, EventId: event.ID, Data: event.Data})
+}
+
+func (ws *wsConn) write(message wsMessage) error {
+	ws.conn.SetWriteDeadline(time.Now().Add(ws.writeWait))
+	return ws.conn.WriteJSON(message)
+}
+
+// close says goodbye with code, the connection may already be gone.
+func (ws *wsConn) close(code int, reason string) {
+	message := websocket.FormatCloseMessage(code, reason)
+	ws.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(ws.writeWait))
+}
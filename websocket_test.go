@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/magicznykacpur/chirpy/internal/auth"
+	"github.com/magicznykacpur/chirpy/internal/database"
+)
+
+// dialWebSocket connects to the websocket of server with token and reads
+// the ready message.
+func dialWebSocket(t *testing.T, server *httptest.Server, token string) *websocket.Conn {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws"
+	conn, res, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer " + token}})
+	if err != nil {
+		t.Fatalf("couldn't dial websocket: %v %v", res, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	var ready wsMessage
+	readWebSocket(t, conn, &ready)
+	if ready.Type != wsReady {
+		t.Fatalf("ready message mismatch --> %+v <--", ready)
+	}
+
+	return conn
+}
+
+func readWebSocket(t *testing.T, conn *websocket.Conn, message *wsMessage) {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	err := conn.ReadJSON(message)
+	if err != nil {
+		t.Fatalf("couldn't read message: %v", err)
+	}
+}
+
+func TestHandlerWebSocket(t *testing.T) {
+	cfg := newTestConfig(t)
+	server := httptest.NewServer(cfg.routes())
+	t.Cleanup(server.Close)
+
+	walt := createTestUser(t, cfg, "walt@example.com")
+	jesse := createTestUser(t, cfg, "jesse@example.com")
+	chirp := createTestChirp(t, cfg, jesse.ID, "Yeah, science!")
+
+	token, _ := auth.MakeJWT(walt.ID, testJWTSecret, time.Hour)
+	conn := dialWebSocket(t, server, token)
+
+	cases := []struct {
+		request  wsRequest
+		expected wsMessage
+	}{
+		{
+			request:  wsRequest{Type: wsSubscribe, Channel: "user:" + walt.ID.String(), Id: "1"},
+			expected: wsMessage{Type: wsSubscribed, Channel: "user:" + walt.ID.String(), Id: "1"},
+		},
+		{
+			request:  wsRequest{Type: wsSubscribe, Channel: "thread:" + strings.ToUpper(chirp.ID.String())},
+			expected: wsMessage{Type: wsSubscribed, Channel: "thread:" + chirp.ID.String()},
+		},
+		{
+			request:  wsRequest{Type: wsSubscribe, Channel: "user:walt"},
+			expected: wsMessage{Type: wsError, Channel: "user:walt"},
+		},
+		{
+			request:  wsRequest{Type: wsSubscribe, Channel: "likes"},
+			expected: wsMessage{Type: wsError, Channel: "likes"},
+		},
+		{
+			request:  wsRequest{Type: "follow", Channel: wsChannelTimeline},
+			expected: wsMessage{Type: wsError, Channel: wsChannelTimeline},
+		},
+	}
+
+	for _, c := range cases {
+		err := conn.WriteJSON(c.request)
+		if err != nil {
+			t.Fatalf("couldn't write request: %v", err)
+		}
+
+		var reply wsMessage
+		readWebSocket(t, conn, &reply)
+		if reply.Type != c.expected.Type || reply.Channel != c.expected.Channel || reply.Id != c.expected.Id {
+			t.Errorf("%+v: reply mismatch --> %+v != %+v <--", c.request, reply, c.expected)
+		}
+		if reply.Type == wsError && reply.Error == "" {
+			t.Errorf("%+v: error reply without a message", c.request)
+		}
+	}
+
+	conn.WriteMessage(websocket.TextMessage, []byte("not json"))
+	var reply wsMessage
+	readWebSocket(t, conn, &reply)
+	if reply.Type != wsError {
+		t.Errorf("malformed request reply mismatch --> %+v <--", reply)
+	}
+
+	// jesse's new chirp matches no channel, deleting the thread's chirp does
+	doRequest(t, cfg, http.MethodPost, "/api/chirps", `{"body": "Mr. White!"}`, bearerHeader(t, jesse.ID))
+	doRequest(t, cfg, http.MethodDelete, "/api/chirps/"+chirp.ID.String(), "", bearerHeader(t, jesse.ID))
+	doRequest(t, cfg, http.MethodPost, "/api/chirps", `{"body": "Say my name"}`, bearerHeader(t, walt.ID))
+
+	var event wsMessage
+	readWebSocket(t, conn, &event)
+	if event.Type != "chirp.deleted" || strings.Join(event.Channels, ",") != "thread:"+chirp.ID.String() || event.EventId == "" {
+		t.Errorf("deleted event mismatch --> %+v <--", event)
+	}
+
+	readWebSocket(t, conn, &event)
+	if event.Type != "chirp.created" || !strings.Contains(string(event.Data), "Say my name") {
+		t.Errorf("created event mismatch --> %+v <--", event)
+	}
+
+	conn.WriteJSON(wsRequest{Type: wsSubscribe, Channel: wsChannelTimeline})
+	readWebSocket(t, conn, &reply)
+	doRequest(t, cfg, http.MethodPost, "/api/chirps", `{"body": "I am the one who knocks"}`, bearerHeader(t, walt.ID))
+
+	readWebSocket(t, conn, &event)
+	if strings.Join(event.Channels, ",") != "timeline,user:"+walt.ID.String() {
+		t.Errorf("event should be sent once for every matched channel --> %+v <--", event)
+	}
+
+	// shutting down closes the connection cleanly
+	cfg.broker.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("shutdown close mismatch --> %v <--", err)
+	}
+}
+
+func TestHandlerWebSocketNotifications(t *testing.T) {
+	cfg := newTestConfig(t)
+	server := httptest.NewServer(cfg.routes())
+	t.Cleanup(server.Close)
+
+	walt := createTestUser(t, cfg, "walt@example.com")
+	jesse := createTestUser(t, cfg, "jesse@example.com")
+	err := cfg.db.UpdateUserProfile(context.Background(), database.UpdateUserProfileParams{
+		Username: sql.NullString{String: "heisenberg", Valid: true},
+		ID:       walt.ID,
+	})
+	if err != nil {
+		t.Fatalf("couldn't set username: %v", err)
+	}
+
+	waltToken, _ := auth.MakeJWT(walt.ID, testJWTSecret, time.Hour)
+	jesseToken, _ := auth.MakeJWT(jesse.ID, testJWTSecret, time.Hour)
+	waltConn := dialWebSocket(t, server, waltToken)
+	jesseConn := dialWebSocket(t, server, jesseToken)
+
+	var reply wsMessage
+	waltConn.WriteJSON(wsRequest{Type: wsSubscribe, Channel: wsChannelNotifications})
+	readWebSocket(t, waltConn, &reply)
+	if reply.Type != wsSubscribed || reply.Channel != wsChannelNotifications {
+		t.Fatalf("subscribe reply mismatch --> %+v <--", reply)
+	}
+	for _, channel := range []string{wsChannelNotifications, wsChannelTimeline, "user:" + walt.ID.String()} {
+		jesseConn.WriteJSON(wsRequest{Type: wsSubscribe, Channel: channel})
+		readWebSocket(t, jesseConn, &reply)
+	}
+
+	doRequest(t, cfg, http.MethodPost, "/api/chirps", `{"body": "Yo @heisenberg"}`, bearerHeader(t, jesse.ID))
+
+	var event wsMessage
+	readWebSocket(t, waltConn, &event)
+	var notification notificationRes
+	if err := json.Unmarshal(event.Data, &notification); err != nil {
+		t.Fatalf("couldn't unmarshal notification: %v", err)
+	}
+	if event.Type != "notification.created" || strings.Join(event.Channels, ",") != wsChannelNotifications ||
+		notification.Kind != notificationMention || notification.ActorId != jesse.ID.String() {
+		t.Errorf("notification event mismatch --> %+v %+v <--", event, notification)
+	}
+
+	// jesse only sees the chirp, not walt's notification, even on walt's
+	// user channel
+	doRequest(t, cfg, http.MethodPost, "/api/chirps", `{"body": "Say my name"}`, bearerHeader(t, walt.ID))
+	for _, expected := range []string{"Yo @heisenberg", "Say my name"} {
+		readWebSocket(t, jesseConn, &event)
+		if event.Type != "chirp.created" || !strings.Contains(string(event.Data), expected) {
+			t.Errorf("jesse's event mismatch --> %+v != %s <--", event, expected)
+		}
+	}
+}
+
+func TestHandlerWebSocketUnauthorized(t *testing.T) {
+	cfg := newTestConfig(t)
+	server := httptest.NewServer(cfg.routes())
+	t.Cleanup(server.Close)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws"
+	for _, header := range []http.Header{nil, {"Authorization": {"Bearer nope"}}} {
+		_, res, err := websocket.DefaultDialer.Dial(url, header)
+		if err == nil || res.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%v: dial mismatch --> %v %v <--", header, res, err)
+		}
+	}
+}
+
+func TestHandlerWebSocketCloses(t *testing.T) {
+	userId := uuid.New()
+
+	t.Run("token expired", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		server := httptest.NewServer(cfg.routes())
+		t.Cleanup(server.Close)
+
+		// the expiry claim has second precision
+		token, _ := auth.MakeJWT(userId, testJWTSecret, 1500*time.Millisecond)
+		conn := dialWebSocket(t, server, token)
+
+		conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		_, _, err := conn.ReadMessage()
+		if !websocket.IsCloseError(err, wsCloseTokenExpired) {
+			t.Errorf("close mismatch --> %v <--", err)
+		}
+	})
+
+	cfg := newTestConfig(t)
+	cfg.streamHeartbeat = 100 * time.Millisecond
+	server := httptest.NewServer(cfg.routes())
+	t.Cleanup(server.Close)
+
+	t.Run("pings are answered", func(t *testing.T) {
+		token, _ := auth.MakeJWT(userId, testJWTSecret, time.Hour)
+		conn := dialWebSocket(t, server, token)
+
+		pings := 0
+		conn.SetPingHandler(func(data string) error {
+			pings++
+			return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+		})
+
+		// reading runs the ping handler, the server keeps the connection
+		// open well past its pong wait
+		conn.SetReadDeadline(time.Now().Add(10 * cfg.streamHeartbeat))
+		_, _, err := conn.ReadMessage()
+		if websocket.IsCloseError(err, websocket.CloseGoingAway) || pings < 5 {
+			t.Errorf("keepalive mismatch --> %d pings, %v <--", pings, err)
+		}
+	})
+
+	t.Run("silent clients are dropped", func(t *testing.T) {
+		token, _ := auth.MakeJWT(userId, testJWTSecret, time.Hour)
+		conn := dialWebSocket(t, server, token)
+		conn.SetPingHandler(func(string) error { return nil })
+
+		conn.SetReadDeadline(time.Now().Add(10 * cfg.streamHeartbeat))
+		_, _, err := conn.ReadMessage()
+
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseGoingAway || closeErr.Text != "no pong" {
+			t.Errorf("close mismatch --> %v <--", err)
+		}
+	})
+}